@@ -31,18 +31,9 @@ func main() {
 
 	handlers := api.New(temporalClient)
 
-	http.HandleFunc("/health", handlers.HealthCheck)
-	http.HandleFunc("/workflows", handlers.ExecuteWorkflow)
-	http.HandleFunc("/workflows/json", handlers.ExecuteJSONWorkflow)
-	http.HandleFunc("/workflows/yaml", handlers.ExecuteYAMLWorkflow)
-	http.HandleFunc("/workflows/state", handlers.GetWorkflowState)
-	http.HandleFunc("/chatbot/init", handlers.InitiateChatbot)
-	http.HandleFunc("/chatbot/message", handlers.SendChatMessage)
-	http.HandleFunc("/chatbot/thread", handlers.GetChatThread)
-	http.HandleFunc("/demo/", handlers.DemoHandler)
-
 	server := &http.Server{
-		Addr: ":8088",
+		Addr:    ":8088",
+		Handler: api.NewRouter(handlers),
 	}
 
 	go func() {
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+// ImportStateMachine translates the AWS Step Functions state machine (Amazon States Language JSON)
+// in the body to a DSL 1.0 workflow named ?name=, written as ?to=json or yaml (default: json).
+// Constructs this engine cannot execute are reported as issues.
+func (h *Handlers) ImportStateMachine(w http.ResponseWriter, r *http.Request) {
+	source, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = workflows.FormatJSON
+	}
+	result, err := workflows.ImportStateMachineSource(source, r.URL.Query().Get("name"), to)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
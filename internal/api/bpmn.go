@@ -0,0 +1,28 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+)
+
+// ExportBPMN exports the raw YAML or JSON workflow definition in the body as BPMN 2.0 XML with
+// diagram interchange layout, for review in BPMN modelling tools
+func (h *Handlers) ExportBPMN(w http.ResponseWriter, r *http.Request) {
+	source, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	workflowDef, err := parser.FromYAMLSource(source)
+	if err != nil {
+		writeError(w, "Invalid workflow definition: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+workflowDef.Document.Name+`.bpmn"`)
+	io.WriteString(w, workflows.ExportBPMN(workflowDef))
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+// chatStreamKeepAlive is how often an idle chat stream sends an SSE comment to keep proxies open
+const chatStreamKeepAlive = 15 * time.Second
+
+// StreamChatResponses relays the assistant's reply deltas for a thread as Server-Sent Events while
+// they are generated. The complete reply is persisted in the thread once generation finishes.
+func (h *Handlers) StreamChatResponses(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+
+	if _, err := h.temporal.DescribeWorkflowExecution(r.Context(), "chatbot-workflow-"+threadID, ""); err != nil {
+		log.Printf("Unable to describe chatbot workflow: %v", err)
+		writeError(w, "Failed to stream chat thread", temporalErrorStatus(err))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	subscription, err := workflows.SubscribeChatStream(r.Context(), threadID)
+	if err != nil {
+		log.Printf("Unable to subscribe to chat stream: %v", err)
+		writeError(w, "Failed to stream chat thread", http.StatusInternalServerError)
+		return
+	}
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(chatStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case message := <-subscription.Messages():
+			data, err := json.Marshal(message.Payload)
+			if err != nil {
+				log.Printf("Unable to encode chat stream event: %v", err)
+				continue
+			}
+			event, _ := message.Payload.(workflows.ChatStreamEvent)
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+// ConvertWorkflow converts the raw YAML or JSON workflow definition in the body to ?to=json or yaml.
+// The source's key order is kept unless ?normalize=true, which writes the definition canonically.
+func (h *Handlers) ConvertWorkflow(w http.ResponseWriter, r *http.Request) {
+	to := r.URL.Query().Get("to")
+	if to != workflows.FormatJSON && to != workflows.FormatYAML {
+		writeError(w, "to must be json or yaml", http.StatusBadRequest)
+		return
+	}
+
+	source, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	converted, err := workflows.ConvertWorkflow(source, to, r.URL.Query().Get("normalize") == "true")
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if to == workflows.FormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	w.Write(converted)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+	enumspb "go.temporal.io/api/enums/v1"
+	"golang.org/x/net/websocket"
+)
+
+// eventPollInterval is how often the workflow is queried for new events while streaming
+const eventPollInterval = time.Second
+
+// StreamWorkflowEvents streams the task lifecycle and status events of an execution as Server-Sent
+// Events, or as JSON messages when the request is a WebSocket upgrade. Streams resume after the
+// Last-Event-ID header (or ?last_event_id=) and end once the workflow has closed.
+func (h *Handlers) StreamWorkflowEvents(w http.ResponseWriter, r *http.Request) {
+	workflowID := r.PathValue("id")
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		var err error
+		afterID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || afterID < 0 {
+			writeError(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Fail fast for unknown executions before switching protocols
+	if _, err := h.temporal.DescribeWorkflowExecution(r.Context(), workflowID, ""); err != nil {
+		log.Printf("Unable to describe workflow: %v", err)
+		writeError(w, "Failed to stream workflow events", temporalErrorStatus(err))
+		return
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		websocket.Handler(func(conn *websocket.Conn) {
+			defer conn.Close()
+			err := h.streamEvents(conn.Request().Context(), workflowID, afterID, func(event workflows.ExecutionEvent) error {
+				return websocket.JSON.Send(conn, event)
+			})
+			if err != nil {
+				log.Printf("Workflow event stream ended: %v", err)
+			}
+		}).ServeHTTP(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	err := h.streamEvents(r.Context(), workflowID, afterID, func(event workflows.ExecutionEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Workflow event stream ended: %v", err)
+		fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+		flusher.Flush()
+	}
+}
+
+// streamEvents polls the workflow for events after afterID and sends them in order until a
+// terminal event is sent, the workflow closes or the context is done. Events are fetched a page at
+// a time; a full page is followed by the next without waiting for the poll interval.
+func (h *Handlers) streamEvents(ctx context.Context, workflowID string, afterID int64, send func(workflows.ExecutionEvent) error) error {
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		resp, err := h.temporal.QueryWorkflow(ctx, workflowID, "", workflows.WorkflowEventsQuery, afterID, workflows.WorkflowEventsPageSize)
+		if err != nil {
+			return fmt.Errorf("query workflow events: %w", err)
+		}
+		var events []workflows.ExecutionEvent
+		if err := resp.Get(&events); err != nil {
+			return fmt.Errorf("decode workflow events: %w", err)
+		}
+
+		for _, event := range events {
+			if err := send(event); err != nil {
+				return err
+			}
+			afterID = event.ID
+			if workflows.IsTerminalEvent(event) {
+				return nil
+			}
+		}
+
+		// Executions terminated or timed out by Temporal never record a terminal event
+		if len(events) == 0 {
+			description, err := h.temporal.DescribeWorkflowExecution(ctx, workflowID, "")
+			if err != nil {
+				return fmt.Errorf("describe workflow: %w", err)
+			}
+			if description.GetWorkflowExecutionInfo().GetStatus() != enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING {
+				return nil
+			}
+		}
+		if len(events) == workflows.WorkflowEventsPageSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
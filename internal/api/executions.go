@@ -0,0 +1,231 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+const (
+	defaultListPageSize = 20
+	maxListPageSize     = 100
+)
+
+// executionStatuses maps the status filter values onto Temporal visibility statuses
+var executionStatuses = map[string]string{
+	"running":          "Running",
+	"completed":        "Completed",
+	"failed":           "Failed",
+	"canceled":         "Canceled",
+	"cancelled":        "Canceled",
+	"terminated":       "Terminated",
+	"timed_out":        "TimedOut",
+	"continued_as_new": "ContinuedAsNew",
+}
+
+// ExecutionDefinition identifies the serverless workflow definition an execution runs
+type ExecutionDefinition struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// ExecutionSummary is a single entry of the execution listing
+type ExecutionSummary struct {
+	WorkflowID string               `json:"workflow_id"`
+	RunID      string               `json:"run_id"`
+	Type       string               `json:"type"`
+	Status     string               `json:"status"`
+	Kind       string               `json:"kind,omitempty"`
+	Definition *ExecutionDefinition `json:"definition,omitempty"`
+	StartTime  *time.Time           `json:"start_time,omitempty"`
+	CloseTime  *time.Time           `json:"close_time,omitempty"`
+}
+
+// ListWorkflows lists serverless workflow executions and chatbot threads from Temporal visibility.
+// Supported filters: status, kind, namespace, name, version, started_after, started_before and q
+// (workflow ID prefix). Pages are requested with page_size and the returned next_cursor.
+func (h *Handlers) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query, err := buildExecutionQuery(params)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pageSize := defaultListPageSize
+	if raw := params.Get("page_size"); raw != "" {
+		pageSize, err = strconv.Atoi(raw)
+		if err != nil || pageSize < 1 {
+			writeError(w, "page_size must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		pageSize = min(pageSize, maxListPageSize)
+	}
+
+	var pageToken []byte
+	if cursor := params.Get("cursor"); cursor != "" {
+		pageToken, err = base64.URLEncoding.DecodeString(cursor)
+		if err != nil {
+			writeError(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := h.temporal.ListWorkflow(r.Context(), &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize:      int32(pageSize),
+		NextPageToken: pageToken,
+		Query:         query,
+	})
+	if err != nil {
+		log.Printf("Unable to list workflows: %v", err)
+		writeError(w, "Failed to list workflows", temporalErrorStatus(err))
+		return
+	}
+
+	executions := make([]ExecutionSummary, 0, len(resp.GetExecutions()))
+	for _, info := range resp.GetExecutions() {
+		executions = append(executions, executionSummary(info))
+	}
+
+	var nextCursor string
+	if len(resp.GetNextPageToken()) > 0 {
+		nextCursor = base64.URLEncoding.EncodeToString(resp.GetNextPageToken())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"executions":  executions,
+		"next_cursor": nextCursor,
+	})
+}
+
+// buildExecutionQuery translates the listing filters into a Temporal visibility query
+func buildExecutionQuery(params url.Values) (string, error) {
+	var clauses []string
+
+	if status := params.Get("status"); status != "" {
+		executionStatus, ok := executionStatuses[strings.ToLower(status)]
+		if !ok {
+			return "", fmt.Errorf("unknown status %q", status)
+		}
+		clauses = append(clauses, fmt.Sprintf("ExecutionStatus = '%s'", executionStatus))
+	}
+
+	if kind := params.Get("kind"); kind != "" {
+		clauses = append(clauses, fmt.Sprintf("SwKind = %s", quoteQueryValue(kind)))
+	}
+	if namespace := params.Get("namespace"); namespace != "" {
+		clauses = append(clauses, fmt.Sprintf("SwDefinitionNamespace = %s", quoteQueryValue(namespace)))
+	}
+	if name := params.Get("name"); name != "" {
+		clauses = append(clauses, fmt.Sprintf("SwDefinitionName = %s", quoteQueryValue(name)))
+	}
+	if version := params.Get("version"); version != "" {
+		clauses = append(clauses, fmt.Sprintf("SwDefinitionVersion = %s", quoteQueryValue(version)))
+	}
+
+	for _, bound := range []struct{ param, operator string }{{"started_after", ">="}, {"started_before", "<="}} {
+		raw := params.Get(bound.param)
+		if raw == "" {
+			continue
+		}
+		startTime, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return "", fmt.Errorf("%s must be an RFC 3339 timestamp", bound.param)
+		}
+		clauses = append(clauses, fmt.Sprintf("StartTime %s '%s'", bound.operator, startTime.UTC().Format(time.RFC3339Nano)))
+	}
+
+	if q := params.Get("q"); q != "" {
+		// Workflow IDs carry a kind prefix, so match the free text against each of them
+		prefixes := []string{q}
+		for _, prefix := range []string{"serverless-workflow-", "json-workflow-", "yaml-workflow-", "chatbot-workflow-"} {
+			if !strings.HasPrefix(q, prefix) {
+				prefixes = append(prefixes, prefix+q)
+			}
+		}
+		var idClauses []string
+		for _, prefix := range prefixes {
+			idClauses = append(idClauses, fmt.Sprintf("WorkflowId STARTS_WITH %s", quoteQueryValue(prefix)))
+		}
+		clauses = append(clauses, "("+strings.Join(idClauses, " OR ")+")")
+	}
+
+	return strings.Join(clauses, " AND "), nil
+}
+
+// quoteQueryValue quotes a value for use in a visibility query; backslashes are escaped first so
+// they cannot escape the closing quote
+func quoteQueryValue(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	return "'" + strings.ReplaceAll(value, "'", "\\'") + "'"
+}
+
+// executionSummary builds a listing entry from visibility data
+func executionSummary(info *workflowpb.WorkflowExecutionInfo) ExecutionSummary {
+	summary := ExecutionSummary{
+		WorkflowID: info.GetExecution().GetWorkflowId(),
+		RunID:      info.GetExecution().GetRunId(),
+		Type:       info.GetType().GetName(),
+		Status:     executionStatusName(info.GetStatus()),
+	}
+	if info.GetStartTime() != nil {
+		startTime := info.GetStartTime().AsTime()
+		summary.StartTime = &startTime
+	}
+	if info.GetCloseTime() != nil {
+		closeTime := info.GetCloseTime().AsTime()
+		summary.CloseTime = &closeTime
+	}
+
+	attributes := decodeSearchAttributes(info.GetSearchAttributes())
+	summary.Kind = attributes[workflows.SearchAttributeKind.GetName()]
+	definition := ExecutionDefinition{
+		Namespace: attributes[workflows.SearchAttributeDefinitionNamespace.GetName()],
+		Name:      attributes[workflows.SearchAttributeDefinitionName.GetName()],
+		Version:   attributes[workflows.SearchAttributeDefinitionVersion.GetName()],
+	}
+	if definition != (ExecutionDefinition{}) {
+		summary.Definition = &definition
+	}
+	return summary
+}
+
+// executionStatusName returns the status filter value of a visibility status, e.g. "timed_out"
+func executionStatusName(status enumspb.WorkflowExecutionStatus) string {
+	switch status {
+	case enumspb.WORKFLOW_EXECUTION_STATUS_TIMED_OUT:
+		return "timed_out"
+	case enumspb.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW:
+		return "continued_as_new"
+	default:
+		return strings.ToLower(status.String())
+	}
+}
+
+// decodeSearchAttributes decodes the keyword search attributes of an execution
+func decodeSearchAttributes(searchAttributes *commonpb.SearchAttributes) map[string]string {
+	attributes := make(map[string]string)
+	for name, payload := range searchAttributes.GetIndexedFields() {
+		var value string
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &value); err == nil {
+			attributes[name] = value
+		}
+	}
+	return attributes
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuildExecutionQuery(t *testing.T) {
+	params := url.Values{}
+	params.Set("status", "running")
+	params.Set("kind", "serverless")
+	params.Set("name", "order's")
+	params.Set("started_after", "2024-01-02T03:04:05Z")
+	params.Set("q", "abc")
+
+	query, err := buildExecutionQuery(params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "ExecutionStatus = 'Running' AND SwKind = 'serverless' AND SwDefinitionName = 'order\\'s'" +
+		" AND StartTime >= '2024-01-02T03:04:05Z'" +
+		" AND (WorkflowId STARTS_WITH 'abc' OR WorkflowId STARTS_WITH 'serverless-workflow-abc'" +
+		" OR WorkflowId STARTS_WITH 'json-workflow-abc' OR WorkflowId STARTS_WITH 'yaml-workflow-abc'" +
+		" OR WorkflowId STARTS_WITH 'chatbot-workflow-abc')"
+	if query != expected {
+		t.Errorf("Expected query\n%s\ngot\n%s", expected, query)
+	}
+}
+
+func TestQuoteQueryValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{`plain`, `'plain'`},
+		{`order's`, `'order\'s'`},
+		{`trailing\`, `'trailing\\'`},
+		{`\'`, `'\\\''`},
+	}
+
+	for _, tt := range tests {
+		if result := quoteQueryValue(tt.value); result != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, result)
+		}
+	}
+}
+
+func TestBuildExecutionQueryRejectsInvalidFilters(t *testing.T) {
+	for _, params := range []url.Values{
+		{"status": {"sleeping"}},
+		{"started_before": {"yesterday"}},
+	} {
+		if _, err := buildExecutionQuery(params); err == nil {
+			t.Errorf("Expected an error for %v", params)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+)
+
+// GetWorkflowGraph renders the control flow graph of the raw YAML or JSON workflow definition in the
+// body as ?format=json (default), mermaid or dot. Task nodes are identified by their task reference.
+func (h *Handlers) GetWorkflowGraph(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = workflows.GraphFormatJSON
+	}
+	if format != workflows.GraphFormatJSON && format != workflows.GraphFormatMermaid && format != workflows.GraphFormatDOT {
+		writeError(w, "format must be json, mermaid or dot", http.StatusBadRequest)
+		return
+	}
+
+	source, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	workflowDef, err := parser.FromYAMLSource(source)
+	if err != nil {
+		writeError(w, "Invalid workflow definition: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	graph := workflows.BuildWorkflowGraph(workflowDef)
+	switch format {
+	case workflows.GraphFormatMermaid:
+		w.Header().Set("Content-Type", "text/vnd.mermaid; charset=utf-8")
+		io.WriteString(w, graph.Mermaid())
+	case workflows.GraphFormatDOT:
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		io.WriteString(w, graph.DOT())
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(graph)
+	}
+}
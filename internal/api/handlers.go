@@ -1,7 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"math/rand"
@@ -10,7 +13,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
 )
 
 type Handlers struct {
@@ -27,80 +32,103 @@ func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handlers) ExecuteWorkflow(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	// Read the raw JSON payload
+	workflowJSONBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 
-	// Read the raw JSON payload
-	workflowJSONBytes, err := io.ReadAll(r.Body)
+	workflowJSONBytes, migrationIssues, ok := migrateIfRequested(w, r, workflowJSONBytes, workflows.FormatYAML)
+	if !ok {
+		return
+	}
+
+	input, err := workflowInput(r)
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	options := client.StartWorkflowOptions{
-		ID:        "serverless-workflow-" + uuid.New().String(),
-		TaskQueue: "serverless-workflow-task-queue",
+		ID:                    "serverless-workflow-" + uuid.New().String(),
+		TaskQueue:             "serverless-workflow-task-queue",
+		TypedSearchAttributes: workflows.ServerlessSearchAttributes(workflowJSONBytes),
 	}
 
-	wfRun, err := h.temporal.ExecuteWorkflow(r.Context(), options, workflows.ExecuteServerlessYAMLWorkflow, string(workflowJSONBytes))
+	wfRun, err := h.startWorkflow(r.Context(), options, workflows.ExecuteServerlessYAMLWorkflow, string(workflowJSONBytes), input)
 	if err != nil {
 		log.Printf("Unable to execute workflow: %v", err)
-		http.Error(w, "Failed to execute workflow", http.StatusInternalServerError)
+		writeError(w, "Failed to execute workflow", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"workflow_id": wfRun.GetID()})
+	json.NewEncoder(w).Encode(startedWorkflowResponse(wfRun.GetID(), migrationIssues))
 }
 
 func (h *Handlers) ExecuteJSONWorkflow(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	// Read the raw JSON payload
+	workflowJSONBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 
-	// Read the raw JSON payload
-	workflowJSONBytes, err := io.ReadAll(r.Body)
+	workflowJSONBytes, migrationIssues, ok := migrateIfRequested(w, r, workflowJSONBytes, workflows.FormatJSON)
+	if !ok {
+		return
+	}
+
+	input, err := workflowInput(r)
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	options := client.StartWorkflowOptions{
-		ID:        "json-workflow-" + uuid.New().String(),
-		TaskQueue: "serverless-workflow-task-queue",
+		ID:                    "json-workflow-" + uuid.New().String(),
+		TaskQueue:             "serverless-workflow-task-queue",
+		TypedSearchAttributes: workflows.ServerlessSearchAttributes(workflowJSONBytes),
 	}
 
-	wfRun, err := h.temporal.ExecuteWorkflow(r.Context(), options, workflows.ExecuteServerlessJSONWorkflow, string(workflowJSONBytes))
+	wfRun, err := h.startWorkflow(r.Context(), options, workflows.ExecuteServerlessJSONWorkflow, string(workflowJSONBytes), input)
 	if err != nil {
 		log.Printf("Unable to execute JSON workflow: %v", err)
-		http.Error(w, "Failed to execute JSON workflow", http.StatusInternalServerError)
+		writeError(w, "Failed to execute JSON workflow", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"workflow_id": wfRun.GetID()})
+	json.NewEncoder(w).Encode(startedWorkflowResponse(wfRun.GetID(), migrationIssues))
 }
 
 func (h *Handlers) InitiateChatbot(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	// The body is optional; without one the thread uses the default configuration
+	var requestBody struct {
+		Config workflows.ChatbotConfig `json:"config"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := requestBody.Config.Validate(); err != nil {
+		writeError(w, "Invalid config: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	threadID := uuid.New().String()
 
 	options := client.StartWorkflowOptions{
-		ID:        "chatbot-workflow-" + threadID,
-		TaskQueue: "serverless-workflow-task-queue",
+		ID:                    "chatbot-workflow-" + threadID,
+		TaskQueue:             "serverless-workflow-task-queue",
+		TypedSearchAttributes: workflows.ChatbotSearchAttributes(),
 	}
 
-	wfRun, err := h.temporal.ExecuteWorkflow(r.Context(), options, workflows.ChatbotWorkflow, threadID)
+	wfRun, err := h.startWorkflow(r.Context(), options, workflows.ChatbotWorkflow, threadID, requestBody.Config)
 	if err != nil {
 		log.Printf("Unable to initiate chatbot workflow: %v", err)
-		http.Error(w, "Failed to initiate chatbot workflow", http.StatusInternalServerError)
+		writeError(w, "Failed to initiate chatbot workflow", http.StatusInternalServerError)
 		return
 	}
 
@@ -112,140 +140,209 @@ func (h *Handlers) InitiateChatbot(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handlers) SendChatMessage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
-		return
-	}
+	threadID := r.PathValue("id")
 
 	var requestBody struct {
-		ThreadID string `json:"thread_id"`
-		Message  string `json:"message"`
+		Message string `json:"message"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if requestBody.ThreadID == "" || requestBody.Message == "" {
-		http.Error(w, "thread_id and message are required", http.StatusBadRequest)
+	if requestBody.Message == "" {
+		writeError(w, "message is required", http.StatusBadRequest)
 		return
 	}
 
-	workflowID := "chatbot-workflow-" + requestBody.ThreadID
+	workflowID := "chatbot-workflow-" + threadID
 
-	// Get initial conversation length
-	var initialState *workflows.ChatbotState
-	resp, err := h.temporal.QueryWorkflow(r.Context(), workflowID, "", "get-state")
+	// The update returns once the workflow has accepted the message, which it rejects while another
+	// message is processed; the reply is fetched from the update's reply route or the thread's stream
+	handle, err := h.temporal.UpdateWorkflow(r.Context(), client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   workflows.SendMessageUpdate,
+		Args:         []interface{}{workflows.SendMessageRequest{Message: requestBody.Message}},
+		WaitForStage: client.WorkflowUpdateStageAccepted,
+	})
 	if err != nil {
-		log.Printf("Unable to query initial workflow state: %v", err)
-		http.Error(w, "Failed to get workflow state", http.StatusInternalServerError)
+		log.Printf("Unable to send message update: %v", err)
+		writeError(w, "Failed to send message", chatUpdateErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/chatbot/threads/"+threadID+"/messages/"+handle.UpdateID())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"update_id": handle.UpdateID(),
+		"thread_id": threadID,
+	})
+}
+
+// chatReplyWait bounds how long a reply request waits for the assistant before answering 202
+const chatReplyWait = 20 * time.Second
+
+// GetChatMessageReply returns the assistant's reply to a message sent with SendChatMessage; while
+// the reply is being generated it answers 202 after waiting up to chatReplyWait
+func (h *Handlers) GetChatMessageReply(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	updateID := r.PathValue("update_id")
+
+	handle := h.temporal.GetWorkflowUpdateHandle(client.GetWorkflowUpdateHandleOptions{
+		WorkflowID: "chatbot-workflow-" + threadID,
+		UpdateID:   updateID,
+	})
+
+	ctx, cancel := context.WithTimeout(r.Context(), chatReplyWait)
+	defer cancel()
+
+	var reply workflows.SendMessageResponse
+	err := handle.Get(ctx, &reply)
+	var timeoutErr *client.WorkflowUpdateServiceTimeoutOrCanceledError
+	if errors.As(err, &timeoutErr) && r.Context().Err() == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   true,
+			"update_id": updateID,
+			"thread_id": threadID,
+		})
 		return
 	}
-	err = resp.Get(&initialState)
 	if err != nil {
-		log.Printf("Unable to decode initial query result: %v", err)
-		http.Error(w, "Failed to decode workflow state", http.StatusInternalServerError)
+		log.Printf("Message update failed: %v", err)
+		writeError(w, "Failed to send message: "+err.Error(), chatUpdateErrorStatus(err))
 		return
 	}
-	initialLength := len(initialState.Conversation)
 
-	// Send user input signal
-	err = h.temporal.SignalWorkflow(r.Context(), workflowID, "", "user-input", workflows.UserInputSignal{
-		Message: requestBody.Message,
+	// workflow_code is the reply's workflow with the fixes applied; the content keeps the code as written
+	body := map[string]interface{}{
+		"success":   true,
+		"response":  reply.Response,
+		"content":   reply.Message.Content,
+		"thread_id": threadID,
+	}
+	if reply.WorkflowCode != "" {
+		body["workflow_code"] = reply.WorkflowCode
+		body["fixes"] = reply.Fixes
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (h *Handlers) ExecuteChatWorkflow(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+
+	// The body is optional; without a message_index the latest generated workflow is executed
+	var requestBody struct {
+		MessageIndex *int `json:"message_index"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// The update returns once the generated workflow has started as a child of the thread
+	handle, err := h.temporal.UpdateWorkflow(r.Context(), client.UpdateWorkflowOptions{
+		WorkflowID:   "chatbot-workflow-" + threadID,
+		UpdateName:   workflows.ExecuteWorkflowUpdate,
+		Args:         []interface{}{workflows.ExecuteWorkflowRequest{MessageIndex: requestBody.MessageIndex}},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
 	})
 	if err != nil {
-		log.Printf("Unable to signal workflow: %v", err)
-		http.Error(w, "Failed to send message", http.StatusInternalServerError)
+		log.Printf("Unable to send execute workflow update: %v", err)
+		writeError(w, "Failed to execute workflow", chatUpdateErrorStatus(err))
 		return
 	}
 
-	// Poll for the response (wait for conversation to grow by 2: user message + assistant response)
-	for i := 0; i < 30; i++ { // Max 30 attempts (60 seconds)
-		var currentState *workflows.ChatbotState
-		resp, err := h.temporal.QueryWorkflow(r.Context(), workflowID, "", "get-state")
-		if err != nil {
-			log.Printf("Unable to query workflow state: %v", err)
-			http.Error(w, "Failed to get workflow state", http.StatusInternalServerError)
-			return
-		}
-		err = resp.Get(&currentState)
-		if err != nil {
-			log.Printf("Unable to decode query result: %v", err)
-			http.Error(w, "Failed to decode workflow state", http.StatusInternalServerError)
-			return
-		}
-
-		// Check if we have the assistant's response (conversation should have grown by 2) AND processing is complete
-		if len(currentState.Conversation) >= initialLength+2 && !currentState.IsProcessing {
-			lastMessage := currentState.Conversation[len(currentState.Conversation)-1]
-
-			// Extract text content from the assistant's response
-			var responseText string
-			if lastMessage.Role == "assistant" && len(lastMessage.Content) > 0 {
-				// Convert to JSON and back to extract text content
-				contentBytes, err := json.Marshal(lastMessage.Content[0])
-				if err == nil {
-					var contentBlock map[string]interface{}
-					if json.Unmarshal(contentBytes, &contentBlock) == nil {
-						if text, exists := contentBlock["text"]; exists {
-							if textStr, ok := text.(string); ok {
-								responseText = textStr
-							}
-						}
-					}
-				}
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success":   true,
-				"response":  responseText,
-				"thread_id": requestBody.ThreadID,
-			})
-			return
-		}
-
-		// Wait 1 second before polling again
-		time.Sleep(2 * time.Second)
+	var execution workflows.ThreadExecution
+	if err := handle.Get(r.Context(), &execution); err != nil {
+		log.Printf("Execute workflow update failed: %v", err)
+		writeError(w, "Failed to execute workflow: "+err.Error(), chatUpdateErrorStatus(err))
+		return
 	}
 
-	// Timeout - return success but indicate we couldn't get the response in time
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":   true,
-		"response":  "Processing your message...",
-		"thread_id": requestBody.ThreadID,
-		"note":      "Response is being processed. Use GET /workflow/thread to retrieve the full conversation.",
+		"workflow_id":   execution.WorkflowID,
+		"run_id":        execution.RunID,
+		"message_index": execution.MessageIndex,
+		"thread_id":     threadID,
 	})
 }
 
-func (h *Handlers) GetChatThread(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+func (h *Handlers) UpdateChatConfig(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+
+	var requestBody workflows.ChatbotConfigUpdate
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	threadID := r.URL.Query().Get("thread_id")
-	if threadID == "" {
-		http.Error(w, "thread_id parameter is required", http.StatusBadRequest)
+	// Only the fields set or reset in the request change; the merged configuration is returned
+	handle, err := h.temporal.UpdateWorkflow(r.Context(), client.UpdateWorkflowOptions{
+		WorkflowID:   "chatbot-workflow-" + threadID,
+		UpdateName:   workflows.UpdateConfigUpdate,
+		Args:         []interface{}{requestBody},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		log.Printf("Unable to send config update: %v", err)
+		writeError(w, "Failed to update config", chatUpdateErrorStatus(err))
 		return
 	}
 
-	workflowID := "chatbot-workflow-" + threadID
+	var config workflows.ChatbotConfig
+	if err := handle.Get(r.Context(), &config); err != nil {
+		log.Printf("Config update failed: %v", err)
+		writeError(w, "Failed to update config: "+err.Error(), chatUpdateErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"config":    config,
+		"thread_id": threadID,
+	})
+}
+
+// chatUpdateErrorStatus maps send-message update failures to HTTP status codes
+func chatUpdateErrorStatus(err error) int {
+	var applicationErr *temporal.ApplicationError
+	if errors.As(err, &applicationErr) {
+		switch applicationErr.Type() {
+		case workflows.ChatbotBusyErrorType:
+			return http.StatusConflict
+		case workflows.ChatbotInvalidMessageErrorType, workflows.ChatbotInvalidConfigErrorType:
+			return http.StatusBadRequest
+		case workflows.ChatbotNoWorkflowErrorType:
+			return http.StatusNotFound
+		}
+	}
+	return temporalErrorStatus(err)
+}
+
+func (h *Handlers) GetChatThread(w http.ResponseWriter, r *http.Request) {
+	workflowID := "chatbot-workflow-" + r.PathValue("id")
 
 	var result *workflows.ChatbotState
 	resp, err := h.temporal.QueryWorkflow(r.Context(), workflowID, "", "get-state")
 	if err != nil {
 		log.Printf("Unable to query workflow: %v", err)
-		http.Error(w, "Failed to fetch chat thread", http.StatusInternalServerError)
+		writeError(w, "Failed to fetch chat thread", temporalErrorStatus(err))
 		return
 	}
 
 	err = resp.Get(&result)
 	if err != nil {
 		log.Printf("Unable to decode query result: %v", err)
-		http.Error(w, "Failed to decode chat thread state", http.StatusInternalServerError)
+		writeError(w, "Failed to decode chat thread state", http.StatusInternalServerError)
 		return
 	}
 
@@ -254,58 +351,79 @@ func (h *Handlers) GetChatThread(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handlers) ExecuteYAMLWorkflow(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	// Read the raw YAML payload
+	workflowYAMLBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 
-	// Read the raw YAML payload
-	workflowYAMLBytes, err := io.ReadAll(r.Body)
+	workflowYAMLBytes, migrationIssues, ok := migrateIfRequested(w, r, workflowYAMLBytes, workflows.FormatYAML)
+	if !ok {
+		return
+	}
+
+	input, err := workflowInput(r)
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	options := client.StartWorkflowOptions{
-		ID:        "yaml-workflow-" + uuid.New().String(),
-		TaskQueue: "serverless-workflow-task-queue",
+		ID:                    "yaml-workflow-" + uuid.New().String(),
+		TaskQueue:             "serverless-workflow-task-queue",
+		TypedSearchAttributes: workflows.ServerlessSearchAttributes(workflowYAMLBytes),
 	}
 
-	wfRun, err := h.temporal.ExecuteWorkflow(r.Context(), options, workflows.ExecuteServerlessYAMLWorkflow, string(workflowYAMLBytes))
+	wfRun, err := h.startWorkflow(r.Context(), options, workflows.ExecuteServerlessYAMLWorkflow, string(workflowYAMLBytes), input)
 	if err != nil {
 		log.Printf("Unable to execute YAML workflow: %v", err)
-		http.Error(w, "Failed to execute YAML workflow", http.StatusInternalServerError)
+		writeError(w, "Failed to execute YAML workflow", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"workflow_id": wfRun.GetID()})
+	json.NewEncoder(w).Encode(startedWorkflowResponse(wfRun.GetID(), migrationIssues))
 }
 
-func (h *Handlers) GetWorkflowState(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
-		return
+// startedWorkflowResponse is the response of the execute endpoints, with the migration issues of a
+// migrated 0.8 definition
+func startedWorkflowResponse(workflowID string, migrationIssues []workflows.MigrationIssue) map[string]interface{} {
+	response := map[string]interface{}{"workflow_id": workflowID}
+	if migrationIssues != nil {
+		response["migration_issues"] = migrationIssues
 	}
+	return response
+}
 
-	workflowID := r.URL.Query().Get("workflow_id")
-	if workflowID == "" {
-		http.Error(w, "workflow_id parameter is required", http.StatusBadRequest)
-		return
+// workflowInput decodes the workflow input given as a JSON object in ?input=
+func workflowInput(r *http.Request) (map[string]interface{}, error) {
+	raw := r.URL.Query().Get("input")
+	if raw == "" {
+		return nil, nil
 	}
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &input); err != nil {
+		return nil, fmt.Errorf("input must be a JSON object: %w", err)
+	}
+	return input, nil
+}
+
+func (h *Handlers) GetWorkflowState(w http.ResponseWriter, r *http.Request) {
+	workflowID := r.PathValue("id")
 
 	var result *workflows.WorkflowState
 	resp, err := h.temporal.QueryWorkflow(r.Context(), workflowID, "", "get-workflow-state")
 	if err != nil {
 		log.Printf("Unable to query workflow state: %v", err)
-		http.Error(w, "Failed to get workflow state", http.StatusInternalServerError)
+		writeError(w, "Failed to get workflow state", temporalErrorStatus(err))
 		return
 	}
 
 	err = resp.Get(&result)
 	if err != nil {
 		log.Printf("Unable to decode workflow state: %v", err)
-		http.Error(w, "Failed to decode workflow state", http.StatusInternalServerError)
+		writeError(w, "Failed to decode workflow state", http.StatusInternalServerError)
 		return
 	}
 
@@ -327,3 +445,116 @@ func (h *Handlers) DemoHandler(w http.ResponseWriter, r *http.Request) {
 		"wait_time": waitTime.String(),
 	})
 }
+
+func (h *Handlers) CancelWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflowID := r.PathValue("id")
+
+	// Cancellation is graceful: the running task's activity is cancelled, the catch tasks of the
+	// enclosing try tasks run as compensation, then the interpreter records the cancellation
+	err := h.temporal.CancelWorkflow(r.Context(), workflowID, "")
+	if err != nil {
+		log.Printf("Unable to cancel workflow: %v", err)
+		writeError(w, "Failed to cancel workflow", temporalErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"workflow_id": workflowID,
+		"status":      "cancel_requested",
+	})
+}
+
+func (h *Handlers) TerminateWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflowID := r.PathValue("id")
+
+	var requestBody struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			writeError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if requestBody.Reason == "" {
+		requestBody.Reason = "terminated via API"
+	}
+
+	err := h.temporal.TerminateWorkflow(r.Context(), workflowID, "", requestBody.Reason)
+	if err != nil {
+		log.Printf("Unable to terminate workflow: %v", err)
+		writeError(w, "Failed to terminate workflow", temporalErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"workflow_id": workflowID,
+		"status":      "terminated",
+		"reason":      requestBody.Reason,
+	})
+}
+
+func (h *Handlers) SuspendWorkflow(w http.ResponseWriter, r *http.Request) {
+	h.updateExecutionControl(w, r, workflows.SuspendUpdate)
+}
+
+func (h *Handlers) ResumeWorkflow(w http.ResponseWriter, r *http.Request) {
+	h.updateExecutionControl(w, r, workflows.ResumeUpdate)
+}
+
+// updateExecutionControl suspends or resumes an execution and reports the status the workflow
+// ended up in; a control that does not apply to the current status is rejected with 409
+func (h *Handlers) updateExecutionControl(w http.ResponseWriter, r *http.Request, updateName string) {
+	workflowID := r.PathValue("id")
+
+	handle, err := h.temporal.UpdateWorkflow(r.Context(), client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   updateName,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	var status string
+	if err == nil {
+		err = handle.Get(r.Context(), &status)
+	}
+	if err != nil {
+		log.Printf("Unable to %s workflow: %v", updateName, err)
+		// Executions that have already closed reject the update with NotFound
+		message, status := "Failed to "+updateName+" workflow", temporalErrorStatus(err)
+		var applicationErr *temporal.ApplicationError
+		if errors.As(err, &applicationErr) && applicationErr.Type() == workflows.ExecutionStateErrorType {
+			message, status = applicationErr.Message(), http.StatusConflict
+		}
+		writeError(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"workflow_id": workflowID,
+		"status":      status,
+	})
+}
+
+// temporalErrorStatus maps Temporal client errors to HTTP status codes
+func temporalErrorStatus(err error) int {
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// startWorkflow starts a workflow execution. When the namespace has not registered the custom
+// search attributes the start is retried without them, so a bare dev server keeps working.
+func (h *Handlers) startWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflowFn interface{}, args ...interface{}) (client.WorkflowRun, error) {
+	wfRun, err := h.temporal.ExecuteWorkflow(ctx, options, workflowFn, args...)
+	var invalidArgument *serviceerror.InvalidArgument
+	if err != nil && errors.As(err, &invalidArgument) && options.TypedSearchAttributes.Size() > 0 {
+		log.Printf("Search attributes rejected, starting %s without them: %v", options.ID, err)
+		options.TypedSearchAttributes = temporal.SearchAttributes{}
+		return h.temporal.ExecuteWorkflow(ctx, options, workflowFn, args...)
+	}
+	return wfRun, err
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+// LintResponse is the outcome of linting a workflow definition
+type LintResponse struct {
+	Valid    bool                  `json:"valid"` // no error severity issues
+	Errors   int                   `json:"errors"`
+	Warnings int                   `json:"warnings"`
+	Issues   []workflows.LintIssue `json:"issues"`
+}
+
+// LintWorkflow lints the raw YAML or JSON workflow definition in the body. Rules are configured with
+// ?disable=rule,rule, ?severity=rule:warning,rule:error and ?allowed_endpoints=prefix,prefix.
+func (h *Handlers) LintWorkflow(w http.ResponseWriter, r *http.Request) {
+	options, err := lintOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	source, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	response := LintResponse{Issues: workflows.LintWorkflowSource(source, options)}
+	for _, issue := range response.Issues {
+		if issue.Severity == workflows.LintSeverityError {
+			response.Errors++
+		} else {
+			response.Warnings++
+		}
+	}
+	response.Valid = response.Errors == 0
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// lintOptions builds the lint options from the query parameters, rejecting unknown rules and
+// severities
+func lintOptions(params url.Values) (workflows.LintOptions, error) {
+	known := map[string]bool{}
+	for _, rule := range workflows.LintRules() {
+		known[rule.Name] = true
+	}
+
+	var options workflows.LintOptions
+	for _, rule := range splitList(params.Get("disable")) {
+		if !known[rule] {
+			return options, fmt.Errorf("unknown lint rule %q", rule)
+		}
+		options.Disabled = append(options.Disabled, rule)
+	}
+	for _, override := range splitList(params.Get("severity")) {
+		rule, severity, found := strings.Cut(override, ":")
+		if !found || !known[rule] {
+			return options, fmt.Errorf("invalid severity override %q, expected <rule>:<severity>", override)
+		}
+		if severity != workflows.LintSeverityError && severity != workflows.LintSeverityWarning {
+			return options, fmt.Errorf("invalid severity %q, expected error or warning", severity)
+		}
+		if options.Severity == nil {
+			options.Severity = map[string]string{}
+		}
+		options.Severity[rule] = severity
+	}
+	options.AllowedEndpoints = splitList(params.Get("allowed_endpoints"))
+	options.Functions = workflows.RegisteredFunctions()
+	return options, nil
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
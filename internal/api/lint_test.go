@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+func TestLintWorkflowHandler(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: lint
+  version: 1.0.0
+do:
+  - fetch:
+      call: http
+      with:
+        method: get
+        endpoint: https://api.example.com/orders
+      then: end
+  - report:
+      set:
+        done: true
+`
+	h := &Handlers{}
+	request := httptest.NewRequest(http.MethodPost, "/workflows/lint?severity=endpoint-not-allowed:warning,unfollowed-then:warning", strings.NewReader(definition))
+	recorder := httptest.NewRecorder()
+	h.LintWorkflow(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var response LintResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.Valid || response.Errors != 0 || response.Warnings != 3 || len(response.Issues) != 3 {
+		t.Errorf("Expected three warnings, got %+v", response)
+	}
+}
+
+func TestLintOptions(t *testing.T) {
+	params := url.Values{}
+	params.Set("disable", "unreachable-task, duplicate-task-name")
+	params.Set("severity", "switch-without-default:error")
+	params.Set("allowed_endpoints", "https://api.example.com/,http://localhost:8088/demo/")
+
+	options, err := lintOptions(params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(options.Disabled) != 2 || options.Disabled[1] != workflows.LintDuplicateTaskName {
+		t.Errorf("Unexpected disabled rules %v", options.Disabled)
+	}
+	if options.Severity[workflows.LintSwitchWithoutDefault] != workflows.LintSeverityError {
+		t.Errorf("Unexpected severity overrides %v", options.Severity)
+	}
+	if len(options.AllowedEndpoints) != 2 {
+		t.Errorf("Unexpected allowed endpoints %v", options.AllowedEndpoints)
+	}
+
+	for _, params := range []url.Values{
+		{"disable": {"no-such-rule"}},
+		{"severity": {"switch-without-default"}},
+		{"severity": {"switch-without-default:fatal"}},
+	} {
+		if _, err := lintOptions(params); err == nil {
+			t.Errorf("Expected an error for %v", params)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+// MigrateWorkflow migrates the raw 0.8 YAML or JSON workflow definition in the body to DSL 1.0,
+// written as ?to=json or yaml (default: the format of the source). Untranslated parts are reported
+// as issues.
+func (h *Handlers) MigrateWorkflow(w http.ResponseWriter, r *http.Request) {
+	source, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = sourceFormat(source)
+	}
+	result, err := workflows.MigrateLegacyWorkflow(source, to)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// migrateIfRequested migrates a 0.8 definition to the given format when ?migrate=true is set, so
+// the execute endpoints can run legacy definitions. It writes the error response and returns
+// false when the migration fails, or when the migrated workflow uses task types or `then` jumps
+// this engine does not run.
+func migrateIfRequested(w http.ResponseWriter, r *http.Request, source []byte, format string) ([]byte, []workflows.MigrationIssue, bool) {
+	if r.URL.Query().Get("migrate") != "true" || !workflows.IsLegacyWorkflow(source) {
+		return source, nil, true
+	}
+	result, err := workflows.MigrateLegacyWorkflow(source, format)
+	if err != nil {
+		writeError(w, "Failed to migrate workflow: "+err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	issues := workflows.LintWorkflowSource([]byte(result.Workflow), unsupportedOnlyLintOptions())
+	if workflows.HasLintErrors(issues) {
+		messages := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			messages = append(messages, issue.Path+": "+issue.Message)
+		}
+		writeError(w, "Migrated workflow cannot run on this engine: "+strings.Join(messages, "; "), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return []byte(result.Workflow), result.Issues, true
+}
+
+// unsupportedOnlyLintOptions runs only the rules reporting what this engine cannot execute:
+// unsupported task types, `then` jumps it does not follow and functions it cannot call
+func unsupportedOnlyLintOptions() workflows.LintOptions {
+	enabled := map[string]bool{
+		workflows.LintUnsupportedTaskType: true,
+		workflows.LintUnfollowedThen:      true,
+		workflows.LintUndefinedFunction:   true,
+	}
+	options := workflows.LintOptions{Functions: workflows.RegisteredFunctions()}
+	for _, rule := range workflows.LintRules() {
+		if !enabled[rule.Name] {
+			options.Disabled = append(options.Disabled, rule.Name)
+		}
+	}
+	return options
+}
+
+// sourceFormat guesses whether a definition is JSON or YAML
+func sourceFormat(source []byte) string {
+	if strings.HasPrefix(strings.TrimSpace(string(source)), "{") {
+		return workflows.FormatJSON
+	}
+	return workflows.FormatYAML
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+func TestMigrateIfRequestedRefusesUnsupportedWorkflows(t *testing.T) {
+	source, err := os.ReadFile("../../test_data/legacy-order.sw.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/workflows/yaml?migrate=true", nil)
+	if _, _, ok := migrateIfRequested(recorder, request, source, workflows.FormatYAML); ok {
+		t.Fatal("Expected the migrated workflow to be refused")
+	}
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", recorder.Code)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Body is not JSON: %v", err)
+	}
+	for _, want := range []string{"openapi", "wait", "then"} {
+		if !strings.Contains(body.Error, want) {
+			t.Errorf("Expected the error to mention %s, got %q", want, body.Error)
+		}
+	}
+}
+
+func TestMigrateIfRequestedRunnableWorkflow(t *testing.T) {
+	source := []byte(`{"id": "hello", "version": "2", "specVersion": "0.8", "start": "Greet",
+		"states": [{"name": "Greet", "type": "inject", "data": {"greeting": "hello"}, "end": true}]}`)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/workflows/json?migrate=true", nil)
+	migrated, _, ok := migrateIfRequested(recorder, request, source, workflows.FormatJSON)
+	if !ok {
+		t.Fatalf("Expected the migrated workflow to be accepted, got %s", recorder.Body)
+	}
+	if !strings.Contains(string(migrated), `"document"`) {
+		t.Errorf("Expected a 1.0 definition, got %s", migrated)
+	}
+}
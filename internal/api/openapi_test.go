@@ -0,0 +1,318 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{(\w+)(\.\.\.)?\}`)
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// loadOpenAPIDocument decodes the embedded document generically
+func loadOpenAPIDocument(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var document map[string]interface{}
+	if err := json.Unmarshal(openAPIDocument, &document); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return document
+}
+
+// splitPattern splits a ServeMux pattern into its method and its path in OpenAPI form
+func splitPattern(pattern string) (string, string) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	return method, pathParamPattern.ReplaceAllString(path, "{$1}")
+}
+
+// resolveRef follows a local $ref such as #/components/parameters/WorkflowID
+func resolveRef(t *testing.T, document map[string]interface{}, node map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+	var current interface{} = document
+	for _, segment := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, _ := current.(map[string]interface{})
+		current = object[segment]
+	}
+	resolved, ok := current.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unresolved $ref %s", ref)
+	}
+	return resolved
+}
+
+// documentedPathParams returns the sorted path parameters of an operation, including those declared
+// on its path item
+func documentedPathParams(t *testing.T, document, pathItem, operation map[string]interface{}) []string {
+	t.Helper()
+	var names []string
+	for _, owner := range []map[string]interface{}{pathItem, operation} {
+		parameters, _ := owner["parameters"].([]interface{})
+		for _, raw := range parameters {
+			parameter := resolveRef(t, document, raw.(map[string]interface{}))
+			if parameter["in"] != "path" {
+				continue
+			}
+			if parameter["required"] != true {
+				t.Errorf("path parameter %v must be required", parameter["name"])
+			}
+			names = append(names, parameter["name"].(string))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestOpenAPIDocumentVersion(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	if document["openapi"] != "3.1.0" {
+		t.Errorf("Expected openapi 3.1.0, got %v", document["openapi"])
+	}
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	paths := document["paths"].(map[string]interface{})
+
+	for _, route := range (&Handlers{}).routes() {
+		method, path := splitPattern(route.pattern)
+		pathItem, ok := paths[path].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: path %s is not documented", route.pattern, path)
+			continue
+		}
+
+		methods := openAPIMethods
+		if method != "" {
+			methods = []string{strings.ToLower(method)}
+		}
+		documented := 0
+		for _, m := range methods {
+			operation, ok := pathItem[m].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			documented++
+
+			var want []string
+			for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+				want = append(want, match[1])
+			}
+			sort.Strings(want)
+			got := documentedPathParams(t, document, pathItem, operation)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("%s %s: expected path parameters %v, got %v", m, path, want, got)
+			}
+		}
+		if documented == 0 {
+			t.Errorf("%s: no documented operation", route.pattern)
+		}
+	}
+}
+
+func TestOpenAPIOperationsResolveToRoutes(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	mux := newServeMux(&Handlers{})
+
+	operationIDs := map[string]string{}
+	for path, rawItem := range document["paths"].(map[string]interface{}) {
+		pathItem := rawItem.(map[string]interface{})
+		for _, method := range openAPIMethods {
+			raw, ok := pathItem[method]
+			if !ok {
+				continue
+			}
+			operation := raw.(map[string]interface{})
+			name := strings.ToUpper(method) + " " + path
+
+			operationID, _ := operation["operationId"].(string)
+			if operationID == "" {
+				t.Errorf("%s: missing operationId", name)
+			} else if other, ok := operationIDs[operationID]; ok {
+				t.Errorf("%s: operationId %s already used by %s", name, operationID, other)
+			}
+			operationIDs[operationID] = name
+
+			responses, _ := operation["responses"].(map[string]interface{})
+			for status, response := range responses {
+				resolved := resolveRef(t, document, response.(map[string]interface{}))
+				if status[0] != '4' && status[0] != '5' {
+					continue
+				}
+				schema, _ := resolved["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+				if schema["$ref"] != "#/components/schemas/Error" {
+					t.Errorf("%s: %s response does not use the Error schema", name, status)
+				}
+			}
+
+			request := httptest.NewRequest(strings.ToUpper(method), pathParamPattern.ReplaceAllString(path, "example"), nil)
+			_, pattern := mux.Handler(request)
+			if pattern == "" {
+				t.Errorf("%s: no route", name)
+				continue
+			}
+			routeMethod, routePath := splitPattern(pattern)
+			if routePath != path || (routeMethod != "" && routeMethod != strings.ToUpper(method)) {
+				t.Errorf("%s: served by %q", name, pattern)
+			}
+		}
+	}
+}
+
+func TestRouterServesOpenAPIDocument(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewRouter(&Handlers{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", got)
+	}
+	if recorder.Body.String() != string(openAPIDocument) {
+		t.Error("Expected the embedded document as the body")
+	}
+}
+
+func TestRouterJSONErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		allow  string
+	}{
+		{name: "unknown path", method: http.MethodGet, target: "/unknown", status: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodGet, target: "/chatbot/threads", status: http.StatusMethodNotAllowed, allow: "POST"},
+		{name: "wrong method on item", method: http.MethodDelete, target: "/chatbot/threads/abc/config", status: http.StatusMethodNotAllowed, allow: "PATCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			NewRouter(&Handlers{}).ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if recorder.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, recorder.Code)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got %q", got)
+			}
+			if tt.allow != "" && !strings.Contains(recorder.Header().Get("Allow"), tt.allow) {
+				t.Errorf("Expected Allow to contain %s, got %q", tt.allow, recorder.Header().Get("Allow"))
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not JSON: %v\n%s", err, recorder.Body.String())
+			}
+			if body.Status != tt.status || body.Error == "" {
+				t.Errorf("Expected an error body with status %d, got %+v", tt.status, body)
+			}
+		})
+	}
+}
+
+func TestHandlerErrorsAreJSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewRouter(&Handlers{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/workflows/convert?to=xml", strings.NewReader("{}")))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", recorder.Code)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if body.Error != "to must be json or yaml" || body.Status != http.StatusBadRequest {
+		t.Errorf("Expected the to-format error, got %+v", body)
+	}
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	tests := []struct {
+		name      string
+		param     string
+		successor string
+		request   *http.Request
+		status    int
+		id        string
+		link      string
+	}{
+		{
+			name:      "id from query",
+			param:     "workflow_id",
+			successor: "/workflows/{id}",
+			request:   httptest.NewRequest(http.MethodGet, "/workflows/state?workflow_id=wf-1", nil),
+			status:    http.StatusOK,
+			id:        "wf-1",
+			link:      `</workflows/wf-1>; rel="successor-version"`,
+		},
+		{
+			name:      "id from body",
+			param:     "thread_id",
+			successor: "/chatbot/threads/{id}/messages",
+			request:   httptest.NewRequest(http.MethodPost, "/chatbot/message", strings.NewReader(`{"thread_id": "t 1", "message": "hi"}`)),
+			status:    http.StatusOK,
+			id:        "t 1",
+			link:      `</chatbot/threads/t%201/messages>; rel="successor-version"`,
+		},
+		{
+			name:      "no id",
+			successor: "/chatbot/threads",
+			request:   httptest.NewRequest(http.MethodPost, "/chatbot/init", nil),
+			status:    http.StatusOK,
+			link:      `</chatbot/threads>; rel="successor-version"`,
+		},
+		{
+			name:      "missing id",
+			param:     "thread_id",
+			successor: "/chatbot/threads/{id}",
+			request:   httptest.NewRequest(http.MethodPost, "/chatbot/message", strings.NewReader(`{"message": "hi"}`)),
+			status:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id, body string
+			handler := deprecatedRoute(tt.param, tt.successor, func(w http.ResponseWriter, r *http.Request) {
+				id = r.PathValue("id")
+				raw, _ := io.ReadAll(r.Body)
+				body = string(raw)
+			})
+			recorder := httptest.NewRecorder()
+			handler(recorder, tt.request)
+
+			if recorder.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, recorder.Code)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if id != tt.id {
+				t.Errorf("Expected path value id %q, got %q", tt.id, id)
+			}
+			if tt.request.Method == http.MethodPost && tt.param != "" && !strings.Contains(body, `"message": "hi"`) {
+				t.Errorf("Expected the handler to read the original body, got %q", body)
+			}
+			if got := recorder.Header().Get("Deprecation"); got != "true" {
+				t.Errorf("Expected Deprecation true, got %q", got)
+			}
+			if got := recorder.Header().Get("Link"); got != tt.link {
+				t.Errorf("Expected Link %s, got %s", tt.link, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	defaultResultWait = 30 * time.Second
+	maxResultWait     = 5 * time.Minute
+)
+
+// ExecutionResult is the final outcome of an execution: its output document or spec error
+type ExecutionResult struct {
+	WorkflowID    string                   `json:"workflow_id"`
+	RunID         string                   `json:"run_id"`
+	Status        string                   `json:"status"`
+	Output        interface{}              `json:"output,omitempty"`
+	Error         *workflows.WorkflowError `json:"error,omitempty"`
+	StartTime     *time.Time               `json:"start_time,omitempty"`
+	CloseTime     *time.Time               `json:"close_time,omitempty"`
+	DurationMs    int64                    `json:"duration_ms,omitempty"`
+	Attempt       int32                    `json:"attempt"`
+	HistoryLength int64                    `json:"history_length"`
+}
+
+// GetWorkflowResult returns the output or error of a closed execution. Running executions return
+// 202 with their status, unless ?wait=true is given, in which case the request long-polls for up to
+// ?timeout= (default 30s, max 5m) for the execution to close.
+func (h *Handlers) GetWorkflowResult(w http.ResponseWriter, r *http.Request) {
+	workflowID := r.PathValue("id")
+
+	wait := r.URL.Query().Get("wait") == "true"
+	timeout := defaultResultWait
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, "timeout must be a positive duration, e.g. 30s", http.StatusBadRequest)
+			return
+		}
+		timeout = min(parsed, maxResultWait)
+	}
+
+	description, err := h.temporal.DescribeWorkflowExecution(r.Context(), workflowID, "")
+	if err != nil {
+		log.Printf("Unable to describe workflow: %v", err)
+		writeError(w, "Failed to get workflow result", temporalErrorStatus(err))
+		return
+	}
+	runID := description.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+
+	if description.GetWorkflowExecutionInfo().GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING && wait {
+		waitCtx, cancel := context.WithTimeout(r.Context(), timeout)
+		err := h.temporal.GetWorkflow(waitCtx, workflowID, runID).Get(waitCtx, nil)
+		timedOut := waitCtx.Err() != nil
+		cancel()
+		if err == nil || !timedOut {
+			description, err = h.temporal.DescribeWorkflowExecution(r.Context(), workflowID, runID)
+			if err != nil {
+				log.Printf("Unable to describe workflow: %v", err)
+				writeError(w, "Failed to get workflow result", temporalErrorStatus(err))
+				return
+			}
+		}
+	}
+
+	result := h.executionResult(r.Context(), description)
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status == "running" {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	var output interface{}
+	err = h.temporal.GetWorkflow(r.Context(), workflowID, runID).Get(r.Context(), &output)
+	if err != nil {
+		result.Error = h.executionError(r.Context(), workflowID, runID, err)
+	} else {
+		result.Output = output
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// executionResult fills in the status, timing and attempt metadata of an execution
+func (h *Handlers) executionResult(ctx context.Context, description *workflowservice.DescribeWorkflowExecutionResponse) ExecutionResult {
+	info := description.GetWorkflowExecutionInfo()
+	summary := executionSummary(info)
+	result := ExecutionResult{
+		WorkflowID:    summary.WorkflowID,
+		RunID:         summary.RunID,
+		Status:        summary.Status,
+		StartTime:     summary.StartTime,
+		CloseTime:     summary.CloseTime,
+		Attempt:       1,
+		HistoryLength: info.GetHistoryLength(),
+	}
+	if info.GetExecutionDuration() != nil {
+		result.DurationMs = info.GetExecutionDuration().AsDuration().Milliseconds()
+	}
+
+	// The run attempt is only recorded on the first history event
+	history := h.temporal.GetWorkflowHistory(ctx, result.WorkflowID, result.RunID, false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	if history.HasNext() {
+		event, err := history.Next()
+		if err == nil && event.GetWorkflowExecutionStartedEventAttributes() != nil {
+			result.Attempt = event.GetWorkflowExecutionStartedEventAttributes().GetAttempt()
+		}
+	}
+	return result
+}
+
+// executionError converts the failure of an execution into a spec error. The faulted task recorded
+// in the workflow state is preferred, as it identifies the task instance that raised the error.
+func (h *Handlers) executionError(ctx context.Context, workflowID, runID string, err error) *workflows.WorkflowError {
+	var state *workflows.WorkflowState
+	if resp, queryErr := h.temporal.QueryWorkflow(ctx, workflowID, runID, "get-workflow-state"); queryErr == nil {
+		if resp.Get(&state) == nil && state != nil {
+			if taskErr := faultedTaskError(state); taskErr != nil {
+				return taskErr
+			}
+		}
+	}
+	return workflowFailureError(err)
+}
+
+// faultedTaskError returns the error of the innermost faulted task, if any
+func faultedTaskError(state *workflows.WorkflowState) *workflows.WorkflowError {
+	var faulted *workflows.TaskExecution
+	for _, execution := range state.Tasks {
+		if execution.Status != workflows.TaskStatusFaulted || execution.Error == nil {
+			continue
+		}
+		if faulted == nil || len(execution.Reference) > len(faulted.Reference) {
+			faulted = execution
+		}
+	}
+	if faulted == nil {
+		return nil
+	}
+	return faulted.Error
+}
+
+// workflowFailureError maps a Temporal workflow failure onto a spec error
+func workflowFailureError(err error) *workflows.WorkflowError {
+	workflowErr := &workflows.WorkflowError{
+		Type:   workflows.ErrorTypeRuntime,
+		Status: http.StatusInternalServerError,
+		Title:  "Workflow execution failed",
+		Detail: err.Error(),
+	}
+
+	var applicationErr *temporal.ApplicationError
+	var canceledErr *temporal.CanceledError
+	var terminatedErr *temporal.TerminatedError
+	var timeoutErr *temporal.TimeoutError
+	switch {
+	case errors.As(err, &canceledErr):
+		workflowErr.Title = "Workflow execution cancelled"
+		workflowErr.Detail = canceledErr.Error()
+	case errors.As(err, &terminatedErr):
+		workflowErr.Title = "Workflow execution terminated"
+		workflowErr.Detail = terminatedErr.Error()
+	case errors.As(err, &timeoutErr):
+		workflowErr.Type = workflows.ErrorTypeTimeout
+		workflowErr.Status = http.StatusRequestTimeout
+		workflowErr.Title = "Workflow execution timed out"
+		workflowErr.Detail = timeoutErr.Error()
+	case errors.As(err, &applicationErr):
+		workflowErr.Detail = applicationErr.Message()
+	}
+	return workflowErr
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestWorkflowFailureError(t *testing.T) {
+	failure := temporal.NewApplicationError("task callApi failed", "")
+	workflowErr := workflowFailureError(failure)
+	if workflowErr.Type != workflows.ErrorTypeRuntime || workflowErr.Status != http.StatusInternalServerError {
+		t.Errorf("Expected a runtime error, got %+v", workflowErr)
+	}
+	if workflowErr.Detail != "task callApi failed" {
+		t.Errorf("Expected the application error message as detail, got %q", workflowErr.Detail)
+	}
+
+	workflowErr = workflowFailureError(errors.New("unexpected"))
+	if workflowErr.Detail != "unexpected" {
+		t.Errorf("Expected the error message as detail, got %q", workflowErr.Detail)
+	}
+}
+
+func TestFaultedTaskError(t *testing.T) {
+	state := &workflows.WorkflowState{Tasks: map[string]*workflows.TaskExecution{
+		"/do/0/outer": {
+			Reference: "/do/0/outer",
+			Status:    workflows.TaskStatusFaulted,
+			Error:     &workflows.WorkflowError{Instance: "/do/0/outer"},
+		},
+		"/do/0/outer/do/1/inner": {
+			Reference: "/do/0/outer/do/1/inner",
+			Status:    workflows.TaskStatusFaulted,
+			Error:     &workflows.WorkflowError{Instance: "/do/0/outer/do/1/inner"},
+		},
+		"/do/1/next": {Reference: "/do/1/next", Status: workflows.TaskStatusPending},
+	}}
+
+	taskErr := faultedTaskError(state)
+	if taskErr == nil || taskErr.Instance != "/do/0/outer/do/1/inner" {
+		t.Errorf("Expected the innermost faulted task error, got %+v", taskErr)
+	}
+	if faultedTaskError(&workflows.WorkflowState{}) != nil {
+		t.Error("Expected no error without faulted tasks")
+	}
+}
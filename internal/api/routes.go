@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// openAPIDocument describes the routes below; openapi_test.go keeps the two in sync
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// route is a ServeMux pattern and the handler serving it
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// routes is the route table of the API. Patterns without a method accept any method.
+func (h *Handlers) routes() []route {
+	return []route{
+		{"GET /health", h.HealthCheck},
+		{"GET /openapi.json", h.GetOpenAPIDocument},
+
+		{"GET /workflows", h.ListWorkflows},
+		{"POST /workflows", h.ExecuteWorkflow},
+		{"POST /workflows/json", h.ExecuteJSONWorkflow},
+		{"POST /workflows/yaml", h.ExecuteYAMLWorkflow},
+		{"POST /workflows/lint", h.LintWorkflow},
+		{"POST /workflows/validate", h.ValidateWorkflow},
+		{"POST /workflows/graph", h.GetWorkflowGraph},
+		{"POST /workflows/bpmn", h.ExportBPMN},
+		{"POST /workflows/convert", h.ConvertWorkflow},
+		{"POST /workflows/migrate", h.MigrateWorkflow},
+		{"POST /workflows/import/asl", h.ImportStateMachine},
+		{"GET /workflows/{id}", h.GetWorkflowState},
+		{"GET /workflows/{id}/result", h.GetWorkflowResult},
+		{"GET /workflows/{id}/events", h.StreamWorkflowEvents},
+		{"POST /workflows/{id}/cancel", h.CancelWorkflow},
+		{"POST /workflows/{id}/terminate", h.TerminateWorkflow},
+		{"POST /workflows/{id}/suspend", h.SuspendWorkflow},
+		{"POST /workflows/{id}/resume", h.ResumeWorkflow},
+
+		{"POST /chatbot/threads", h.InitiateChatbot},
+		{"GET /chatbot/threads/{id}", h.GetChatThread},
+		{"POST /chatbot/threads/{id}/messages", h.SendChatMessage},
+		{"GET /chatbot/threads/{id}/messages/{update_id}", h.GetChatMessageReply},
+		{"GET /chatbot/threads/{id}/stream", h.StreamChatResponses},
+		{"POST /chatbot/threads/{id}/executions", h.ExecuteChatWorkflow},
+		{"PATCH /chatbot/threads/{id}/config", h.UpdateChatConfig},
+
+		{"/demo/{path...}", h.DemoHandler},
+
+		// Flat routes of the previous API, kept for one release
+		{"GET /workflows/state", deprecatedRoute("workflow_id", "/workflows/{id}", h.GetWorkflowState)},
+		{"POST /chatbot/init", deprecatedRoute("", "/chatbot/threads", h.InitiateChatbot)},
+		{"POST /chatbot/message", deprecatedRoute("thread_id", "/chatbot/threads/{id}/messages", h.SendChatMessage)},
+		{"GET /chatbot/thread", deprecatedRoute("thread_id", "/chatbot/threads/{id}", h.GetChatThread)},
+		{"GET /chatbot/stream", deprecatedRoute("thread_id", "/chatbot/threads/{id}/stream", h.StreamChatResponses)},
+		{"POST /chatbot/execute", deprecatedRoute("thread_id", "/chatbot/threads/{id}/executions", h.ExecuteChatWorkflow)},
+		{"POST /chatbot/config", deprecatedRoute("thread_id", "/chatbot/threads/{id}/config", h.UpdateChatConfig)},
+	}
+}
+
+// deprecatedRoute serves a flat route of the previous API with the handler of its successor. The ID
+// the flat route took as the param query parameter, or as that field of a JSON body, becomes the {id}
+// path value; responses carry a Deprecation header and a Link to the successor.
+func deprecatedRoute(param, successor string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link := successor
+		if param != "" {
+			id := r.URL.Query().Get(param)
+			if id == "" && r.Body != nil {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					writeError(w, "Invalid request body", http.StatusBadRequest)
+					return
+				}
+				var fields map[string]interface{}
+				if json.Unmarshal(body, &fields) == nil {
+					id, _ = fields[param].(string)
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			if id == "" {
+				writeError(w, param+" is required", http.StatusBadRequest)
+				return
+			}
+			r.SetPathValue("id", id)
+			link = strings.Replace(successor, "{id}", url.PathEscape(id), 1)
+		}
+
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+link+">; rel=\"successor-version\"")
+		handler(w, r)
+	}
+}
+
+// newServeMux registers the route table on a new ServeMux
+func newServeMux(h *Handlers) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, route := range h.routes() {
+		mux.HandleFunc(route.pattern, route.handler)
+	}
+	return mux
+}
+
+// NewRouter returns the HTTP handler of the API. Unknown paths and methods a path does not accept
+// get the same JSON error body as the handlers, keeping the Allow header of a 405.
+func NewRouter(h *Handlers) http.Handler {
+	mux := newServeMux(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		// The mux's own 404/405/redirect handler; redirects pass through unchanged
+		recorder := &statusRecorder{ResponseWriter: w}
+		handler.ServeHTTP(recorder, r)
+		switch recorder.status {
+		case http.StatusNotFound:
+			writeError(w, "No route for "+r.URL.Path, http.StatusNotFound)
+		case http.StatusMethodNotAllowed:
+			writeError(w, "Method "+r.Method+" not allowed for "+r.URL.Path, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// statusRecorder forwards redirects from the mux and holds back its plain-text 404 and 405 responses
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	if status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+		s.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (s *statusRecorder) Write(body []byte) (int, error) {
+	if s.status == http.StatusNotFound || s.status == http.StatusMethodNotAllowed {
+		return len(body), nil
+	}
+	return s.ResponseWriter.Write(body)
+}
+
+// GetOpenAPIDocument serves the OpenAPI 3.1 description of the API
+func (h *Handlers) GetOpenAPIDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/semaphore99/serverless-workflow-backend/internal/workflows"
+)
+
+// ValidateResponse is the outcome of validating a workflow definition without running it
+type ValidateResponse struct {
+	Valid  bool                        `json:"valid"`
+	Errors []workflows.ValidationIssue `json:"errors"`
+}
+
+// ValidateWorkflow validates the raw YAML or JSON workflow definition in the body without starting
+// an execution. Errors carry a JSON pointer and the line and column in the source.
+func (h *Handlers) ValidateWorkflow(w http.ResponseWriter, r *http.Request) {
+	source, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	issues := workflows.ValidateWorkflowSource(source)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ValidateResponse{Valid: len(issues) == 0, Errors: issues})
+}
@@ -1,7 +1,6 @@
 package workflows
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,52 +8,89 @@ import (
 	"strings"
 	"time"
 
-	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/serverlessworkflow/sdk-go/v3/parser"
-	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
 type ChatbotState struct {
-	Conversation []anthropic.MessageParam `json:"conversation"`
-	ThreadID     string                   `json:"thread_id"`
-	SystemPrompt string                   `json:"system_prompt"`
-	IsProcessing bool                     `json:"is_processing"`
+	Conversation []ChatMessage       `json:"conversation"`
+	ThreadID     string              `json:"thread_id"`
+	SystemPrompt string              `json:"system_prompt"`
+	IsProcessing bool                `json:"is_processing"`
+	Workflows    []GeneratedWorkflow `json:"workflows"`  // validated workflows produced in the thread
+	Executions   []ThreadExecution   `json:"executions"` // executions started from the thread
+	Config       ChatbotConfig       `json:"config"`
+
+	// interactions counts updates and execution outcomes; used to reset the inactivity timeout
+	interactions int
+
+	// executionsStarted numbers child executions; reserved before the start is awaited so that
+	// concurrent execute updates get distinct workflow IDs
+	executionsStarted int
+
+	// functions are the native functions registered on the worker when the thread started,
+	// recorded in history so that generated workflows are linted the same way on replay
+	functions []string
 }
 
-type UserInputSignal struct {
+// SendMessageUpdate is the update that sends a user message and returns the assistant's reply
+const SendMessageUpdate = "send-message"
+
+// Application error types returned when a send-message update is rejected
+const (
+	ChatbotBusyErrorType           = "ChatbotBusy"
+	ChatbotInvalidMessageErrorType = "InvalidMessage"
+)
+
+type SendMessageRequest struct {
 	Message string `json:"message"`
 }
 
+type SendMessageResponse struct {
+	ThreadID     string      `json:"thread_id"`
+	Response     string      `json:"response"`                // text blocks of the reply
+	Message      ChatMessage `json:"message"`                 // the reply with all of its content blocks
+	WorkflowCode string      `json:"workflow_code,omitempty"` // the validated workflow of the reply, with Fixes applied
+	Fixes        []AutoFix   `json:"fixes,omitempty"`         // fixes applied to the workflow written in Message
+}
+
 type WorkflowValidationResult struct {
-	HasWorkflow     bool   `json:"has_workflow"`
-	IsValid         bool   `json:"is_valid"`
-	ValidationError string `json:"validation_error"`
-	WorkflowCode    string `json:"workflow_code"`
+	HasWorkflow     bool                `json:"has_workflow"`
+	IsValid         bool                `json:"is_valid"`         // every candidate workflow is valid
+	ValidationError string              `json:"validation_error"` // errors of the invalid candidates
+	WorkflowCode    string              `json:"workflow_code"`    // the last valid candidate
+	Candidates      []WorkflowCandidate `json:"candidates"`
+}
+
+// WorkflowCandidate is a workflow definition found in a code block of a reply
+type WorkflowCandidate struct {
+	Index           int         `json:"index"` // position among the reply's code blocks; -1 for unfenced JSON
+	Language        string      `json:"language"`
+	Code            string      `json:"code"`
+	IsValid         bool        `json:"is_valid"`
+	ValidationError string      `json:"validation_error,omitempty"`
+	Fixes           []AutoFix   `json:"fixes,omitempty"` // fixes applied to Code before validation
+	LintIssues      []LintIssue `json:"lint_issues,omitempty"`
 }
 
 type ChatbotActivities struct {
-	client *anthropic.Client
+	provider LLMProvider
 }
 
+// NewChatbotActivities creates the chatbot activities with the provider configured in the environment
 func NewChatbotActivities() *ChatbotActivities {
-	apiKey := getClaudeAPIKey()
-	if apiKey == "" {
-		return &ChatbotActivities{client: nil}
-	}
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
-	return &ChatbotActivities{
-		client: &client,
-	}
+	return &ChatbotActivities{provider: NewLLMProviderFromEnv()}
 }
 
-func ChatbotWorkflow(ctx workflow.Context, threadID string) (*ChatbotState, error) {
-	logger := workflow.GetLogger(ctx)
-	logger.Info("ChatbotWorkflow started", "threadID", threadID)
+// NewChatbotActivitiesWithProvider creates the chatbot activities with an explicit provider
+func NewChatbotActivitiesWithProvider(provider LLMProvider) *ChatbotActivities {
+	return &ChatbotActivities{provider: provider}
+}
 
-	// Initialize with system prompt for serverless workflow assistance
-	systemPrompt := `You are a specialized assistant dedicated to helping users create workflow definitions in YAML based on the CNCF Serverless Workflow v1.0 specification. Your primary focus is to:
+// defaultChatbotSystemPrompt is the system prompt for serverless workflow assistance
+const defaultChatbotSystemPrompt = `You are a specialized assistant dedicated to helping users create workflow definitions in YAML based on the CNCF Serverless Workflow v1.0 specification. Your primary focus is to:
 
 1. Help users design and create serverless workflow definitions in YAML format
 2. Follow the official schema specification from https://serverlessworkflow.io/schemas/1.0.0/workflow.yaml
@@ -63,15 +99,28 @@ func ChatbotWorkflow(ctx workflow.Context, threadID string) (*ChatbotState, erro
 5. do not use "uri", use "endpoint" for http calls
 6. Provide clear and concise YAML workflow definitions without unnecessary explanations or comments
 7. use dsl version 1.0.0, not 1.0.0-alpha1
+8. before answering with a workflow, check it with the validate_workflow and dry_run_workflow tools and fix any problems they report; use list_demo_endpoints to pick endpoints
 
 
 Make sure to prioritize creating valid, well-structured workflow definitions that conform to the CNCF Serverless Workflow v1.0 specification with json spec at https://serverlessworkflow.io/schemas/1.0.0/workflow.json. Ask clarifying questions about the user's requirements to build the most appropriate workflow for their use case.`
 
+func ChatbotWorkflow(ctx workflow.Context, threadID string, config ChatbotConfig) (*ChatbotState, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("ChatbotWorkflow started", "threadID", threadID)
+
+	if err := config.Validate(); err != nil {
+		return nil, errInvalidChatbotConfig(err)
+	}
+	config = config.withDefaults()
+
 	state := &ChatbotState{
 		ThreadID:     threadID,
-		Conversation: []anthropic.MessageParam{},
-		SystemPrompt: systemPrompt,
+		Conversation: []ChatMessage{},
+		SystemPrompt: chatbotSystemPrompt(config),
 		IsProcessing: false,
+		Workflows:    []GeneratedWorkflow{},
+		Executions:   []ThreadExecution{},
+		Config:       config,
 	}
 
 	ao := workflow.ActivityOptions{
@@ -79,7 +128,14 @@ Make sure to prioritize creating valid, well-structured workflow definitions tha
 	}
 	ctx = workflow.WithActivityOptions(ctx, ao)
 
-	err := workflow.SetQueryHandler(ctx, "get-state", func() (*ChatbotState, error) {
+	err := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return RegisteredFunctions()
+	}).Get(&state.functions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = workflow.SetQueryHandler(ctx, "get-state", func() (*ChatbotState, error) {
 		return state, nil
 	})
 	if err != nil {
@@ -87,236 +143,363 @@ Make sure to prioritize creating valid, well-structured workflow definitions tha
 		return nil, err
 	}
 
-	signalCh := workflow.GetSignalChannel(ctx, "user-input")
+	err = workflow.SetUpdateHandlerWithOptions(ctx, SendMessageUpdate,
+		func(ctx workflow.Context, request SendMessageRequest) (*SendMessageResponse, error) {
+			// Re-checked here as several updates may pass validation within the same workflow task
+			if state.IsProcessing {
+				return nil, errChatbotBusy()
+			}
+			defer func() { state.interactions++ }()
+
+			// Update handlers run on the root context, without the workflow's activity options
+			ctx = workflow.WithActivityOptions(ctx, ao)
+			generated := len(state.Workflows)
+			processUserMessage(ctx, state, request.Message)
+			reply := lastAssistantMessage(state.Conversation)
+			response := &SendMessageResponse{
+				ThreadID: state.ThreadID,
+				Response: reply.Text(),
+				Message:  reply,
+			}
+			if len(state.Workflows) > generated {
+				latest := state.Workflows[len(state.Workflows)-1]
+				response.WorkflowCode = latest.WorkflowCode
+				response.Fixes = latest.Fixes
+			}
+			return response, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, request SendMessageRequest) error {
+				if strings.TrimSpace(request.Message) == "" {
+					return temporal.NewApplicationError("message is required", ChatbotInvalidMessageErrorType)
+				}
+				if state.IsProcessing {
+					return errChatbotBusy()
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to set update handler", "error", err)
+		return nil, err
+	}
+	err = setExecuteWorkflowHandler(ctx, state)
+	if err != nil {
+		logger.Error("Failed to set execute workflow update handler", "error", err)
+		return nil, err
+	}
+	err = setUpdateConfigHandler(ctx, state)
+	if err != nil {
+		logger.Error("Failed to set update config handler", "error", err)
+		return nil, err
+	}
 
 	for {
-		var userInput UserInputSignal
-		signalReceived, _ := signalCh.ReceiveWithTimeout(ctx, 10*time.Minute, &userInput)
-		if !signalReceived {
+		handled := state.interactions
+		received, err := workflow.AwaitWithTimeout(ctx, 10*time.Minute, func() bool {
+			return state.IsProcessing || state.interactions != handled
+		})
+		if err != nil {
+			return state, err
+		}
+		if !received {
 			logger.Info("ChatbotWorkflow completed - timeout after 10 minutes of inactivity")
 			break
 		}
 
-		logger.Info("Received user input", "message", userInput.Message)
-
-		// Mark as processing when we start handling the user input
-		state.IsProcessing = true
-		state.Conversation = append(state.Conversation, anthropic.NewUserMessage(anthropic.NewTextBlock(userInput.Message)))
-
-		activities := NewChatbotActivities()
+		// A message is being handled; the inactivity timeout restarts once it has been answered
+		if err := workflow.Await(ctx, func() bool { return !state.IsProcessing }); err != nil {
+			return state, err
+		}
+	}
 
-		// Try to get a valid response, with retries for invalid workflows
-		maxRetries := 4
-		for retry := 0; retry < maxRetries; retry++ {
-			var response *anthropic.Message
-			err := workflow.ExecuteActivity(ctx, activities.CallClaudeAPI, state.SystemPrompt, state.Conversation).Get(ctx, &response)
-			if err != nil {
-				logger.Error("Failed to call Claude API", "error", err)
+	// Let in-flight updates reply before the workflow completes
+	if err := workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) }); err != nil {
+		return state, err
+	}
 
-				errorResponse := "I apologize, but I'm having trouble connecting to the AI service right now. Please try again later."
-				state.Conversation = append(state.Conversation, anthropic.NewAssistantMessage(anthropic.NewTextBlock(errorResponse)))
-				state.IsProcessing = false
-				break
-			}
+	return state, nil
+}
 
-			// Check if the response contains a valid workflow (check YAML first since system prompt requests YAML)
-			validationResult := validateWorkflowInResponse(response, "yaml")
-			if validationResult.ValidationError != "" {
-				logger.Error("Failed to validate workflow in response", "error", validationResult.ValidationError)
-			}
+// processUserMessage appends the user message and the assistant's reply to the conversation,
+// asking the model to correct invalid workflows up to a fixed number of attempts
+func processUserMessage(ctx workflow.Context, state *ChatbotState, message string) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Received user input", "message", message)
 
-			if validationResult.IsValid {
-				// Valid workflow, add response and continue
-				state.Conversation = append(state.Conversation, response.ToParam())
-				logger.Info("Added Claude response to chat history", "response", response.Content)
-				state.IsProcessing = false
-				break
-			} else if validationResult.HasWorkflow {
-				// Invalid workflow, ask Claude to fix it
-				logger.Info("Invalid workflow detected, asking Claude to fix it", "error", validationResult.ValidationError)
+	// Mark as processing when we start handling the user input
+	state.IsProcessing = true
+	defer func() { state.IsProcessing = false }()
+	state.Conversation = append(state.Conversation, NewTextMessage(RoleUser, message))
 
-				// Add the response first
-				state.Conversation = append(state.Conversation, response.ToParam())
+	var activities *ChatbotActivities
 
-				// Then add correction request
-				correctionPrompt := fmt.Sprintf("The workflow you provided has validation errors:\n\n%s\n\nPlease correct the workflow and provide a valid YAML workflow definition.", validationResult.ValidationError)
-				state.Conversation = append(state.Conversation, anthropic.NewUserMessage(anthropic.NewTextBlock(correctionPrompt)))
+	// Replies are streamed, so allow long generations but detect stalled streams via heartbeats
+	streamCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 2 * time.Minute,
+		HeartbeatTimeout:    15 * time.Second,
+	})
 
-				// If this is the last retry, stop processing
-				if retry == maxRetries-1 {
-					state.IsProcessing = false
-				}
+	// Try to get a valid response, with retries for invalid workflows
+	for retry := 0; retry < state.Config.MaxRetries; retry++ {
+		response, err := generateReply(streamCtx, activities, state)
 
-				// Continue to next retry
-				continue
-			} else {
-				// No workflow in response, just add it normally
-				state.Conversation = append(state.Conversation, response.ToParam())
-				logger.Info("Added Claude response to chat history", "response", response.Content)
-				state.IsProcessing = false
+		// Let the model call its tools, recording the calls and results in the thread, until it answers
+		for round := 0; err == nil && response.StopReason == StopToolUse; round++ {
+			state.Conversation = append(state.Conversation, response.Message)
+			state.Conversation = append(state.Conversation, executeChatbotTools(ctx, state, response.Message))
+			if round == maxToolRounds-1 {
+				err = fmt.Errorf("no answer after %d rounds of tool calls", maxToolRounds)
 				break
 			}
+			response, err = generateReply(streamCtx, activities, state)
 		}
-	}
+		if err != nil {
+			logger.Error("Failed to generate reply", "error", err)
 
-	return state, nil
-}
-
-func (a *ChatbotActivities) CallClaudeAPI(ctx context.Context, systemPrompt string, conversation []anthropic.MessageParam) (*anthropic.Message, error) {
-	logger := activity.GetLogger(ctx)
-	logger.Info("CallClaudeAPI activity started")
+			errorResponse := "I apologize, but I'm having trouble connecting to the AI service right now. Please try again later."
+			state.Conversation = append(state.Conversation, NewTextMessage(RoleAssistant, errorResponse))
+			return
+		}
 
-	if a.client == nil {
-		return nil, fmt.Errorf("claude API key not found in environment variables")
-	}
+		// Check if the response contains a valid workflow in the configured format
+		validationResult := validateWorkflowInResponse(response.Message, state.Config.Format, state.functions)
+		if validationResult.ValidationError != "" {
+			logger.Error("Failed to validate workflow in response", "error", validationResult.ValidationError)
+		}
 
-	resp, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.ModelClaude4Sonnet20250514,
-		MaxTokens: 4096,
-		System: []anthropic.TextBlockParam{
-			{Text: systemPrompt},
-		},
-		Messages: conversation,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Claude API: %w", err)
+		fixes := validationResult.fixes()
+		if validationResult.IsValid {
+			// Valid workflow, add response and remember it so it can be executed from the thread
+			state.Conversation = append(state.Conversation, response.Message)
+			state.Workflows = append(state.Workflows, GeneratedWorkflow{
+				MessageIndex: len(state.Conversation) - 1,
+				WorkflowCode: validationResult.WorkflowCode,
+				Fixes:        fixes,
+			})
+			logger.Info("Added assistant response to chat history", "response", response.Message.Text())
+			return
+		} else if validationResult.HasWorkflow {
+			// Invalid workflow, ask the model to fix it
+			logger.Info("Invalid workflow detected, asking the model to fix it", "error", validationResult.ValidationError)
+
+			// Add the response first
+			state.Conversation = append(state.Conversation, response.Message)
+
+			// Then add correction request
+			correctionPrompt := fmt.Sprintf("The workflow you provided has validation errors:\n\n%s\n\nPlease correct the workflow and provide a valid %s workflow definition.", validationResult.ValidationError, strings.ToUpper(state.Config.Format))
+			if len(fixes) > 0 {
+				correctionPrompt = "I automatically applied these fixes to your workflow:\n\n" + describeAutoFixes(fixes) +
+					"\n\nKeep them in your corrected workflow. " + correctionPrompt
+			}
+			state.Conversation = append(state.Conversation, NewTextMessage(RoleUser, correctionPrompt))
+
+			// Continue to next retry
+			continue
+		} else {
+			// No workflow in response, just add it normally
+			state.Conversation = append(state.Conversation, response.Message)
+			logger.Info("Added assistant response to chat history", "response", response.Message.Text())
+			return
+		}
 	}
+}
 
-	if len(resp.Content) == 0 {
-		return nil, fmt.Errorf("empty response content from Claude API")
-	}
+// generateReply asks the provider for the next assistant message of the thread
+func generateReply(ctx workflow.Context, activities *ChatbotActivities, state *ChatbotState) (*LLMResponse, error) {
+	var response *LLMResponse
+	err := workflow.ExecuteActivity(ctx, activities.GenerateReply, LLMRequest{
+		ThreadID:     state.ThreadID,
+		SystemPrompt: state.SystemPrompt,
+		Messages:     state.Conversation,
+		Tools:        chatbotTools(),
+		Model:        state.Config.Model,
+		MaxTokens:    state.Config.MaxTokens,
+		Temperature:  state.Config.Temperature,
+	}).Get(ctx, &response)
+	return response, err
+}
 
-	// Log if response was truncated due to max tokens
-	if resp.StopReason == "max_tokens" {
-		logger.Warn("Response was truncated due to max_tokens limit",
-			"input_tokens", resp.Usage.InputTokens,
-			"output_tokens", resp.Usage.OutputTokens)
-	} else {
-		logger.Info("Response completed normally",
-			"stop_reason", resp.StopReason,
-			"input_tokens", resp.Usage.InputTokens,
-			"output_tokens", resp.Usage.OutputTokens)
+// lastAssistantMessage returns the most recent assistant message
+func lastAssistantMessage(conversation []ChatMessage) ChatMessage {
+	for i := len(conversation) - 1; i >= 0; i-- {
+		if conversation[i].Role == RoleAssistant {
+			return conversation[i]
+		}
 	}
+	return ChatMessage{Role: RoleAssistant, Content: []ContentBlock{}}
+}
 
-	return resp, nil
+func errChatbotBusy() error {
+	return temporal.NewApplicationError("a message is already being processed for this thread", ChatbotBusyErrorType)
 }
 
-// validateWorkflowInResponse validates workflow code in Claude's response (deterministic function)
-func validateWorkflowInResponse(response *anthropic.Message, format string) WorkflowValidationResult {
+// validateWorkflowInResponse extracts every candidate workflow from the text blocks of the
+// assistant's response and validates each of them (deterministic function). Fenced yaml, yml,
+// json or unlabelled code blocks with a top-level document are candidates; with the JSON format
+// an unfenced JSON object is used when the reply has no fenced candidate.
+func validateWorkflowInResponse(response ChatMessage, format string, functions []string) WorkflowValidationResult {
 	result := WorkflowValidationResult{
 		HasWorkflow:     false,
 		IsValid:         false,
 		ValidationError: "",
 		WorkflowCode:    "",
+		Candidates:      []WorkflowCandidate{},
 	}
 
-	// Extract text content from the response
-	var responseText string
-	if len(response.Content) > 0 {
-		// Convert to JSON and extract text content
-		contentBytes, err := json.Marshal(response.Content[0])
-		if err == nil {
-			var contentBlock map[string]interface{}
-			if json.Unmarshal(contentBytes, &contentBlock) == nil {
-				if text, exists := contentBlock["text"]; exists {
-					if textStr, ok := text.(string); ok {
-						responseText = textStr
-					}
-				}
-			}
-		}
-	}
-
+	responseText := response.Text()
 	if responseText == "" {
 		return result
 	}
 
-	// Look for code blocks based on format
-	var codeBlock string
-	if format == "yaml" {
-		codeBlock = extractYAMLCodeBlock(responseText)
-		if codeBlock == "" {
-			// Fallback to JSON if no YAML found
-			codeBlock = extractJSONCodeBlock(responseText)
+	for i, block := range extractCodeBlocks(responseText) {
+		if !isWorkflowCodeBlock(block) {
+			continue
+		}
+		result.Candidates = append(result.Candidates, validateWorkflowCandidate(i, block, functions))
+	}
+	if len(result.Candidates) == 0 && format == FormatJSON {
+		if code := extractStandaloneJSON(responseText); code != "" && workflowDocumentPattern.MatchString(code) {
+			result.Candidates = append(result.Candidates, validateWorkflowCandidate(-1, CodeBlock{Language: FormatJSON, Code: code}, functions))
 		}
-	} else {
-		codeBlock = extractJSONCodeBlock(responseText)
 	}
 
-	if codeBlock == "" {
+	if len(result.Candidates) == 0 {
 		return result
 	}
-
 	result.HasWorkflow = true
-	result.WorkflowCode = codeBlock
-
-	// Try to parse the workflow based on format
-	var err error
-	if format == "yaml" {
-		_, err = parser.FromYAMLSource([]byte(codeBlock))
-	} else {
-		_, err = parser.FromJSONSource([]byte(codeBlock))
+	result.IsValid = true
+
+	var errs []string
+	for _, candidate := range result.Candidates {
+		if !candidate.IsValid {
+			result.IsValid = false
+			if len(result.Candidates) == 1 {
+				errs = append(errs, candidate.ValidationError)
+			} else {
+				errs = append(errs, fmt.Sprintf("Code block %d: %s", candidate.Index+1, candidate.ValidationError))
+			}
+			continue
+		}
+		// The last valid workflow of the reply is the one recorded for execution, in the thread's format
+		result.WorkflowCode = candidateInFormat(candidate, format)
 	}
-
-	if err != nil {
-		result.IsValid = false
-		result.ValidationError = err.Error()
-	} else {
-		result.IsValid = true
+	if !result.IsValid {
+		result.WorkflowCode = ""
+		result.ValidationError = strings.Join(errs, "\n\n")
 	}
 
 	return result
 }
 
-// extractJSONCodeBlock extracts JSON code from markdown code blocks
-func extractJSONCodeBlock(text string) string {
-	// Look for ```json or ``` followed by JSON content
-	patterns := []string{
-		"```json\\s*\\n([\\s\\S]*?)```",
-		"```\\s*\\n(\\{[\\s\\S]*?\\})```",
+// candidateInFormat returns the code of a valid candidate converted to the given format, keeping
+// its key order
+func candidateInFormat(candidate WorkflowCandidate, format string) string {
+	isJSON := candidate.Language == FormatJSON || strings.HasPrefix(strings.TrimSpace(candidate.Code), "{")
+	if format == "" || isJSON == (format == FormatJSON) {
+		return candidate.Code
+	}
+	converted, err := ConvertWorkflow([]byte(candidate.Code), format, false)
+	if err != nil {
+		return candidate.Code
 	}
+	return strings.TrimSpace(string(converted))
+}
 
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(text)
-		if len(matches) > 1 {
-			return strings.TrimSpace(matches[1])
-		}
+// fixes returns the auto-fixes applied to the candidates
+func (r WorkflowValidationResult) fixes() []AutoFix {
+	var fixes []AutoFix
+	for _, candidate := range r.Candidates {
+		fixes = append(fixes, candidate.Fixes...)
 	}
+	return fixes
+}
 
-	// If no code block found, look for standalone JSON objects
-	jsonPattern := `\{[^{}]*(?:\{[^{}]*\}[^{}]*)*\}`
-	re := regexp.MustCompile(jsonPattern)
-	matches := re.FindAllString(text, -1)
+// validateWorkflowCandidate auto-fixes known mistakes in a code block and parses it as a
+// workflow definition, linting calls against the given native functions
+func validateWorkflowCandidate(index int, block CodeBlock, functions []string) WorkflowCandidate {
+	candidate := WorkflowCandidate{Index: index, Language: block.Language, IsValid: true}
+	candidate.Code, candidate.Fixes = autoFixWorkflow(block.Code, block.Language)
+	block.Code = candidate.Code
 
-	// Return the largest JSON object found
-	var largestJSON string
-	for _, match := range matches {
-		if len(match) > len(largestJSON) {
-			largestJSON = match
-		}
+	var workflowDef *model.Workflow
+	var err error
+	if block.Language == FormatJSON {
+		workflowDef, err = parser.FromJSONSource([]byte(block.Code))
+	} else {
+		// JSON is valid YAML, so unlabelled blocks parse either way
+		workflowDef, err = parser.FromYAMLSource([]byte(block.Code))
+	}
+	if err != nil {
+		candidate.IsValid = false
+		candidate.ValidationError = err.Error()
+		return candidate
 	}
 
-	return strings.TrimSpace(largestJSON)
+	// Logic errors the parser accepts fail the candidate too; warnings only ride along in the
+	// correction prompt
+	candidate.LintIssues = LintWorkflow(workflowDef, LintOptions{Functions: functions})
+	if HasLintErrors(candidate.LintIssues) {
+		candidate.IsValid = false
+		candidate.ValidationError = "lint issues:\n" + describeLintIssues(candidate.LintIssues)
+	}
+	return candidate
+}
+
+// CodeBlock is a fenced code block of a message
+type CodeBlock struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
 }
 
-// extractYAMLCodeBlock extracts YAML code from markdown code blocks
-func extractYAMLCodeBlock(text string) string {
-	// Look for ```yaml or ```yml followed by YAML content
-	patterns := []string{
-		"```yaml\\s*\\n([\\s\\S]*?)```",
-		"```yml\\s*\\n([\\s\\S]*?)```",
+var (
+	codeBlockPattern        = regexp.MustCompile("(?m)^[ \\t]*```[ \\t]*([\\w+-]*)[^\\n]*\\n([\\s\\S]*?)^[ \\t]*```")
+	workflowDocumentPattern = regexp.MustCompile(`(?m)^document[ \t]*:|"document"\s*:`)
+)
+
+// extractCodeBlocks returns every fenced code block in the text, in order
+func extractCodeBlocks(text string) []CodeBlock {
+	var blocks []CodeBlock
+	for _, match := range codeBlockPattern.FindAllStringSubmatch(text, -1) {
+		blocks = append(blocks, CodeBlock{
+			Language: strings.ToLower(match[1]),
+			Code:     strings.TrimSpace(match[2]),
+		})
 	}
+	return blocks
+}
 
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(text)
-		if len(matches) > 1 {
-			return strings.TrimSpace(matches[1])
-		}
+// isWorkflowCodeBlock reports whether a code block holds a workflow definition rather than, say,
+// an input document or a shell command
+func isWorkflowCodeBlock(block CodeBlock) bool {
+	switch block.Language {
+	case "yaml", "yml", FormatJSON, "":
+		return workflowDocumentPattern.MatchString(block.Code)
+	default:
+		return false
 	}
+}
 
-	return ""
+// extractStandaloneJSON returns the largest unfenced JSON object in the text
+func extractStandaloneJSON(text string) string {
+	var largestJSON string
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' {
+			continue
+		}
+		var object json.RawMessage
+		decoder := json.NewDecoder(strings.NewReader(text[i:]))
+		if decoder.Decode(&object) != nil {
+			continue
+		}
+		if len(object) > len(largestJSON) {
+			largestJSON = string(object)
+		}
+		// Skip past the object so nested objects are not decoded again
+		i += int(decoder.InputOffset()) - 1
+	}
+	return strings.TrimSpace(largestJSON)
 }
 
 func getClaudeAPIKey() string {
@@ -0,0 +1,586 @@
+package workflows
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// toolUseResponse scripts an assistant message requesting the given tool calls
+func toolUseResponse(t *testing.T, calls ...ContentBlock) LLMResponse {
+	t.Helper()
+	message := ChatMessage{Role: RoleAssistant}
+	for _, call := range calls {
+		call.Type = BlockToolUse
+		message.Content = append(message.Content, call)
+	}
+	return LLMResponse{Message: message, StopReason: StopToolUse}
+}
+
+func toolInput(t *testing.T, input interface{}) json.RawMessage {
+	t.Helper()
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		t.Fatalf("Failed to encode tool input: %v", err)
+	}
+	return encoded
+}
+
+func TestSendMessageUpdate(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ChatbotWorkflow)
+	env.RegisterActivity(NewChatbotActivitiesWithProvider(NewScriptedProvider(
+		NewScriptedTextResponse("Hi! What should the workflow do?"),
+	)))
+
+	var first *SendMessageResponse
+	var firstErr, secondErr, emptyErr error
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(SendMessageUpdate, "first", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { firstErr = err },
+			OnComplete: func(result interface{}, err error) {
+				firstErr = err
+				first, _ = result.(*SendMessageResponse)
+			},
+		}, SendMessageRequest{Message: "Hello"})
+		env.UpdateWorkflow(SendMessageUpdate, "second", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { secondErr = err },
+			OnComplete: func(result interface{}, err error) { secondErr = err },
+		}, SendMessageRequest{Message: "Are you there?"})
+		env.UpdateWorkflow(SendMessageUpdate, "empty", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { emptyErr = err },
+			OnComplete: func(result interface{}, err error) {},
+		}, SendMessageRequest{Message: "  "})
+	}, 0)
+
+	env.ExecuteWorkflow(ChatbotWorkflow, "thread-1", ChatbotConfig{})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if firstErr != nil {
+		t.Fatalf("Expected first message to succeed, got %v", firstErr)
+	}
+	if first == nil || first.Response != "Hi! What should the workflow do?" || first.ThreadID != "thread-1" {
+		t.Errorf("Unexpected reply %+v", first)
+	}
+	if secondErr == nil {
+		t.Error("Expected concurrent message to be rejected while processing")
+	}
+	if emptyErr == nil {
+		t.Error("Expected empty message to be rejected")
+	}
+
+	var state ChatbotState
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(state.Conversation) != 2 || state.IsProcessing {
+		t.Errorf("Expected one exchange and no processing, got %d messages, processing=%v", len(state.Conversation), state.IsProcessing)
+	}
+}
+
+func TestExecuteWorkflowFromThread(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ChatbotWorkflow)
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(NewChatbotActivitiesWithProvider(NewScriptedProvider(
+		NewScriptedTextResponse("Here you go:\n\n```yaml\ndocument:\n  dsl: 1.0.0\n  namespace: test\n  name: greet\n  version: 1.0.0\ndo:\n  - greet:\n      set:\n        greeting: hello\n```"),
+	)))
+	env.RegisterActivity(EvaluateValueActivity)
+
+	var started, startedAgain *ThreadExecution
+	var executeErr, executeAgainErr, earlyErr error
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(ExecuteWorkflowUpdate, "too-early", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { earlyErr = err },
+			OnComplete: func(result interface{}, err error) {},
+		}, ExecuteWorkflowRequest{})
+		env.UpdateWorkflow(SendMessageUpdate, "message", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) {},
+			OnComplete: func(result interface{}, err error) {},
+		}, SendMessageRequest{Message: "Build a greeting workflow"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(ExecuteWorkflowUpdate, "execute", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { executeErr = err },
+			OnComplete: func(result interface{}, err error) {
+				executeErr = err
+				started, _ = result.(*ThreadExecution)
+			},
+		}, ExecuteWorkflowRequest{})
+		env.UpdateWorkflow(ExecuteWorkflowUpdate, "execute-again", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { executeAgainErr = err },
+			OnComplete: func(result interface{}, err error) {
+				executeAgainErr = err
+				startedAgain, _ = result.(*ThreadExecution)
+			},
+		}, ExecuteWorkflowRequest{})
+	}, time.Minute)
+
+	env.ExecuteWorkflow(ChatbotWorkflow, "thread-2", ChatbotConfig{})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if earlyErr == nil {
+		t.Error("Expected execution to be rejected before a workflow was generated")
+	}
+	if executeErr != nil || executeAgainErr != nil {
+		t.Fatalf("Expected both executions to start, got %v and %v", executeErr, executeAgainErr)
+	}
+	if started == nil || started.WorkflowID != "yaml-workflow-thread-2-1" || started.MessageIndex != 1 {
+		t.Fatalf("Unexpected execution %+v", started)
+	}
+	if startedAgain == nil || startedAgain.WorkflowID != "yaml-workflow-thread-2-2" {
+		t.Fatalf("Expected concurrent executions to get distinct IDs, got %+v", startedAgain)
+	}
+
+	var state ChatbotState
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(state.Executions) != 2 || state.Executions[0].Status != WorkflowStatusCompleted || state.Executions[1].Status != WorkflowStatusCompleted {
+		t.Fatalf("Expected two completed executions, got %+v", state.Executions)
+	}
+	if len(state.Conversation) != 4 {
+		t.Fatalf("Expected the outcome to be summarised in the conversation, got %d messages", len(state.Conversation))
+	}
+}
+
+func TestExecuteWorkflowFromThreadSearchAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		thread   temporal.SearchAttributes // attributes the thread was started with
+		expected map[temporal.SearchAttributeKeyKeyword]string
+	}{
+		{
+			name:   "registered",
+			thread: ChatbotSearchAttributes(),
+			expected: map[temporal.SearchAttributeKeyKeyword]string{
+				SearchAttributeKind:                ExecutionKindServerless,
+				SearchAttributeDefinitionNamespace: "test",
+				SearchAttributeDefinitionName:      "greet",
+				SearchAttributeDefinitionVersion:   "1.0.0",
+			},
+		},
+		{
+			// The API starts the thread untagged when the namespace rejects the attributes
+			name:     "unregistered",
+			thread:   temporal.NewSearchAttributes(),
+			expected: map[temporal.SearchAttributeKeyKeyword]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(ChatbotWorkflow)
+			env.RegisterActivity(NewChatbotActivitiesWithProvider(NewScriptedProvider(
+				NewScriptedTextResponse("Here you go:\n\n```yaml\ndocument:\n  dsl: 1.0.0\n  namespace: test\n  name: greet\n  version: 1.0.0\ndo:\n  - greet:\n      set:\n        greeting: hello\n```"),
+			)))
+			env.RegisterActivity(EvaluateValueActivity)
+			if err := env.SetTypedSearchAttributesOnStart(tt.thread); err != nil {
+				t.Fatalf("Failed to set search attributes: %v", err)
+			}
+
+			// Stands in for the serverless interpreter to capture the search attributes of the child
+			var attributes temporal.SearchAttributes
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context, workflowYAML string, input map[string]interface{}) (map[string]interface{}, error) {
+				attributes = workflow.GetTypedSearchAttributes(ctx)
+				return nil, nil
+			}, workflow.RegisterOptions{Name: "ExecuteServerlessYAMLWorkflow"})
+
+			env.RegisterDelayedCallback(func() {
+				env.UpdateWorkflow(SendMessageUpdate, "message", &testsuite.TestUpdateCallback{
+					OnAccept:   func() {},
+					OnReject:   func(err error) {},
+					OnComplete: func(result interface{}, err error) {},
+				}, SendMessageRequest{Message: "Build a greeting workflow"})
+			}, 0)
+			var executeErr error
+			env.RegisterDelayedCallback(func() {
+				env.UpdateWorkflow(ExecuteWorkflowUpdate, "execute", &testsuite.TestUpdateCallback{
+					OnAccept:   func() {},
+					OnReject:   func(err error) { executeErr = err },
+					OnComplete: func(result interface{}, err error) { executeErr = err },
+				}, ExecuteWorkflowRequest{})
+			}, time.Minute)
+
+			env.ExecuteWorkflow(ChatbotWorkflow, "thread-3", ChatbotConfig{})
+
+			if err := env.GetWorkflowError(); err != nil {
+				t.Fatalf("Workflow failed: %v", err)
+			}
+			if executeErr != nil {
+				t.Fatalf("Expected the execution to start, got %v", executeErr)
+			}
+			if attributes.Size() != len(tt.expected) {
+				t.Errorf("Expected %d search attributes on the child, got %d", len(tt.expected), attributes.Size())
+			}
+			for key, expected := range tt.expected {
+				if value, _ := attributes.GetKeyword(key); value != expected {
+					t.Errorf("Expected %s %q, got %q", key.GetName(), expected, value)
+				}
+			}
+		})
+	}
+}
+
+func TestChatbotToolUse(t *testing.T) {
+	definition := "document:\n  dsl: 1.0.0\n  namespace: test\n  name: ping\n  version: 1.0.0\ndo:\n  - ping:\n      call: http\n      with:\n        method: get\n        endpoint: http://localhost:8088/demo/ping\n"
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ChatbotWorkflow)
+	env.RegisterWorkflow(DryRunServerlessWorkflow)
+	env.RegisterActivity(NewChatbotActivitiesWithProvider(NewScriptedProvider(
+		toolUseResponse(t,
+			ContentBlock{ID: "toolu_1", Name: ToolValidateWorkflow, Input: toolInput(t, map[string]string{"workflow": definition})},
+			ContentBlock{ID: "toolu_2", Name: ToolDryRunWorkflow, Input: toolInput(t, map[string]string{"workflow": definition})},
+		),
+		NewScriptedTextResponse("The workflow validates and runs."),
+	)))
+
+	var reply *SendMessageResponse
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(SendMessageUpdate, "message", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("Update rejected: %v", err) },
+			OnComplete: func(result interface{}, err error) { reply, _ = result.(*SendMessageResponse) },
+		}, SendMessageRequest{Message: "Ping the demo API"})
+	}, 0)
+
+	env.ExecuteWorkflow(ChatbotWorkflow, "thread-3", ChatbotConfig{})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if reply == nil || reply.Response != "The workflow validates and runs." {
+		t.Fatalf("Unexpected reply %+v", reply)
+	}
+
+	var state ChatbotState
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	// user, assistant tool calls, user tool results, assistant answer
+	if len(state.Conversation) != 4 {
+		t.Fatalf("Expected tool calls and results in the thread, got %d messages", len(state.Conversation))
+	}
+	results := state.Conversation[2].Content
+	if len(results) != 2 || results[0].Type != BlockToolResult || results[1].Type != BlockToolResult {
+		t.Fatalf("Expected two tool results, got %+v", results)
+	}
+	for _, result := range results {
+		if result.IsError {
+			t.Errorf("Expected tool %s to succeed, got %s", result.ToolUseID, result.Text)
+		}
+	}
+	if text := results[1].Text; !strings.Contains(text, `"dry_run":true`) {
+		t.Errorf("Expected the dry run to simulate the HTTP call, got %s", text)
+	}
+}
+
+func TestChatbotCorrectsInvalidWorkflow(t *testing.T) {
+	invalid := "```yaml\ndocument:\n  dsl: 1.0.0\n  namespace: test\n  name: broken\n  version: 1.0.0\ndo: not-a-list\n```"
+	valid := "```yaml\ndocument:\n  dsl: 1.0.0\n  namespace: test\n  name: fixed\n  version: 1.0.0\ndo:\n  - greet:\n      set:\n        greeting: hello\n```"
+	provider := NewScriptedProvider(NewScriptedTextResponse(invalid), NewScriptedTextResponse(valid))
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ChatbotWorkflow)
+	env.RegisterActivity(NewChatbotActivitiesWithProvider(provider))
+
+	var reply *SendMessageResponse
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(SendMessageUpdate, "message", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("Update rejected: %v", err) },
+			OnComplete: func(result interface{}, err error) { reply, _ = result.(*SendMessageResponse) },
+		}, SendMessageRequest{Message: "Build a greeting workflow"})
+	}, 0)
+
+	env.ExecuteWorkflow(ChatbotWorkflow, "thread-4", ChatbotConfig{})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if reply == nil || reply.Response != valid {
+		t.Fatalf("Expected the corrected workflow as reply, got %+v", reply)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected a correction round, got %d requests", len(requests))
+	}
+	correction := requests[1].Messages[len(requests[1].Messages)-1]
+	if correction.Role != RoleUser || !strings.Contains(correction.Text(), "validation errors") {
+		t.Errorf("Expected the correction prompt to be sent, got %+v", correction)
+	}
+
+	var state ChatbotState
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	// user, invalid reply, correction prompt, valid reply
+	if len(state.Conversation) != 4 || len(state.Workflows) != 1 || state.Workflows[0].MessageIndex != 3 {
+		t.Errorf("Expected only the corrected workflow to be recorded, got %d messages and %+v", len(state.Conversation), state.Workflows)
+	}
+}
+
+func TestChatbotConfig(t *testing.T) {
+	temperature := 0.2
+	jsonWorkflow := "```json\n{\"document\": {\"dsl\": \"1.0.0\", \"namespace\": \"test\", \"name\": \"greet\", \"version\": \"1.0.0\"}, \"do\": [{\"greet\": {\"set\": {\"greeting\": \"hello\"}}}]}\n```"
+	provider := NewScriptedProvider(NewScriptedTextResponse(jsonWorkflow), NewScriptedTextResponse("Done."))
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ChatbotWorkflow)
+	env.RegisterActivity(NewChatbotActivitiesWithProvider(provider))
+
+	var updated *ChatbotConfig
+	var invalidErr, unknownResetErr error
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(SendMessageUpdate, "message", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("Update rejected: %v", err) },
+			OnComplete: func(result interface{}, err error) {},
+		}, SendMessageRequest{Message: "Build a greeting workflow"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(UpdateConfigUpdate, "invalid", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { invalidErr = err },
+			OnComplete: func(result interface{}, err error) {},
+		}, ChatbotConfigUpdate{Config: ChatbotConfig{Format: "xml"}})
+		env.UpdateWorkflow(UpdateConfigUpdate, "unknown-reset", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { unknownResetErr = err },
+			OnComplete: func(result interface{}, err error) {},
+		}, ChatbotConfigUpdate{Reset: []string{"colour"}})
+		env.UpdateWorkflow(UpdateConfigUpdate, "config", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("Config update rejected: %v", err) },
+			OnComplete: func(result interface{}, err error) { updated, _ = result.(*ChatbotConfig) },
+		}, ChatbotConfigUpdate{
+			Config: ChatbotConfig{Model: "claude-opus-4-20250514", SystemPrompt: "You only write workflows."},
+			Reset:  []string{"temperature", "system_prompt_append"},
+		})
+		env.UpdateWorkflow(SendMessageUpdate, "second", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("Update rejected: %v", err) },
+			OnComplete: func(result interface{}, err error) {},
+		}, SendMessageRequest{Message: "Thanks"})
+	}, time.Minute)
+
+	env.ExecuteWorkflow(ChatbotWorkflow, "thread-5", ChatbotConfig{
+		Model:              "llama3",
+		MaxTokens:          1024,
+		Temperature:        &temperature,
+		SystemPromptAppend: "Name workflows in kebab-case.",
+		Format:             FormatJSON,
+	})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if invalidErr == nil {
+		t.Error("Expected an invalid format to be rejected")
+	}
+	if unknownResetErr == nil {
+		t.Error("Expected a reset of an unknown field to be rejected")
+	}
+	if updated == nil || updated.Model != "claude-opus-4-20250514" || updated.Format != FormatJSON || updated.MaxRetries != defaultMaxRetries ||
+		updated.Temperature != nil || updated.SystemPromptAppend != "" {
+		t.Fatalf("Expected the update to be merged into the configuration, got %+v", updated)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected two requests, got %d", len(requests))
+	}
+	first := requests[0]
+	if first.Model != "llama3" || first.MaxTokens != 1024 || first.Temperature == nil || *first.Temperature != temperature {
+		t.Errorf("Expected the thread configuration in the request, got %+v", first)
+	}
+	if !strings.HasPrefix(first.SystemPrompt, defaultChatbotSystemPrompt) || !strings.Contains(first.SystemPrompt, "JSON instead of YAML") ||
+		!strings.HasSuffix(first.SystemPrompt, "Name workflows in kebab-case.") {
+		t.Errorf("Unexpected system prompt %q", first.SystemPrompt)
+	}
+	second := requests[1]
+	if second.Model != "claude-opus-4-20250514" || !strings.HasPrefix(second.SystemPrompt, "You only write workflows.") ||
+		second.Temperature != nil || strings.Contains(second.SystemPrompt, "kebab-case") {
+		t.Errorf("Expected the updated configuration in the request, got %+v", second)
+	}
+
+	var state ChatbotState
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(state.Workflows) != 1 || !strings.HasPrefix(state.Workflows[0].WorkflowCode, "{") {
+		t.Errorf("Expected the JSON workflow to be validated, got %+v", state.Workflows)
+	}
+}
+
+func TestChatbotConfigValidate(t *testing.T) {
+	tooHot := 1.5
+	invalid := []ChatbotConfig{
+		{MaxTokens: -1},
+		{MaxTokens: maxTokensLimit + 1},
+		{Temperature: &tooHot},
+		{Format: "xml"},
+		{MaxRetries: maxRetriesLimit + 1},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+	if err := (ChatbotConfig{Format: FormatYAML, MaxTokens: 8000, MaxRetries: 2}).Validate(); err != nil {
+		t.Errorf("Expected a valid configuration, got %v", err)
+	}
+}
+
+func TestValidateWorkflowInResponse(t *testing.T) {
+	valid := "```yaml\ndocument:\n  dsl: 1.0.0\n  namespace: test\n  name: greet\n  version: 1.0.0\ndo:\n  - greet:\n      set:\n        greeting: hello\n```"
+	invalid := "```yml\ndocument:\n  dsl: 1.0.0\n  namespace: test\n  name: broken\n  version: 1.0.0\ndo: not-a-list\n```"
+	duplicate := "```yaml\ndocument:\n  dsl: 1.0.0\n  namespace: test\n  name: twice\n  version: 1.0.0\ndo:\n  - greet:\n      set:\n        greeting: hello\n  - greet:\n      set:\n        greeting: bye\n```"
+	input := "```yaml\ngreeting: hello\n```"
+
+	tests := []struct {
+		name       string
+		message    ChatMessage
+		format     string
+		candidates int
+		valid      bool
+		code       string
+		lint       string
+	}{
+		{
+			name:    "no workflow",
+			message: NewTextMessage(RoleAssistant, "What should the workflow do?\n\n"+input),
+			format:  FormatYAML,
+		},
+		{
+			name: "workflow in a later text block",
+			message: ChatMessage{Role: RoleAssistant, Content: []ContentBlock{
+				{Type: BlockThinking, Text: "The user wants a greeting."},
+				{Type: BlockText, Text: "Here is the input:\n\n" + input},
+				{Type: BlockToolUse, ID: "toolu_1", Name: ToolListDemoEndpoints},
+				{Type: BlockText, Text: "And the workflow:\n\n" + valid},
+			}},
+			format:     FormatYAML,
+			candidates: 1,
+			valid:      true,
+			code:       "document:",
+		},
+		{
+			name:       "one of several candidates is invalid",
+			message:    NewTextMessage(RoleAssistant, valid+"\n\nAlternatively:\n\n"+invalid),
+			format:     FormatYAML,
+			candidates: 2,
+		},
+		{
+			name:       "candidate with lint errors",
+			message:    NewTextMessage(RoleAssistant, valid+"\n\nOr:\n\n"+duplicate),
+			format:     FormatYAML,
+			candidates: 2,
+			lint:       LintDuplicateTaskName,
+		},
+		{
+			name:       "converted to the thread format",
+			message:    NewTextMessage(RoleAssistant, valid),
+			format:     FormatJSON,
+			candidates: 1,
+			valid:      true,
+			code:       "{\n  \"document\": {",
+		},
+		{
+			name:       "unfenced JSON",
+			message:    NewTextMessage(RoleAssistant, `Workflow: {"document": {"dsl": "1.0.0", "namespace": "test", "name": "greet", "version": "1.0.0"}, "do": [{"greet": {"set": {"greeting": "hello"}}}]}`),
+			format:     FormatJSON,
+			candidates: 1,
+			valid:      true,
+			code:       "{",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validateWorkflowInResponse(tt.message, tt.format, nil)
+			if len(result.Candidates) != tt.candidates || result.HasWorkflow != (tt.candidates > 0) {
+				t.Fatalf("Expected %d candidates, got %+v", tt.candidates, result.Candidates)
+			}
+			if result.IsValid != tt.valid {
+				t.Errorf("Expected valid=%v, got %+v", tt.valid, result)
+			}
+			if !strings.HasPrefix(result.WorkflowCode, tt.code) {
+				t.Errorf("Expected workflow code starting with %q, got %q", tt.code, result.WorkflowCode)
+			}
+			if tt.candidates > 0 && !tt.valid && !strings.Contains(result.ValidationError, "Code block 2") {
+				t.Errorf("Expected the error to name the invalid block, got %q", result.ValidationError)
+			}
+			if tt.lint != "" && !strings.Contains(result.ValidationError, tt.lint) {
+				t.Errorf("Expected the error to report %s, got %q", tt.lint, result.ValidationError)
+			}
+		})
+	}
+}
+
+func TestChatbotAutoFixesWorkflow(t *testing.T) {
+	reply := "```yaml\ndocument:\n  dsl: 1.0.0-alpha1\n  namespace: test\n  name: ping\n  version: 1.0.0\ndo:\n  - ping:\n      call: http\n      with:\n        method: get\n        uri: http://localhost:8088/demo/ping\n```"
+	provider := NewScriptedProvider(NewScriptedTextResponse(reply))
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ChatbotWorkflow)
+	env.RegisterActivity(NewChatbotActivitiesWithProvider(provider))
+
+	var response *SendMessageResponse
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(SendMessageUpdate, "message", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("Update rejected: %v", err) },
+			OnComplete: func(result interface{}, err error) { response, _ = result.(*SendMessageResponse) },
+		}, SendMessageRequest{Message: "Ping the demo API"})
+	}, 0)
+
+	env.ExecuteWorkflow(ChatbotWorkflow, "thread-6", ChatbotConfig{})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if len(provider.Requests()) != 1 {
+		t.Errorf("Expected the fixes to avoid a correction round, got %d requests", len(provider.Requests()))
+	}
+
+	var state ChatbotState
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(state.Workflows) != 1 || len(state.Workflows[0].Fixes) != 2 || !strings.Contains(state.Workflows[0].WorkflowCode, "endpoint:") {
+		t.Fatalf("Expected the fixed workflow to be recorded, got %+v", state.Workflows)
+	}
+	if len(state.Conversation) != 2 || state.Conversation[1].Role != RoleAssistant {
+		t.Errorf("Expected only the user message and the reply in the thread, got %+v", state.Conversation)
+	}
+	if response == nil || len(response.Fixes) != 2 || response.WorkflowCode != state.Workflows[0].WorkflowCode {
+		t.Errorf("Expected the fixes and the fixed workflow in the reply, got %+v", response)
+	}
+}
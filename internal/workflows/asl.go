@@ -0,0 +1,668 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+)
+
+// Defaults for document properties Amazon States Language definitions do not have
+const (
+	importedName      = "imported-state-machine"
+	aslRuntimeError   = "https://serverlessworkflow.io/spec/1.0.0/errors/runtime"
+	aslHTTPResource   = "arn:aws:states:::http:invoke"
+	aslLambdaResource = "arn:aws:states:::lambda:invoke"
+)
+
+var (
+	aslPathPattern     = regexp.MustCompile(`^\$((\.[A-Za-z_][A-Za-z0-9_]*)|(\[\d+\]))*$`)
+	aslServicePattern  = regexp.MustCompile(`^arn:aws:states:::([a-z0-9-]+):([A-Za-z0-9-]+)(\.[A-Za-z]+(?::\d+)?)?$`)
+	aslPropertyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// aslComparators maps the comparison operators of Choice rules to jq operators
+var aslComparators = map[string]string{
+	"Equals":            "==",
+	"LessThan":          "<",
+	"GreaterThan":       ">",
+	"LessThanEquals":    "<=",
+	"GreaterThanEquals": ">=",
+}
+
+// aslTypeTests maps the type tests of Choice rules to the jq type they check
+var aslTypeTests = map[string]string{
+	"IsString":  "string",
+	"IsNumeric": "number",
+	"IsBoolean": "boolean",
+}
+
+// ImportStateMachine translates an Amazon States Language state machine (JSON) into a DSL 1.0
+// workflow named name. States run in order from StartAt; Next transitions and Choice rules that do
+// not lead to the next state become `then` directives, which this engine does not follow. The
+// workflow runs on this engine only when no issues are reported: those directives, task types that
+// do not run inside Parallel branches and the constructs that are left out or approximated are all
+// reported, with paths into the state machine.
+func ImportStateMachine(source []byte, name string) (*model.Workflow, []MigrationIssue, error) {
+	var definition map[string]interface{}
+	if err := json.Unmarshal(source, &definition); err != nil {
+		return nil, nil, fmt.Errorf("failed to read state machine: %w", err)
+	}
+	if _, ok := definition["States"].(map[string]interface{}); !ok || stringValue(definition["StartAt"]) == "" {
+		return nil, nil, fmt.Errorf("not a state machine: expected `StartAt` and `States`")
+	}
+	if stringValue(definition["QueryLanguage"]) == "JSONata" {
+		return nil, nil, fmt.Errorf("JSONata state machines are not supported, only JSONPath")
+	}
+
+	name = strings.Trim(nameUnsafePattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		name = importedName
+	}
+	document := map[string]interface{}{
+		"dsl":       "1.0.0",
+		"namespace": migratedNamespace,
+		"name":      name,
+		"version":   migratedVersion,
+	}
+	if summary := stringValue(definition["Comment"]); summary != "" {
+		document["summary"] = summary
+	}
+
+	i := &aslImporter{issues: []MigrationIssue{}}
+	if _, ok := definition["TimeoutSeconds"]; ok {
+		i.report("/TimeoutSeconds", "state machine timeouts are not imported")
+	}
+	imported := map[string]interface{}{
+		"document": document,
+		"do":       i.importStates(definition, "", "end"),
+	}
+
+	workflowDef, err := buildWorkflow(imported)
+	if err != nil {
+		return nil, nil, fmt.Errorf("imported workflow is invalid: %w", err)
+	}
+	return workflowDef, i.issues, nil
+}
+
+// ImportStateMachineSource imports a state machine and writes the workflow in the given format
+func ImportStateMachineSource(source []byte, name, format string) (*MigrationResult, error) {
+	if format != FormatJSON && format != FormatYAML {
+		return nil, fmt.Errorf("unsupported format %q, expected json or yaml", format)
+	}
+	workflowDef, issues, err := ImportStateMachine(source, name)
+	if err != nil {
+		return nil, err
+	}
+	written, err := writeWorkflow(workflowDef, format)
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationResult{Workflow: written, Format: format, Issues: issues}, nil
+}
+
+type aslImporter struct {
+	issues   []MigrationIssue
+	input    string // jq path of the state input: the iteration item inside a Map, else the state itself
+	inBranch bool   // importing the states of a Parallel branch, which run in ExecuteBranchActivity
+}
+
+func (i *aslImporter) report(path, format string, args ...interface{}) {
+	i.issues = append(i.issues, MigrationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// aslTask is a task imported from a state, with the state its Next names
+type aslTask struct {
+	name     string
+	body     map[string]interface{}
+	next     string
+	path     string // path of the state
+	nextPath string // path of the state's Next or End
+}
+
+// importStates translates the states of a state machine, Parallel branch or Map processor to a
+// task list. terminal is the directive End translates to: end at the top level, exit in a branch.
+func (i *aslImporter) importStates(machine map[string]interface{}, path, terminal string) []interface{} {
+	states, _ := machine["States"].(map[string]interface{})
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var order []string
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		state, ok := states[name].(map[string]interface{})
+		if visited[name] || !ok {
+			return
+		}
+		visited[name] = true
+		order = append(order, name)
+		for _, next := range aslTransitions(state) {
+			visit(next)
+		}
+	}
+	visit(stringValue(machine["StartAt"]))
+	// States reached only through a Catch are error handlers; catchers are not imported, so they
+	// are left out rather than run in order on the success path
+	catchOnly := map[string]bool{}
+	for _, name := range names {
+		if state, ok := states[name].(map[string]interface{}); ok {
+			for _, handler := range aslCatchTargets(state) {
+				i.markCatchOnly(states, handler, visited, catchOnly)
+			}
+		}
+	}
+	for _, name := range names {
+		if catchOnly[name] {
+			visited[name] = true
+			i.report(path+"/States/"+escapePointer(name), "state is reached only through a Catch and was left out, as catchers are not imported")
+		}
+	}
+	// States not reachable from StartAt follow in name order
+	for _, name := range names {
+		visit(name)
+	}
+
+	var tasks []aslTask
+	// States left out of the task list lead on to where they would have transitioned
+	skipped := map[string]string{}
+	for _, name := range order {
+		state := states[name].(map[string]interface{})
+		statePath := path + "/States/" + escapePointer(name)
+		next, nextPath := stringValue(state["Next"]), statePath+"/Next"
+		if end, _ := state["End"].(bool); end {
+			next, nextPath = terminal, statePath+"/End"
+		}
+
+		imported := i.importState(name, state, statePath)
+		if i.inBranch {
+			i.checkBranchTasks(imported, statePath)
+		}
+		if len(imported) == 0 {
+			if stringValue(state["Type"]) == "Succeed" {
+				next = terminal
+			}
+			skipped[name] = next
+			continue
+		}
+		// Follow-up tasks of the state run before its transition
+		for j := range imported {
+			imported[j].path = statePath
+			if j < len(imported)-1 {
+				imported[j].next = ""
+			} else if stringValue(state["Type"]) != "Choice" {
+				imported[j].next, imported[j].nextPath = next, nextPath
+			}
+		}
+		tasks = append(tasks, imported...)
+	}
+
+	resolve := func(target string) string {
+		for hops := 0; hops <= len(skipped); hops++ {
+			next, ok := skipped[target]
+			if !ok {
+				break
+			}
+			target = next
+		}
+		if _, isState := states[target]; (!isState || catchOnly[target]) && target != terminal {
+			return terminal
+		}
+		return target
+	}
+
+	list := []interface{}{}
+	for j, task := range tasks {
+		isLast := j == len(tasks)-1
+		// Transitions to the following task, and the terminal one from the last, are implicit
+		sequential := func(then string) bool {
+			return (isLast && then == terminal) || (!isLast && then == tasks[j+1].name)
+		}
+		if cases, ok := task.body["switch"].([]interface{}); ok {
+			for _, item := range cases {
+				for caseName, switchCase := range item.(map[string]interface{}) {
+					switchCase := switchCase.(map[string]interface{})
+					switchCase["then"] = resolve(stringValue(switchCase["then"]))
+					if then := stringValue(switchCase["then"]); !sequential(then) {
+						i.report(choiceRulePath(task.path, caseName), "`then: %s` is not followed by this engine, which runs tasks in order", then)
+					}
+				}
+			}
+		}
+		if task.next != "" {
+			if then := resolve(task.next); !sequential(then) {
+				task.body["then"] = then
+				i.report(task.nextPath, "`then: %s` is not followed by this engine, which runs tasks in order", then)
+			}
+		}
+		list = append(list, map[string]interface{}{task.name: task.body})
+	}
+	return list
+}
+
+// choiceRulePath returns the path of the Choice rule or Default a switch case was imported from
+func choiceRulePath(statePath, caseName string) string {
+	if index, err := strconv.Atoi(strings.TrimPrefix(caseName, "choice")); err == nil {
+		return fmt.Sprintf("%s/Choices/%d/Next", statePath, index-1)
+	}
+	return statePath + "/Default"
+}
+
+// checkBranchTasks reports the tasks of a state in a Parallel branch that ExecuteBranchActivity
+// cannot run: inside a branch only HTTP calls and set tasks run
+func (i *aslImporter) checkBranchTasks(tasks []aslTask, path string) {
+	for _, task := range tasks {
+		_, isSet := task.body["set"]
+		if isSet || task.body["call"] == "http" {
+			continue
+		}
+		i.report(path, "the state imports as a task that does not run inside Parallel branches on this engine, which only runs HTTP tasks and Pass states there")
+		return
+	}
+}
+
+// importState translates a state to its task, followed by a set task when the state's result is
+// stored under a ResultPath. States that need no task return nothing.
+func (i *aslImporter) importState(name string, state map[string]interface{}, path string) []aslTask {
+	var body map[string]interface{}
+	stateType := stringValue(state["Type"])
+	switch stateType {
+	case "Task":
+		body = i.importTask(state, path)
+	case "Choice":
+		body = i.importChoice(state, path)
+	case "Parallel":
+		branches := []interface{}{}
+		inBranch := i.inBranch
+		i.inBranch = true
+		for j, raw := range toList(state["Branches"]) {
+			branch, _ := raw.(map[string]interface{})
+			branchPath := fmt.Sprintf("%s/Branches/%d", path, j)
+			branches = append(branches, map[string]interface{}{
+				fmt.Sprintf("branch%d", j+1): map[string]interface{}{"do": i.importStates(branch, branchPath, "exit")},
+			})
+		}
+		i.inBranch = inBranch
+		body = map[string]interface{}{"fork": map[string]interface{}{"branches": branches}}
+	case "Map":
+		body = i.importMap(state, path)
+	case "Pass":
+		body = i.importPass(name, state, path)
+	case "Wait":
+		i.report(path, "wait states are not executed by this engine and were left out")
+	case "Fail":
+		raised := map[string]interface{}{"type": aslRuntimeError, "status": 500}
+		if title := stringValue(state["Error"]); title != "" {
+			raised["title"] = title
+		}
+		if detail := stringValue(state["Cause"]); detail != "" {
+			raised["detail"] = detail
+		}
+		body = map[string]interface{}{"raise": map[string]interface{}{"error": raised}}
+		i.report(path, "fail state becomes a raise task, which this engine does not execute; the workflow still fails at this point")
+	case "Succeed":
+		// Succeed ends the workflow, which the directives leading to it do
+	default:
+		i.report(path, "%q states are not supported and were left out", stateType)
+	}
+	if body == nil {
+		return nil
+	}
+
+	for _, key := range []string{"InputPath", "OutputPath", "ResultSelector", "TimeoutSeconds", "TimeoutSecondsPath", "HeartbeatSeconds", "HeartbeatSecondsPath", "Credentials"} {
+		if _, ok := state[key]; ok {
+			i.report(path+"/"+key, "`%s` is not imported", key)
+		}
+	}
+	// DSL 1.0 expresses both as a try task (catch.retry and catch.do); the engine does not run retry
+	// policies, and the handler states a catcher leads to are not imported
+	if _, ok := state["Retry"]; ok {
+		i.report(path+"/Retry", "retriers are not imported: their DSL 1.0 form, the retry policy of a try task, does not run on this engine; activities use the engine's default retry policy")
+	}
+	if _, ok := state["Catch"]; ok {
+		i.report(path+"/Catch", "catchers are not imported, nor the states reached only through them; errors fail the workflow")
+	}
+
+	tasks := []aslTask{{name: name, body: body}}
+	if stateType == "Task" || stateType == "Parallel" || stateType == "Map" {
+		if store := i.storeResult(name, state, path); store != nil {
+			tasks = append(tasks, aslTask{name: name + "Result", body: store})
+		}
+	}
+	return tasks
+}
+
+// storeResult returns a set task copying a result, which the engine keeps under the task name, to
+// the state's ResultPath. Only paths to a top-level property are supported.
+func (i *aslImporter) storeResult(name string, state map[string]interface{}, path string) map[string]interface{} {
+	resultPath, ok := state["ResultPath"]
+	if !ok || resultPath == nil {
+		return nil
+	}
+	property := strings.TrimPrefix(stringValue(resultPath), "$.")
+	if !aslPropertyPattern.MatchString(property) {
+		i.report(path+"/ResultPath", "result path %q is not imported; the result is stored under the task name", resultPath)
+		return nil
+	}
+	return map[string]interface{}{"set": map[string]interface{}{property: fmt.Sprintf("${ .[%s] }", strconv.Quote(name))}}
+}
+
+// importTask translates a Task state: HTTP invocations become HTTP calls, and Lambda functions,
+// activities and other service integrations become calls to native functions
+func (i *aslImporter) importTask(state map[string]interface{}, path string) map[string]interface{} {
+	resource := stringValue(state["Resource"])
+	parameters, _ := i.importPayload(state["Parameters"], path+"/Parameters").(map[string]interface{})
+
+	if resource == aslHTTPResource {
+		with := map[string]interface{}{
+			"method":   strings.ToLower(stringValue(parameters["Method"])),
+			"endpoint": parameters["ApiEndpoint"],
+		}
+		if headers, ok := parameters["Headers"]; ok {
+			with["headers"] = headers
+		}
+		if query, ok := parameters["QueryParameters"]; ok {
+			with["query"] = query
+		}
+		if body, ok := parameters["RequestBody"]; ok {
+			with["body"] = body
+		}
+		if _, ok := parameters["Authentication"]; ok {
+			i.report(path+"/Parameters/Authentication", "HTTP task authentication is not imported")
+		}
+		return map[string]interface{}{"call": "http", "with": with}
+	}
+
+	var function string
+	var with interface{} = parameters
+	switch {
+	case resource == aslLambdaResource:
+		function = aslFunctionName(stringValue(parameters["FunctionName"]))
+		with = parameters["Payload"]
+	case strings.HasPrefix(resource, "arn:aws:lambda:"):
+		function = aslFunctionName(resource)
+	case strings.Contains(resource, ":activity:"):
+		function = resource[strings.LastIndex(resource, ":")+1:]
+	default:
+		match := aslServicePattern.FindStringSubmatch(resource)
+		if match == nil {
+			i.report(path+"/Resource", "resource %q is not supported and was left out", resource)
+			return nil
+		}
+		function = match[1] + ":" + match[2]
+		if match[3] != "" {
+			i.report(path+"/Resource", "the %s integration pattern is not imported; the call returns when the function does", match[3])
+		}
+	}
+	if function == "" {
+		i.report(path+"/Resource", "resource %q names no function and was left out", resource)
+		return nil
+	}
+	i.report(path+"/Resource", "calls native function %q, which must be registered with RegisterFunction", function)
+
+	task := map[string]interface{}{"call": function}
+	switch args := with.(type) {
+	case map[string]interface{}:
+		task["with"] = args
+	case nil:
+	default:
+		task["with"] = map[string]interface{}{"payload": args}
+	}
+	return task
+}
+
+// aslFunctionName returns the function name of a Lambda function ARN without its version or
+// alias, or the name itself
+func aslFunctionName(function string) string {
+	if _, name, found := strings.Cut(function, ":function:"); found {
+		name, _, _ = strings.Cut(name, ":")
+		return name
+	}
+	return function
+}
+
+// importChoice translates a Choice state to a switch, one case per rule and the Default last
+func (i *aslImporter) importChoice(state map[string]interface{}, path string) map[string]interface{} {
+	cases := []interface{}{}
+	for j, raw := range toList(state["Choices"]) {
+		rule, _ := raw.(map[string]interface{})
+		rulePath := fmt.Sprintf("%s/Choices/%d", path, j)
+		condition, ok := i.importCondition(rule, rulePath)
+		if !ok {
+			continue
+		}
+		cases = append(cases, map[string]interface{}{fmt.Sprintf("choice%d", j+1): map[string]interface{}{
+			"when": "${ " + condition + " }",
+			"then": stringValue(rule["Next"]),
+		}})
+	}
+	if next := stringValue(state["Default"]); next != "" {
+		cases = append(cases, map[string]interface{}{"default": map[string]interface{}{"then": next}})
+	}
+	return map[string]interface{}{"switch": cases}
+}
+
+// importCondition translates a Choice rule to a jq condition
+func (i *aslImporter) importCondition(rule map[string]interface{}, path string) (string, bool) {
+	for _, operator := range []string{"And", "Or"} {
+		if _, ok := rule[operator]; !ok {
+			continue
+		}
+		var conditions []string
+		for j, raw := range toList(rule[operator]) {
+			nested, _ := raw.(map[string]interface{})
+			condition, ok := i.importCondition(nested, fmt.Sprintf("%s/%s/%d", path, operator, j))
+			if !ok {
+				return "", false
+			}
+			conditions = append(conditions, condition)
+		}
+		return "(" + strings.Join(conditions, " "+strings.ToLower(operator)+" ") + ")", true
+	}
+	if nested, ok := rule["Not"].(map[string]interface{}); ok {
+		condition, ok := i.importCondition(nested, path+"/Not")
+		return "(" + condition + " | not)", ok
+	}
+
+	variable, ok := i.importPath(stringValue(rule["Variable"]), path+"/Variable")
+	if !ok {
+		return "", false
+	}
+	for key, value := range rule {
+		if key == "Variable" || key == "Next" {
+			continue
+		}
+		negate := ""
+		if value == false {
+			negate = " | not"
+		}
+		switch {
+		case key == "IsPresent" || key == "IsNull":
+			// Missing and null properties both read as null
+			operator := "=="
+			if (key == "IsPresent") == (value == true) {
+				operator = "!="
+			}
+			return fmt.Sprintf("(%s %s null)", variable, operator), true
+		case aslTypeTests[key] != "":
+			return fmt.Sprintf("((%s | type == %q)%s)", variable, aslTypeTests[key], negate), true
+		case key == "IsTimestamp":
+			return fmt.Sprintf("((%s | type == \"string\" and test(\"^\\\\d{4}-\\\\d{2}-\\\\d{2}T\"))%s)", variable, negate), true
+		case key == "StringMatches":
+			pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(stringValue(value)), `\*`, ".*") + "$"
+			return fmt.Sprintf("(%s | test(%s))", variable, strconv.Quote(pattern)), true
+		}
+
+		comparison := strings.TrimSuffix(key, "Path")
+		for _, kind := range []string{"String", "Numeric", "Boolean", "Timestamp"} {
+			operator, ok := aslComparators[strings.TrimPrefix(comparison, kind)]
+			if !ok || !strings.HasPrefix(comparison, kind) {
+				continue
+			}
+			operand := ""
+			if strings.HasSuffix(key, "Path") {
+				if operand, ok = i.importPath(stringValue(value), path+"/"+key); !ok {
+					return "", false
+				}
+			} else {
+				encoded, _ := json.Marshal(value)
+				operand = string(encoded)
+			}
+			// ISO 8601 timestamps in the same zone compare as strings
+			return fmt.Sprintf("%s %s %s", variable, operator, operand), true
+		}
+	}
+	i.report(path, "choice rule has no supported comparison and was left out")
+	return "", false
+}
+
+// importMap translates a Map state to a for task over its items
+func (i *aslImporter) importMap(state map[string]interface{}, path string) map[string]interface{} {
+	items := "."
+	if itemsPath := stringValue(state["ItemsPath"]); itemsPath != "" {
+		var ok bool
+		if items, ok = i.importPath(itemsPath, path+"/ItemsPath"); !ok {
+			return nil
+		}
+	}
+	processor, processorKey := state["ItemProcessor"].(map[string]interface{}), "ItemProcessor"
+	if processor == nil {
+		processor, processorKey = state["Iterator"].(map[string]interface{}), "Iterator"
+	}
+	if processor == nil {
+		i.report(path, "map state has no ItemProcessor and was left out")
+		return nil
+	}
+	if concurrency, ok := state["MaxConcurrency"]; ok && fmt.Sprint(concurrency) != "1" {
+		i.report(path+"/MaxConcurrency", "iterations run sequentially")
+	}
+	for _, key := range []string{"ItemSelector", "Parameters", "ItemReader", "ResultWriter", "ItemBatcher", "ToleratedFailurePercentage", "ToleratedFailureCount"} {
+		if _, ok := state[key]; ok {
+			i.report(path+"/"+key, "`%s` is not imported", key)
+		}
+	}
+	if config, ok := processor["ProcessorConfig"].(map[string]interface{}); ok && stringValue(config["Mode"]) == "DISTRIBUTED" {
+		i.report(path+"/"+processorKey+"/ProcessorConfig", "distributed maps run inline")
+	}
+
+	// The processor states read the item the loop binds and run in the loop, not in a branch
+	input, inBranch := i.input, i.inBranch
+	i.input, i.inBranch = "."+defaultIterationVar, false
+	do := i.importStates(processor, path+"/"+processorKey, "exit")
+	i.input, i.inBranch = input, inBranch
+	return map[string]interface{}{
+		"for": map[string]interface{}{"each": defaultIterationVar, "in": "${ " + items + " }"},
+		"do":  do,
+	}
+}
+
+// importPass translates a Pass state to a set task of its Result or Parameters
+func (i *aslImporter) importPass(name string, state map[string]interface{}, path string) map[string]interface{} {
+	var value interface{}
+	if result, ok := state["Result"]; ok {
+		value = result
+	} else if _, ok := state["Parameters"]; ok {
+		value = i.importPayload(state["Parameters"], path+"/Parameters")
+	} else {
+		// Passing the input through changes nothing
+		return nil
+	}
+
+	set, isObject := value.(map[string]interface{})
+	if resultPath, ok := state["ResultPath"]; ok && resultPath != nil && stringValue(resultPath) != "$" {
+		property := strings.TrimPrefix(stringValue(resultPath), "$.")
+		if !aslPropertyPattern.MatchString(property) {
+			i.report(path+"/ResultPath", "result path %q is not imported; the result is stored under the task name", resultPath)
+			property = name
+		}
+		set, isObject = map[string]interface{}{property: value}, true
+	}
+	if !isObject || len(set) == 0 {
+		set = map[string]interface{}{name: value}
+	}
+	return map[string]interface{}{"set": set}
+}
+
+// importPayload translates a Parameters template: keys ending in .$ take the value of a path,
+// which becomes a runtime expression
+func (i *aslImporter) importPayload(raw interface{}, path string) interface{} {
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		payload := map[string]interface{}{}
+		for key, field := range value {
+			fieldPath := path + "/" + escapePointer(key)
+			if name, dynamic := strings.CutSuffix(key, ".$"); dynamic {
+				if expression, ok := i.importPath(stringValue(field), fieldPath); ok {
+					payload[name] = "${ " + expression + " }"
+				}
+				continue
+			}
+			payload[key] = i.importPayload(field, fieldPath)
+		}
+		return payload
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for j, item := range value {
+			list[j] = i.importPayload(item, fmt.Sprintf("%s/%d", path, j))
+		}
+		return list
+	default:
+		return raw
+	}
+}
+
+// importPath translates a JSONPath reference such as $.order.items[0] to a jq path. Context
+// object references ($$) and intrinsic functions are not supported.
+func (i *aslImporter) importPath(path, location string) (string, bool) {
+	if !aslPathPattern.MatchString(path) {
+		i.report(location, "path %q is not supported; only simple paths such as $.a.b[0] are imported", path)
+		return "", false
+	}
+	if path == "$" && i.input == "" {
+		return ".", true
+	}
+	return i.input + strings.TrimPrefix(path, "$"), true
+}
+
+// aslTransitions lists the states a state can transition to, in order
+func aslTransitions(state map[string]interface{}) []string {
+	var targets []string
+	for _, raw := range toList(state["Choices"]) {
+		if rule, ok := raw.(map[string]interface{}); ok {
+			targets = append(targets, stringValue(rule["Next"]))
+		}
+	}
+	targets = append(targets, stringValue(state["Default"]), stringValue(state["Next"]))
+	return targets
+}
+
+// aslCatchTargets lists the states the catchers of a state lead to
+func aslCatchTargets(state map[string]interface{}) []string {
+	var targets []string
+	for _, raw := range toList(state["Catch"]) {
+		if catcher, ok := raw.(map[string]interface{}); ok {
+			targets = append(targets, stringValue(catcher["Next"]))
+		}
+	}
+	return targets
+}
+
+// markCatchOnly marks a catch handler state, and the states it transitions to, that are not
+// reachable from StartAt
+func (i *aslImporter) markCatchOnly(states map[string]interface{}, name string, reachable, catchOnly map[string]bool) {
+	state, ok := states[name].(map[string]interface{})
+	if !ok || reachable[name] || catchOnly[name] {
+		return
+	}
+	catchOnly[name] = true
+	for _, next := range aslTransitions(state) {
+		i.markCatchOnly(states, next, reachable, catchOnly)
+	}
+}
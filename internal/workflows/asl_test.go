@@ -0,0 +1,280 @@
+package workflows
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestImportStateMachineCorpus(t *testing.T) {
+	tests := []struct {
+		file      string
+		tasks     []string // name:type, with ->then when the task has a directive
+		fragments []string
+		issues    []string
+	}{
+		{
+			file:      "hello-world.asl.json",
+			tasks:     []string{"Hello:set", "World:set"},
+			fragments: []string{"greeting: Hello", "audience: World"},
+		},
+		{
+			file:  "order-choice.asl.json",
+			tasks: []string{"CheckOrder:switch", "Standard:set->end", "Express:set->end", "Rejected:raise"},
+			fragments: []string{
+				`when: ${ (.order.total > 1000 and (.order.region == "EU" | not)) }`,
+				"then: Standard",
+				`when: ${ (.order.express == true or (.order.priority != null)) }`,
+				`when: ${ (.order.sku | test("^GIFT-.*$")) }`,
+				"- default:",
+				"orderId: ${ .order.id }",
+				"title: OrderCancelled",
+			},
+			issues: []string{
+				"/States/CheckOrder/Choices/3/Variable",
+				"/States/CheckOrder/Choices/1/Next",
+				"/States/CheckOrder/Choices/2/Next",
+				"/States/CheckOrder/Choices/4/Next",
+				"/States/ManualReview",
+				"/States/Standard/Next",
+				"/States/Express/End",
+				"/States/Rejected",
+			},
+		},
+		{
+			file:  "http-task.asl.json",
+			tasks: []string{"GetCustomer:call:http", "GetCustomerResult:set", "UpdateCustomer:call:http"},
+			fragments: []string{
+				"endpoint: https://api.example.com/customers",
+				"id: ${ .customerId }",
+				`customer: ${ .["GetCustomer"] }`,
+				"endpoint: ${ .callbackUrl }",
+				"method: post",
+			},
+			issues: []string{
+				"/TimeoutSeconds",
+				"/States/GetCustomer/Parameters/Authentication",
+				"/States/GetCustomer/Retry",
+				"/States/GetCustomer/Catch",
+				"/States/LookupFailed",
+			},
+		},
+		{
+			file: "lambda-pipeline.asl.json",
+			tasks: []string{
+				"Resize:call:resize-image", "ResizeResult:set", "Classify:call:classify-image",
+				"Notify:call:sns:publish", "Archive:call:glue:startJobRun",
+			},
+			fragments: []string{"bucket: ${ .bucket }", "width: 640", "Message: ${ .resized }"},
+			issues: []string{
+				"/States/Resize/Resource",
+				"/States/Resize/ResultSelector",
+				"/States/Pause",
+				"/States/Classify/TimeoutSeconds",
+				"/States/Notify/Resource",
+				"/States/Archive/Resource",
+			},
+		},
+		{
+			file:  "parallel-map.asl.json",
+			tasks: []string{"Prepare:fork", "Summarize:set"},
+			fragments: []string{
+				"in: ${ .order.items }",
+				"payload: ${ .item.sku }",
+				`prices: ${ .["PriceItems"] }`,
+				"call: check-stock",
+				"items: ${ .order.items[0] }",
+			},
+			issues: []string{
+				"/States/Prepare/Branches/0/States/PriceItems",
+				"/States/Prepare/Branches/0/States/PriceItems/MaxConcurrency",
+				"/States/Prepare/Branches/0/States/PriceItems/ItemProcessor/States/Price/Resource",
+				"/States/Prepare/Branches/1/States/CheckStock",
+			},
+		},
+	}
+
+	files, err := filepath.Glob("../../test_data/asl/*.asl.json")
+	if err != nil || len(files) != len(tests) {
+		t.Fatalf("Expected a test for each of the %d samples", len(files))
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			source, err := os.ReadFile("../../test_data/asl/" + tt.file)
+			if err != nil {
+				t.Fatalf("Failed to read test data: %v", err)
+			}
+			result, err := ImportStateMachineSource(source, "", FormatYAML)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			workflowDef, err := parser.FromYAMLSource([]byte(result.Workflow))
+			if err != nil {
+				t.Fatalf("Imported workflow is invalid: %v\n%s", err, result.Workflow)
+			}
+			if workflowDef.Document.Name != importedName {
+				t.Errorf("Expected name %q, got %q", importedName, workflowDef.Document.Name)
+			}
+
+			var tasks []string
+			for _, task := range *workflowDef.Do {
+				description := task.Key + ":" + taskTypeName(task)
+				if base := task.GetBase(); base != nil && base.Then != nil {
+					description += "->" + base.Then.Value
+				}
+				tasks = append(tasks, description)
+			}
+			if strings.Join(tasks, " ") != strings.Join(tt.tasks, " ") {
+				t.Errorf("Expected tasks %v, got %v:\n%s", tt.tasks, tasks, result.Workflow)
+			}
+			for _, fragment := range tt.fragments {
+				if !strings.Contains(result.Workflow, fragment) {
+					t.Errorf("Expected the workflow to contain %q:\n%s", fragment, result.Workflow)
+				}
+			}
+
+			issues := map[string]bool{}
+			for _, issue := range result.Issues {
+				issues[issue.Path] = true
+			}
+			for _, path := range tt.issues {
+				if !issues[path] {
+					t.Errorf("Expected an issue at %s, got %+v", path, result.Issues)
+				}
+			}
+			if len(tt.issues) == 0 && len(result.Issues) > 0 {
+				t.Errorf("Expected no issues, got %+v", result.Issues)
+			}
+		})
+	}
+}
+
+func TestExecuteImportedStateMachine(t *testing.T) {
+	source := `{
+  "StartAt": "Prepare",
+  "States": {
+    "Prepare": {"Type": "Pass", "Result": {"order": {"total": 1500, "region": "US"}}, "Next": "Route"},
+    "Route": {
+      "Type": "Choice",
+      "Choices": [{"Variable": "$.order.total", "NumericGreaterThan": 1000, "Next": "Label"}],
+      "Default": "Label"
+    },
+    "Label": {
+      "Type": "Parallel",
+      "Branches": [
+        {"StartAt": "Priority", "States": {"Priority": {"Type": "Pass", "Result": {"priority": "high"}, "End": true}}},
+        {"StartAt": "Region", "States": {"Region": {"Type": "Pass", "Parameters": {"region.$": "$.order.region"}, "End": true}}}
+      ],
+      "Next": "Done"
+    },
+    "Done": {"Type": "Pass", "Result": {"status": "labelled"}, "End": true}
+  }
+}`
+	result, err := ImportStateMachineSource([]byte(source), "labels", FormatYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Issues) != 0 {
+		t.Fatalf("Expected no issues, got %+v\n%s", result.Issues, result.Workflow)
+	}
+	if issues := LintWorkflowSource([]byte(result.Workflow), LintOptions{}); HasLintErrors(issues) {
+		t.Fatalf("Expected the imported workflow to run on this engine, got %+v\n%s", issues, result.Workflow)
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(EvaluateExpressionActivity)
+	env.RegisterActivity(ExecuteBranchActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, result.Workflow, nil)
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Expected workflow to complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v\n%s", err, result.Workflow)
+	}
+	var state map[string]interface{}
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if state["status"] != "labelled" || state["Label"] == nil {
+		t.Errorf("Expected every state to run, got %v\n%s", state, result.Workflow)
+	}
+}
+
+func TestImportStateMachineCatchHandlers(t *testing.T) {
+	source := `{
+  "StartAt": "Charge",
+  "States": {
+    "Charge": {
+      "Type": "Pass",
+      "Result": {"charged": true},
+      "Catch": [{"ErrorEquals": ["States.ALL"], "Next": "Refund"}],
+      "Next": "Ship"
+    },
+    "Ship": {"Type": "Pass", "Result": {"shipped": true}, "End": true},
+    "Refund": {"Type": "Pass", "Result": {"refunded": true}, "Next": "RefundFailed"},
+    "RefundFailed": {"Type": "Fail", "Error": "RefundFailed"}
+  }
+}`
+	result, err := ImportStateMachineSource([]byte(source), "orders", FormatYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, state := range []string{"Refund", "RefundFailed"} {
+		if strings.Contains(result.Workflow, state+":") {
+			t.Errorf("Expected the catch handler %s to be left out:\n%s", state, result.Workflow)
+		}
+	}
+	issues := map[string]bool{}
+	for _, issue := range result.Issues {
+		issues[issue.Path] = true
+	}
+	for _, path := range []string{"/States/Charge/Catch", "/States/Refund", "/States/RefundFailed"} {
+		if !issues[path] {
+			t.Errorf("Expected an issue at %s, got %+v", path, result.Issues)
+		}
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(EvaluateExpressionActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, result.Workflow, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v\n%s", err, result.Workflow)
+	}
+	var state map[string]interface{}
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if state["shipped"] != true || state["refunded"] != nil {
+		t.Errorf("Expected only the success path to run, got %v\n%s", state, result.Workflow)
+	}
+}
+
+func TestImportStateMachineErrors(t *testing.T) {
+	for name, source := range map[string]string{
+		"not json":      "StartAt: Hello",
+		"no states":     `{"StartAt": "Hello"}`,
+		"jsonata":       `{"QueryLanguage": "JSONata", "StartAt": "Hello", "States": {"Hello": {"Type": "Succeed"}}}`,
+		"legacy format": `{"id": "x", "states": []}`,
+	} {
+		if _, _, err := ImportStateMachine([]byte(source), ""); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := ImportStateMachineSource([]byte(`{"StartAt": "A", "States": {"A": {"Type": "Succeed"}}}`), "", "xml"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
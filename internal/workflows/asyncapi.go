@@ -0,0 +1,576 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/workflow"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxAsyncAPISubscription bounds subscriptions whose consumption policy has no consume.for window
+	maxAsyncAPISubscription = 10 * time.Minute
+	// asyncAPIDocumentTimeout is added to the subscription window for loading the AsyncAPI document
+	asyncAPIDocumentTimeout = 30 * time.Second
+	// asyncAPIHeartbeatInterval is how often a subscription heartbeats while waiting for messages
+	asyncAPIHeartbeatInterval = 5 * time.Second
+	asyncAPIHeartbeatTimeout  = 20 * time.Second
+)
+
+// BrokerMessage represents a message published to or consumed from a message broker
+type BrokerMessage struct {
+	Channel string                 `json:"channel"`
+	Payload interface{}            `json:"payload"`
+	Headers map[string]interface{} `json:"headers,omitempty"`
+}
+
+// BrokerSubscription delivers messages consumed from a single channel
+type BrokerSubscription interface {
+	Messages() <-chan BrokerMessage
+	Close() error
+}
+
+// MessageBroker is the adapter between `call: asyncapi` tasks and a messaging system.
+// Adapters are registered per AsyncAPI protocol (nats, kafka, amqp, ...).
+type MessageBroker interface {
+	Publish(ctx context.Context, channel string, message BrokerMessage) error
+	Subscribe(ctx context.Context, channel string) (BrokerSubscription, error)
+}
+
+// InMemoryBroker is a process-local MessageBroker used for local development and tests
+type InMemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[string][]*inMemorySubscription
+}
+
+// NewInMemoryBroker creates an empty in-memory broker
+func NewInMemoryBroker() *InMemoryBroker {
+	return &InMemoryBroker{subscribers: make(map[string][]*inMemorySubscription)}
+}
+
+// Publish delivers the message to every subscription currently open on the channel
+func (b *InMemoryBroker) Publish(ctx context.Context, channel string, message BrokerMessage) error {
+	b.mu.Lock()
+	subscribers := append([]*inMemorySubscription(nil), b.subscribers[channel]...)
+	b.mu.Unlock()
+
+	message.Channel = channel
+	for _, sub := range subscribers {
+		select {
+		case sub.messages <- message:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe opens a subscription that receives messages published after this call
+func (b *InMemoryBroker) Subscribe(ctx context.Context, channel string) (BrokerSubscription, error) {
+	sub := &inMemorySubscription{
+		broker:   b,
+		channel:  channel,
+		messages: make(chan BrokerMessage, 64),
+		done:     make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subscribers[channel] = append(b.subscribers[channel], sub)
+	b.mu.Unlock()
+
+	return sub, nil
+}
+
+type inMemorySubscription struct {
+	broker   *InMemoryBroker
+	channel  string
+	messages chan BrokerMessage
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (s *inMemorySubscription) Messages() <-chan BrokerMessage {
+	return s.messages
+}
+
+func (s *inMemorySubscription) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+
+		s.broker.mu.Lock()
+		defer s.broker.mu.Unlock()
+		subscribers := s.broker.subscribers[s.channel]
+		for i, sub := range subscribers {
+			if sub == s {
+				s.broker.subscribers[s.channel] = append(subscribers[:i], subscribers[i+1:]...)
+				break
+			}
+		}
+	})
+	return nil
+}
+
+// AsyncAPICallRequest represents an AsyncAPI call request
+type AsyncAPICallRequest struct {
+	Task      string                  `json:"task"`
+	Arguments model.AsyncAPIArguments `json:"arguments"`
+}
+
+// AsyncAPICallResult represents the outcome of an AsyncAPI publish or subscribe operation
+type AsyncAPICallResult struct {
+	Operation string          `json:"operation"`
+	Action    string          `json:"action"` // "send" or "receive"
+	Channel   string          `json:"channel"`
+	Protocol  string          `json:"protocol"`
+	Messages  []BrokerMessage `json:"messages"`
+}
+
+// AsyncAPIActivities executes `call: asyncapi` tasks against registered broker adapters
+type AsyncAPIActivities struct {
+	brokers     map[string]MessageBroker
+	documentDir string
+}
+
+// NewAsyncAPIActivities creates the activities with the given broker adapters, keyed by
+// AsyncAPI protocol. The adapter registered under "" is used for protocols without one.
+// file:// documents are read only from inside documentDir; when it is empty only http(s)
+// documents can be loaded.
+func NewAsyncAPIActivities(brokers map[string]MessageBroker, documentDir string) *AsyncAPIActivities {
+	if brokers == nil {
+		brokers = make(map[string]MessageBroker)
+	}
+	return &AsyncAPIActivities{brokers: brokers, documentDir: documentDir}
+}
+
+// executeAsyncAPITask handles AsyncAPI publish/subscribe calls. The outbound message is evaluated
+// against the task input; subscriptions run under a heartbeat and a bounded start-to-close timeout.
+func executeAsyncAPITask(ctx workflow.Context, taskName string, asyncAPITask *model.CallAsyncAPI, state map[string]interface{}) (interface{}, error) {
+	logger := workflow.GetLogger(ctx)
+
+	args := asyncAPITask.With
+	if args.Message != nil {
+		message, err := evaluateAsyncAPIMessage(ctx, args.Message, state)
+		if err != nil {
+			return nil, err
+		}
+		args.Message = message
+	}
+	if args.Subscription != nil && args.Subscription.Consume != nil {
+		window, err := subscriptionWindow(args.Subscription.Consume)
+		if err != nil {
+			return nil, err
+		}
+		options := workflow.GetActivityOptions(ctx)
+		options.StartToCloseTimeout = window + asyncAPIDocumentTimeout
+		options.HeartbeatTimeout = asyncAPIHeartbeatTimeout
+		ctx = workflow.WithActivityOptions(ctx, options)
+	}
+
+	var activities *AsyncAPIActivities
+	var result AsyncAPICallResult
+	err := workflow.ExecuteActivity(ctx, activities.CallAsyncAPI, AsyncAPICallRequest{
+		Task:      taskName,
+		Arguments: args,
+	}).Get(ctx, &result)
+	if err != nil {
+		return nil, fmt.Errorf("AsyncAPI call failed: %w", err)
+	}
+
+	logger.Info("AsyncAPI call completed", "operation", result.Operation, "channel", result.Channel, "messages", len(result.Messages))
+	return result, nil
+}
+
+// evaluateAsyncAPIMessage evaluates the expressions in the payload and headers of an outbound message
+func evaluateAsyncAPIMessage(ctx workflow.Context, message *model.AsyncAPIOutboundMessage, state map[string]interface{}) (*model.AsyncAPIOutboundMessage, error) {
+	var evaluated struct {
+		Payload map[string]interface{} `json:"payload"`
+		Headers map[string]interface{} `json:"headers"`
+	}
+	err := workflow.ExecuteActivity(ctx, EvaluateValueActivity, EvaluateValueRequest{
+		Value: map[string]interface{}{
+			"payload": message.Payload,
+			"headers": message.Headers,
+		},
+		Context:   state,
+		Arguments: expressionArguments(ctx, state, message),
+	}).Get(ctx, &evaluated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate asyncapi message: %w", err)
+	}
+	return &model.AsyncAPIOutboundMessage{Payload: evaluated.Payload, Headers: evaluated.Headers}, nil
+}
+
+// subscriptionWindow is how long a subscription may consume messages: its consume.for window, or
+// maxAsyncAPISubscription for policies bounded only by amount, while or until
+func subscriptionWindow(consume *model.AsyncAPIMessageConsumptionPolicy) (time.Duration, error) {
+	if consume.For == nil {
+		return maxAsyncAPISubscription, nil
+	}
+	window, err := toDuration(consume.For)
+	if err != nil {
+		return 0, fmt.Errorf("invalid consumption window: %w", err)
+	}
+	return window, nil
+}
+
+// CallAsyncAPI loads the AsyncAPI document, resolves the operation and publishes or subscribes through a broker
+func (a *AsyncAPIActivities) CallAsyncAPI(ctx context.Context, req AsyncAPICallRequest) (AsyncAPICallResult, error) {
+	logger := activity.GetLogger(ctx)
+	args := req.Arguments
+
+	if args.Document == nil || args.Document.Endpoint == nil {
+		return AsyncAPICallResult{}, fmt.Errorf("asyncapi document is required")
+	}
+
+	document, err := loadAsyncAPIDocument(ctx, args.Document.Endpoint.String(), a.documentDir)
+	if err != nil {
+		return AsyncAPICallResult{}, err
+	}
+
+	operation, err := resolveAsyncAPIOperation(document, args)
+	if err != nil {
+		return AsyncAPICallResult{}, err
+	}
+
+	broker, ok := a.brokers[operation.Protocol]
+	if !ok {
+		broker, ok = a.brokers[""]
+	}
+	if !ok {
+		return AsyncAPICallResult{}, fmt.Errorf("no message broker registered for protocol '%s'", operation.Protocol)
+	}
+
+	logger.Info("CallAsyncAPI started", "operation", operation.Name, "action", operation.Action, "channel", operation.Channel, "protocol", operation.Protocol)
+
+	result := AsyncAPICallResult{
+		Operation: operation.Name,
+		Action:    operation.Action,
+		Channel:   operation.Channel,
+		Protocol:  operation.Protocol,
+		Messages:  []BrokerMessage{},
+	}
+
+	if operation.Action == "send" {
+		message := BrokerMessage{Channel: operation.Channel}
+		if args.Message != nil {
+			message.Payload = args.Message.Payload
+			message.Headers = args.Message.Headers
+		}
+		if err := broker.Publish(ctx, operation.Channel, message); err != nil {
+			return AsyncAPICallResult{}, fmt.Errorf("failed to publish to channel '%s': %w", operation.Channel, err)
+		}
+		result.Messages = append(result.Messages, message)
+		return result, nil
+	}
+
+	messages, err := consumeAsyncAPIMessages(ctx, broker, operation.Channel, args.Subscription)
+	if err != nil {
+		return AsyncAPICallResult{}, err
+	}
+	result.Messages = messages
+	return result, nil
+}
+
+// asyncAPIOperation is an operation resolved from an AsyncAPI document
+type asyncAPIOperation struct {
+	Name     string
+	Action   string
+	Channel  string
+	Protocol string
+}
+
+// loadAsyncAPIDocument fetches an AsyncAPI document (YAML or JSON) from an http(s) endpoint or
+// a file:// endpoint inside documentDir
+func loadAsyncAPIDocument(ctx context.Context, endpoint string, documentDir string) (map[string]interface{}, error) {
+	var content []byte
+
+	switch {
+	case strings.HasPrefix(endpoint, "http://"), strings.HasPrefix(endpoint, "https://"):
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create asyncapi document request: %w", err)
+		}
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch asyncapi document: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("failed to fetch asyncapi document: status %d", resp.StatusCode)
+		}
+		content, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read asyncapi document '%s': %w", endpoint, err)
+		}
+	case strings.HasPrefix(endpoint, "file://"):
+		path, err := asyncAPIDocumentPath(strings.TrimPrefix(endpoint, "file://"), documentDir)
+		if err != nil {
+			return nil, err
+		}
+		content, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read asyncapi document: file not found in the document directory")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported asyncapi document endpoint: use http(s) or file://")
+	}
+
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse asyncapi document '%s': %w", endpoint, err)
+	}
+	return document, nil
+}
+
+// asyncAPIDocumentPath resolves a file:// path against documentDir and rejects any path,
+// including one reached through a symlink, that lies outside it
+func asyncAPIDocumentPath(name string, documentDir string) (string, error) {
+	if documentDir == "" {
+		return "", fmt.Errorf("file:// asyncapi documents are disabled; use an http(s) endpoint")
+	}
+	base, err := filepath.Abs(documentDir)
+	if err != nil {
+		return "", fmt.Errorf("asyncapi document directory is unavailable")
+	}
+	realBase, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return "", fmt.Errorf("asyncapi document directory is unavailable")
+	}
+
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	path = filepath.Clean(path)
+	if !withinDir(base, path) && !withinDir(realBase, path) {
+		return "", fmt.Errorf("asyncapi document must be inside the document directory")
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read asyncapi document: file not found in the document directory")
+	}
+	if !withinDir(realBase, resolved) {
+		return "", fmt.Errorf("asyncapi document must be inside the document directory")
+	}
+	return resolved, nil
+}
+
+// withinDir reports whether path is dir or lies beneath it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveAsyncAPIOperation picks the operation, channel and protocol for the call. Both
+// AsyncAPI 3.x (top-level operations with send/receive actions) and 2.x (publish/subscribe
+// operations nested in channels) documents are supported.
+func resolveAsyncAPIOperation(document map[string]interface{}, args model.AsyncAPIArguments) (asyncAPIOperation, error) {
+	operation := asyncAPIOperation{Name: args.Operation, Channel: args.Channel}
+	channels, _ := document["channels"].(map[string]interface{})
+
+	if args.Operation != "" {
+		found := false
+		if operations, ok := document["operations"].(map[string]interface{}); ok {
+			if op, ok := operations[args.Operation].(map[string]interface{}); ok {
+				found = true
+				operation.Action, _ = op["action"].(string)
+				if ref, ok := op["channel"].(map[string]interface{}); ok {
+					refValue, _ := ref["$ref"].(string)
+					operation.Channel = channelAddress(channels, strings.TrimPrefix(refValue, "#/channels/"))
+				}
+			}
+		}
+		if !found {
+			for name, raw := range channels {
+				channel, _ := raw.(map[string]interface{})
+				for action, key := range map[string]string{"send": "publish", "receive": "subscribe"} {
+					if op, ok := channel[key].(map[string]interface{}); ok && op["operationId"] == args.Operation {
+						found = true
+						operation.Action = action
+						operation.Channel = name
+					}
+				}
+			}
+		}
+		if !found {
+			return asyncAPIOperation{}, fmt.Errorf("operation '%s' not found in asyncapi document", args.Operation)
+		}
+	} else if args.Channel != "" {
+		operation.Channel = channelAddress(channels, args.Channel)
+	}
+
+	if operation.Action == "" {
+		if args.Subscription != nil {
+			operation.Action = "receive"
+		} else {
+			operation.Action = "send"
+		}
+	}
+	if operation.Action != "send" && operation.Action != "receive" {
+		return asyncAPIOperation{}, fmt.Errorf("unsupported asyncapi operation action '%s'", operation.Action)
+	}
+	if operation.Channel == "" {
+		return asyncAPIOperation{}, fmt.Errorf("asyncapi call requires a channel or an operation bound to a channel")
+	}
+	if operation.Action == "receive" && args.Subscription == nil {
+		return asyncAPIOperation{}, fmt.Errorf("asyncapi operation '%s' receives messages but no subscription was defined", operation.Name)
+	}
+
+	operation.Protocol = args.Protocol
+	if operation.Protocol == "" {
+		servers, _ := document["servers"].(map[string]interface{})
+		if args.Server != nil {
+			if server, ok := servers[args.Server.Name].(map[string]interface{}); ok {
+				operation.Protocol, _ = server["protocol"].(string)
+			}
+		} else {
+			for _, raw := range servers {
+				if server, ok := raw.(map[string]interface{}); ok {
+					operation.Protocol, _ = server["protocol"].(string)
+					break
+				}
+			}
+		}
+	}
+
+	return operation, nil
+}
+
+// channelAddress returns the address of an AsyncAPI 3.x channel, falling back to the channel key
+func channelAddress(channels map[string]interface{}, name string) string {
+	if channel, ok := channels[name].(map[string]interface{}); ok {
+		if address, ok := channel["address"].(string); ok && address != "" {
+			return address
+		}
+	}
+	return name
+}
+
+// consumeAsyncAPIMessages reads messages from the channel until the consumption policy is satisfied,
+// heartbeating while it waits. Without a consume.for window the subscription fails once
+// maxAsyncAPISubscription has passed.
+func consumeAsyncAPIMessages(ctx context.Context, broker MessageBroker, channel string, subscription *model.AsyncAPISubscription) ([]BrokerMessage, error) {
+	consume := subscription.Consume
+	if consume == nil {
+		return nil, fmt.Errorf("asyncapi subscription requires a consumption policy")
+	}
+
+	window, err := subscriptionWindow(consume)
+	if err != nil {
+		return nil, err
+	}
+	consumeCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	sub, err := broker.Subscribe(consumeCtx, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to channel '%s': %w", channel, err)
+	}
+	defer sub.Close()
+
+	heartbeat := time.NewTicker(asyncAPIHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	messages := []BrokerMessage{}
+	for {
+		select {
+		case <-consumeCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if consume.For != nil {
+				return messages, nil
+			}
+			return nil, fmt.Errorf("consumption policy of channel '%s' not satisfied within %s", channel, window)
+		case <-heartbeat.C:
+			activity.RecordHeartbeat(ctx, len(messages))
+		case message := <-sub.Messages():
+			messageContext := map[string]interface{}{
+				"payload": message.Payload,
+				"headers": message.Headers,
+			}
+
+			if subscription.Filter != nil {
+				matched, err := evaluateExpression(subscription.Filter.Value, messageContext, nil)
+				if err != nil {
+					return nil, wrapExpressionError(err, "failed to evaluate subscription filter")
+				}
+				if !isTruthy(matched) {
+					continue
+				}
+			}
+
+			if consume.While != nil {
+				keepGoing, err := evaluateExpression(consume.While.Value, messageContext, nil)
+				if err != nil {
+					return nil, wrapExpressionError(err, "failed to evaluate consume.while")
+				}
+				if !isTruthy(keepGoing) {
+					return messages, nil
+				}
+			}
+
+			messages = append(messages, message)
+
+			if consume.Until != nil {
+				done, err := evaluateExpression(consume.Until.Value, messageContext, nil)
+				if err != nil {
+					return nil, wrapExpressionError(err, "failed to evaluate consume.until")
+				}
+				if isTruthy(done) {
+					return messages, nil
+				}
+			}
+			if consume.Amount > 0 && len(messages) >= consume.Amount {
+				return messages, nil
+			}
+		}
+	}
+}
+
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// toDuration converts an inline or ISO 8601 workflow duration to a time.Duration
+func toDuration(d *model.Duration) (time.Duration, error) {
+	if inline := d.AsInline(); inline != nil {
+		return time.Duration(inline.Days)*24*time.Hour +
+			time.Duration(inline.Hours)*time.Hour +
+			time.Duration(inline.Minutes)*time.Minute +
+			time.Duration(inline.Seconds)*time.Second +
+			time.Duration(inline.Milliseconds)*time.Millisecond, nil
+	}
+
+	expression := d.AsExpression()
+	matches := isoDurationPattern.FindStringSubmatch(expression)
+	if matches == nil || expression == "P" || expression == "PT" {
+		return 0, fmt.Errorf("unsupported duration '%s'", expression)
+	}
+
+	var total time.Duration
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(matches[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("unsupported duration '%s': %w", expression, err)
+		}
+		total += time.Duration(value * float64(unit))
+	}
+	return total, nil
+}
@@ -0,0 +1,325 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"go.temporal.io/sdk/testsuite"
+)
+
+const testAsyncAPIDocument = `
+asyncapi: 3.0.0
+info:
+  title: Orders
+  version: 1.0.0
+servers:
+  local:
+    host: localhost:4222
+    protocol: nats
+channels:
+  orderPlaced:
+    address: orders.placed
+operations:
+  publishOrder:
+    action: send
+    channel:
+      $ref: '#/channels/orderPlaced'
+  consumeOrders:
+    action: receive
+    channel:
+      $ref: '#/channels/orderPlaced'
+`
+
+func writeTestAsyncAPIDocument(t *testing.T, dir string) *model.ExternalResource {
+	t.Helper()
+	path := filepath.Join(dir, "orders.asyncapi.yaml")
+	if err := os.WriteFile(path, []byte(testAsyncAPIDocument), 0o644); err != nil {
+		t.Fatalf("Failed to write asyncapi document: %v", err)
+	}
+	return &model.ExternalResource{Endpoint: model.NewEndpoint("file://" + path)}
+}
+
+func TestCallAsyncAPIPublish(t *testing.T) {
+	broker := NewInMemoryBroker()
+	dir := t.TempDir()
+	activities := NewAsyncAPIActivities(map[string]MessageBroker{"nats": broker}, dir)
+
+	sub, err := broker.Subscribe(context.Background(), "orders.placed")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer sub.Close()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.CallAsyncAPI)
+
+	value, err := env.ExecuteActivity(activities.CallAsyncAPI, AsyncAPICallRequest{
+		Task: "publishOrder",
+		Arguments: model.AsyncAPIArguments{
+			Document:  writeTestAsyncAPIDocument(t, dir),
+			Operation: "publishOrder",
+			Message: &model.AsyncAPIOutboundMessage{
+				Payload: map[string]interface{}{"orderId": "123"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CallAsyncAPI failed: %v", err)
+	}
+
+	var result AsyncAPICallResult
+	if err := value.Get(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Action != "send" || result.Channel != "orders.placed" || result.Protocol != "nats" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	select {
+	case message := <-sub.Messages():
+		payload, _ := message.Payload.(map[string]interface{})
+		if payload["orderId"] != "123" {
+			t.Errorf("Unexpected payload: %v", message.Payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a message on orders.placed")
+	}
+}
+
+func TestCallAsyncAPIWithoutBroker(t *testing.T) {
+	dir := t.TempDir()
+	activities := NewAsyncAPIActivities(nil, dir)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.CallAsyncAPI)
+
+	_, err := env.ExecuteActivity(activities.CallAsyncAPI, AsyncAPICallRequest{
+		Task: "publishOrder",
+		Arguments: model.AsyncAPIArguments{
+			Document:  writeTestAsyncAPIDocument(t, dir),
+			Operation: "publishOrder",
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no message broker registered for protocol 'nats'") {
+		t.Fatalf("Expected a missing broker error, got %v", err)
+	}
+}
+
+func TestLoadAsyncAPIDocumentRestrictsFiles(t *testing.T) {
+	dir := t.TempDir()
+	inside := writeTestAsyncAPIDocument(t, dir).Endpoint.String()
+
+	outsideDir := t.TempDir()
+	outside := writeTestAsyncAPIDocument(t, outsideDir).Endpoint.String()
+	if err := os.Symlink(filepath.Join(outsideDir, "orders.asyncapi.yaml"), filepath.Join(dir, "link.yaml")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		documentDir string
+		wantErr     string
+	}{
+		{name: "inside the directory", endpoint: inside, documentDir: dir},
+		{name: "relative to the directory", endpoint: "file://orders.asyncapi.yaml", documentDir: dir},
+		{name: "outside the directory", endpoint: outside, documentDir: dir, wantErr: "must be inside the document directory"},
+		{name: "parent traversal", endpoint: "file://../" + filepath.Base(outsideDir) + "/orders.asyncapi.yaml", documentDir: dir, wantErr: "must be inside the document directory"},
+		{name: "symlink out of the directory", endpoint: "file://link.yaml", documentDir: dir, wantErr: "must be inside the document directory"},
+		{name: "file documents disabled", endpoint: inside, wantErr: "file:// asyncapi documents are disabled"},
+		{name: "bare path", endpoint: "/etc/passwd", documentDir: dir, wantErr: "unsupported asyncapi document endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := loadAsyncAPIDocument(context.Background(), tt.endpoint, tt.documentDir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if document["asyncapi"] != "3.0.0" {
+					t.Errorf("Unexpected document: %v", document)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// subscribeNotifyingBroker signals once a subscription has been opened on the wrapped broker
+type subscribeNotifyingBroker struct {
+	MessageBroker
+	subscribed chan struct{}
+}
+
+func (b *subscribeNotifyingBroker) Subscribe(ctx context.Context, channel string) (BrokerSubscription, error) {
+	sub, err := b.MessageBroker.Subscribe(ctx, channel)
+	close(b.subscribed)
+	return sub, err
+}
+
+func TestCallAsyncAPISubscribe(t *testing.T) {
+	broker := &subscribeNotifyingBroker{MessageBroker: NewInMemoryBroker(), subscribed: make(chan struct{})}
+	dir := t.TempDir()
+	activities := NewAsyncAPIActivities(map[string]MessageBroker{"": broker}, dir)
+
+	go func() {
+		<-broker.subscribed
+		for i := 0; i < 3; i++ {
+			broker.Publish(context.Background(), "orders.placed", BrokerMessage{Payload: map[string]interface{}{"n": i}})
+		}
+	}()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.CallAsyncAPI)
+
+	value, err := env.ExecuteActivity(activities.CallAsyncAPI, AsyncAPICallRequest{
+		Task: "consumeOrders",
+		Arguments: model.AsyncAPIArguments{
+			Document:  writeTestAsyncAPIDocument(t, dir),
+			Operation: "consumeOrders",
+			Subscription: &model.AsyncAPISubscription{
+				Filter:  model.NewExpr("${ .payload.n > 0 }"),
+				Consume: &model.AsyncAPIMessageConsumptionPolicy{Amount: 2},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CallAsyncAPI failed: %v", err)
+	}
+
+	var result AsyncAPICallResult
+	if err := value.Get(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Action != "receive" || len(result.Messages) != 2 {
+		t.Fatalf("Expected 2 received messages, got %+v", result)
+	}
+	if payload, _ := result.Messages[0].Payload.(map[string]interface{}); payload["n"] != float64(1) {
+		t.Errorf("Expected the filter to skip message 0, got %v", result.Messages[0].Payload)
+	}
+}
+
+func TestCallAsyncAPISubscribeFilterError(t *testing.T) {
+	broker := &subscribeNotifyingBroker{MessageBroker: NewInMemoryBroker(), subscribed: make(chan struct{})}
+	dir := t.TempDir()
+	activities := NewAsyncAPIActivities(map[string]MessageBroker{"": broker}, dir)
+
+	go func() {
+		<-broker.subscribed
+		broker.Publish(context.Background(), "orders.placed", BrokerMessage{Payload: "not an object"})
+	}()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.CallAsyncAPI)
+
+	_, err := env.ExecuteActivity(activities.CallAsyncAPI, AsyncAPICallRequest{
+		Task: "consumeOrders",
+		Arguments: model.AsyncAPIArguments{
+			Document:  writeTestAsyncAPIDocument(t, dir),
+			Operation: "consumeOrders",
+			Subscription: &model.AsyncAPISubscription{
+				Filter:  model.NewExpr("${ .payload.n > 0 }"),
+				Consume: &model.AsyncAPIMessageConsumptionPolicy{Amount: 1},
+			},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "subscription filter") {
+		t.Fatalf("Expected a filter evaluation error, got %v", err)
+	}
+}
+
+func TestAsyncAPITaskEvaluatesMessage(t *testing.T) {
+	broker := NewInMemoryBroker()
+	dir := t.TempDir()
+	activities := NewAsyncAPIActivities(map[string]MessageBroker{"": broker}, dir)
+
+	sub, err := broker.Subscribe(context.Background(), "orders.placed")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer sub.Close()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(activities.CallAsyncAPI)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, fmt.Sprintf(`
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: asyncapi-message
+  version: 1.0.0
+do:
+  - prepare:
+      set:
+        orderId: "123"
+  - publishOrder:
+      call: asyncapi
+      with:
+        document:
+          endpoint: %s
+        operation: publishOrder
+        message:
+          payload:
+            orderId: ${ .orderId }
+          headers:
+            source: ${ $task.name }
+`, writeTestAsyncAPIDocument(t, dir).Endpoint.String()), nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	select {
+	case message := <-sub.Messages():
+		payload, _ := message.Payload.(map[string]interface{})
+		if payload["orderId"] != "123" {
+			t.Errorf("Expected evaluated payload, got %v", message.Payload)
+		}
+		if message.Headers["source"] != "publishOrder" {
+			t.Errorf("Expected evaluated headers, got %v", message.Headers)
+		}
+	default:
+		t.Fatal("Expected a message on orders.placed")
+	}
+}
+
+func TestToDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration *model.Duration
+		expected time.Duration
+	}{
+		{"ISO seconds", model.NewDurationExpr("PT5S"), 5 * time.Second},
+		{"ISO mixed", model.NewDurationExpr("P1DT2H30M"), 26*time.Hour + 30*time.Minute},
+		{"inline", &model.Duration{Value: model.DurationInline{Minutes: 1, Milliseconds: 500}}, time.Minute + 500*time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toDuration(tt.duration)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
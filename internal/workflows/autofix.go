@@ -0,0 +1,279 @@
+package workflows
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Auto-fix rules applied to generated workflows before validation
+const (
+	FixDSLVersion   = "dsl-version"
+	FixURIEndpoint  = "uri-to-endpoint"
+	FixDemoEndpoint = "demo-endpoint"
+)
+
+// demoBaseURL is where generated workflows must send their HTTP calls
+const demoBaseURL = "http://localhost:8088/demo"
+
+// dslVersionPattern matches versions the model writes for DSL 1.0.0, e.g. 1.0, 1.0.0-alpha1 or v1.0.0
+var dslVersionPattern = regexp.MustCompile(`^v?1\.0(\.0)?(-[0-9A-Za-z.]+)?$`)
+
+// AutoFix is a deterministic correction applied to a generated workflow
+type AutoFix struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"` // JSON pointer to the fixed node
+	Message string `json:"message"`
+}
+
+// autoFixWorkflow rewrites known mistakes in a workflow definition: pre-release DSL versions,
+// `uri` instead of `endpoint` in HTTP calls and endpoints outside the demo API. The definition is
+// returned unchanged when it cannot be parsed or nothing needed fixing.
+func autoFixWorkflow(code string, language string) (string, []AutoFix) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(code), &root); err != nil || len(root.Content) == 0 {
+		return code, nil
+	}
+	document := root.Content[0]
+	if document.Kind != yaml.MappingNode {
+		return code, nil
+	}
+
+	var fixes []AutoFix
+	fixes = append(fixes, fixDSLVersion(document)...)
+	fixes = append(fixes, fixHTTPCalls(document, "")...)
+	if len(fixes) == 0 {
+		return code, nil
+	}
+
+	var fixed []byte
+	var err error
+	if language == FormatJSON || strings.HasPrefix(strings.TrimSpace(code), "{") {
+		fixed, err = nodeToJSON(document)
+	} else {
+		fixed, err = encodeYAMLNode(&root)
+	}
+	if err != nil {
+		return code, nil
+	}
+	return strings.TrimSpace(string(fixed)), fixes
+}
+
+// fixDSLVersion normalises document.dsl to 1.0.0
+func fixDSLVersion(document *yaml.Node) []AutoFix {
+	header := mappingValue(document, "document")
+	if header == nil || header.Kind != yaml.MappingNode {
+		return nil
+	}
+	dsl := mappingValue(header, "dsl")
+	if dsl == nil || dsl.Kind != yaml.ScalarNode || dsl.Value == "1.0.0" || !dslVersionPattern.MatchString(dsl.Value) {
+		return nil
+	}
+
+	fix := AutoFix{
+		Rule:    FixDSLVersion,
+		Path:    "/document/dsl",
+		Message: fmt.Sprintf("changed dsl version %q to \"1.0.0\"", dsl.Value),
+	}
+	dsl.Value = "1.0.0"
+	dsl.Tag = "!!str"
+	dsl.Style = 0
+	return []AutoFix{fix}
+}
+
+// fixHTTPCalls walks the definition and fixes the arguments of every `call: http` task
+func fixHTTPCalls(node *yaml.Node, path string) []AutoFix {
+	var fixes []AutoFix
+	switch node.Kind {
+	case yaml.MappingNode:
+		if call := mappingValue(node, "call"); call != nil && call.Value == "http" {
+			if with := mappingValue(node, "with"); with != nil && with.Kind == yaml.MappingNode {
+				fixes = append(fixes, fixHTTPArguments(with, path+"/with")...)
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			fixes = append(fixes, fixHTTPCalls(node.Content[i+1], path+"/"+escapePointer(node.Content[i].Value))...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			fixes = append(fixes, fixHTTPCalls(item, path+"/"+strconv.Itoa(i))...)
+		}
+	}
+	return fixes
+}
+
+// fixHTTPArguments renames `uri` to `endpoint` and points the endpoint at the demo API
+func fixHTTPArguments(with *yaml.Node, path string) []AutoFix {
+	var fixes []AutoFix
+	if mappingValue(with, "endpoint") == nil {
+		for i := 0; i+1 < len(with.Content); i += 2 {
+			if with.Content[i].Value == "uri" {
+				with.Content[i].Value = "endpoint"
+				fixes = append(fixes, AutoFix{
+					Rule:    FixURIEndpoint,
+					Path:    path + "/endpoint",
+					Message: "renamed `uri` to `endpoint`",
+				})
+				break
+			}
+		}
+	}
+
+	endpoint := mappingValue(with, "endpoint")
+	endpointPath := path + "/endpoint"
+	if endpoint != nil && endpoint.Kind == yaml.MappingNode {
+		endpoint = mappingValue(endpoint, "uri")
+		endpointPath += "/uri"
+	}
+	if endpoint == nil || endpoint.Kind != yaml.ScalarNode {
+		return fixes
+	}
+	if rewritten, ok := demoEndpoint(endpoint.Value); ok {
+		fixes = append(fixes, AutoFix{
+			Rule:    FixDemoEndpoint,
+			Path:    endpointPath,
+			Message: fmt.Sprintf("rewrote endpoint %q to %q", endpoint.Value, rewritten),
+		})
+		endpoint.Value = rewritten
+		endpoint.Style = 0
+	}
+	return fixes
+}
+
+// demoEndpoint rewrites an endpoint outside the demo API to the same path under it. Runtime
+// expressions are left alone as their value is only known at execution time.
+func demoEndpoint(endpoint string) (string, bool) {
+	if endpoint == "" || strings.Contains(endpoint, "${") {
+		return "", false
+	}
+	raw := endpoint
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+
+	// The escaped path keeps encoded separators such as %2F from turning into path segments
+	path := parsed.EscapedPath()
+	if path != "/demo" && !strings.HasPrefix(path, "/demo/") {
+		path = "/demo" + path
+	}
+	rewritten := strings.TrimSuffix(demoBaseURL, "/demo") + path
+	if parsed.RawQuery != "" {
+		rewritten += "?" + parsed.RawQuery
+	}
+	if parsed.Fragment != "" {
+		rewritten += "#" + parsed.EscapedFragment()
+	}
+	return rewritten, rewritten != endpoint
+}
+
+// describeAutoFixes lists the fixes for the conversation
+func describeAutoFixes(fixes []AutoFix) string {
+	lines := make([]string, len(fixes))
+	for i, fix := range fixes {
+		lines[i] = fmt.Sprintf("- %s: %s", fix.Path, fix.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mappingValue returns the value of a key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// escapePointer escapes a key for use in a JSON pointer
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func encodeYAMLNode(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// nodeToJSON renders a YAML node as indented JSON, keeping the key order
+func nodeToJSON(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeNodeJSON(&buf, node); err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+func writeNodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeNodeJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeNodeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeNodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNodeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(encoded)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package workflows
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+)
+
+func TestAutoFixWorkflow(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0-alpha1
+  namespace: test
+  name: orders
+  version: 1.0.0
+do:
+  - getOrder:
+      call: http
+      with:
+        method: get
+        uri: https://api.example.com/orders/1
+  - notify:
+      fork:
+        branches:
+          - email:
+              call: http
+              with:
+                method: post
+                endpoint:
+                  uri: http://localhost:8088/notifications
+          - sms:
+              call: http
+              with:
+                method: post
+                endpoint: http://localhost:8088/demo/sms
+          - dynamic:
+              call: http
+              with:
+                method: get
+                endpoint: ${ .callbackUrl }
+`
+	if _, err := parser.FromYAMLSource([]byte(definition)); err == nil {
+		t.Fatal("Expected the unfixed definition to be invalid")
+	}
+
+	fixed, fixes := autoFixWorkflow(definition, FormatYAML)
+
+	expected := []AutoFix{
+		{Rule: FixDSLVersion, Path: "/document/dsl"},
+		{Rule: FixURIEndpoint, Path: "/do/0/getOrder/with/endpoint"},
+		{Rule: FixDemoEndpoint, Path: "/do/0/getOrder/with/endpoint"},
+		{Rule: FixDemoEndpoint, Path: "/do/1/notify/fork/branches/0/email/with/endpoint/uri"},
+	}
+	if len(fixes) != len(expected) {
+		t.Fatalf("Expected %d fixes, got %+v", len(expected), fixes)
+	}
+	for i, fix := range fixes {
+		if fix.Rule != expected[i].Rule || fix.Path != expected[i].Path || fix.Message == "" {
+			t.Errorf("Fix %d: expected %s at %s, got %+v", i, expected[i].Rule, expected[i].Path, fix)
+		}
+	}
+
+	for _, want := range []string{"dsl: 1.0.0\n", "endpoint: http://localhost:8088/demo/orders/1", "uri: http://localhost:8088/demo/notifications", "endpoint: ${ .callbackUrl }"} {
+		if !strings.Contains(fixed, want) {
+			t.Errorf("Expected fixed definition to contain %q, got:\n%s", want, fixed)
+		}
+	}
+	if _, err := parser.FromYAMLSource([]byte(fixed)); err != nil {
+		t.Errorf("Expected the fixed definition to be valid, got %v", err)
+	}
+}
+
+func TestAutoFixWorkflowJSON(t *testing.T) {
+	definition := `{"document": {"dsl": "1.0", "namespace": "test", "name": "ping", "version": "1.0.0"}, "do": [{"ping": {"call": "http", "with": {"method": "get", "endpoint": "http://localhost:8088/demo/ping"}}}]}`
+
+	fixed, fixes := autoFixWorkflow(definition, FormatJSON)
+	if len(fixes) != 1 || fixes[0].Rule != FixDSLVersion {
+		t.Fatalf("Expected only the DSL version to be fixed, got %+v", fixes)
+	}
+	if !strings.HasPrefix(fixed, "{\n  \"document\": {\n    \"dsl\": \"1.0.0\"") {
+		t.Errorf("Expected indented JSON in the original key order, got:\n%s", fixed)
+	}
+	if _, err := parser.FromJSONSource([]byte(fixed)); err != nil {
+		t.Errorf("Expected the fixed definition to be valid, got %v", err)
+	}
+
+	unchanged, fixes := autoFixWorkflow(fixed, FormatJSON)
+	if len(fixes) != 0 || unchanged != fixed {
+		t.Errorf("Expected a valid definition to be left alone, got %+v", fixes)
+	}
+}
+
+func TestDemoEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+		changed  bool
+	}{
+		{endpoint: "https://api.example.com/orders/1", want: "http://localhost:8088/demo/orders/1", changed: true},
+		{endpoint: "api.example.com/orders?status=open", want: "http://localhost:8088/demo/orders?status=open", changed: true},
+		{endpoint: "https://api.example.com/files/a%2Fb", want: "http://localhost:8088/demo/files/a%2Fb", changed: true},
+		{endpoint: "https://api.example.com/docs#section-2", want: "http://localhost:8088/demo/docs#section-2", changed: true},
+		{endpoint: "http://localhost:8088/demo/ping", want: "http://localhost:8088/demo/ping", changed: false},
+		{endpoint: "${ .callbackUrl }", want: "", changed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			got, changed := demoEndpoint(tt.endpoint)
+			if got != tt.want || changed != tt.changed {
+				t.Errorf("Expected %q (changed %v), got %q (changed %v)", tt.want, tt.changed, got, changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,590 @@
+package workflows
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+)
+
+// Shape sizes and spacing of the exported BPMN diagram
+const (
+	bpmnTaskWidth   = 100
+	bpmnTaskHeight  = 80
+	bpmnEventSize   = 36
+	bpmnGatewaySize = 50
+	bpmnGap         = 50 // between consecutive elements
+	bpmnBranchGap   = 30 // between parallel branches, and between a try and its catch
+	bpmnPadding     = 30 // inside expanded subprocesses
+	bpmnMargin      = 50 // around the diagram
+)
+
+var bpmnUnsafeIDPattern = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// bpmnElement is a flow node of the exported process. Subprocesses, and the process itself,
+// contain a sequence of blocks and the sequence flows between their elements.
+type bpmnElement struct {
+	id            string
+	tag           string // BPMN element without the bpmn: prefix, e.g. serviceTask
+	name          string
+	documentation string
+	definition    string // event definition, e.g. timerEventDefinition
+	timeDuration  string // duration of a timer event definition
+	attachedTo    *bpmnElement
+	defaultFlow   *bpmnFlow
+	multiInstance bool
+
+	sequence []*bpmnBlock
+	elements []*bpmnElement
+	flows    []*bpmnFlow
+	routeY   int // line above the content that flows skipping over elements follow
+
+	incoming, outgoing  []string
+	x, y, width, height int
+}
+
+// bpmnFlow is a sequence flow, conditional when it leaves an exclusive gateway
+type bpmnFlow struct {
+	id        string
+	source    *bpmnElement
+	target    *bpmnElement
+	name      string
+	condition string
+	skip      bool // a forward flow past the next element
+}
+
+// bpmnBlock is the part of a task list one task becomes: a single element, the gateways and
+// branches of a fork, or a try subprocess with its catch. Flow enters a block through its entry
+// and leaves from each of its exits.
+type bpmnBlock struct {
+	entry    *bpmnElement
+	exits    []*bpmnElement
+	elements []*bpmnElement // flow nodes of the block, including those of fork branches
+	flows    []*bpmnFlow    // flows between the elements of the block
+
+	// Fork blocks lay out their branches between the gateways; try blocks put the catch below
+	branches []*bpmnBlock
+	boundary *bpmnElement
+	catch    *bpmnElement
+
+	width, up, down int // extent to the right of and above and below the center line
+}
+
+type bpmnBuilder struct {
+	ids   map[string]bool
+	flows int
+}
+
+// ExportBPMN exports a workflow as a BPMN 2.0 process with diagram interchange (DI) layout. Task
+// lists become sequence flows; do, for and try tasks become subprocesses (for tasks with
+// sequential multi-instance markers), fork tasks parallel gateways and switch tasks exclusive
+// gateways with conditional flows. Wait and listen tasks become timer and message catch events,
+// emit tasks message throw events and raise tasks error end events; other tasks become service
+// tasks, or script tasks for set. Sequence flows cannot leave a subprocess, so `then: end` inside
+// one ends the subprocess.
+func ExportBPMN(workflowDef *model.Workflow) string {
+	b := &bpmnBuilder{ids: map[string]bool{}}
+	name := workflowDef.Document.Name
+	if workflowDef.Document.Title != "" {
+		name = workflowDef.Document.Title
+	}
+	process := &bpmnElement{id: b.id("Process_" + workflowDef.Document.Name), tag: "process", name: name}
+	b.addTaskList(process, taskListOrEmpty(workflowDef.Do), "/do", "StartEvent", "EndEvent")
+
+	_, up, _ := measureBPMNSequence(process.sequence)
+	placeBPMNSequence(process.sequence, bpmnMargin, bpmnMargin+up)
+	process.routeY = bpmnMargin / 2
+
+	var out strings.Builder
+	out.WriteString(xml.Header)
+	out.WriteString(`<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL"` +
+		` xmlns:bpmndi="http://www.omg.org/spec/BPMN/20100524/DI"` +
+		` xmlns:dc="http://www.omg.org/spec/DD/20100524/DC"` +
+		` xmlns:di="http://www.omg.org/spec/DD/20100524/DI"` +
+		` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"` +
+		` id="Definitions_1" targetNamespace="https://serverlessworkflow.io/bpmn"` +
+		` exporter="serverless-workflow-backend">` + "\n")
+	process.writeXML(&out, "  ")
+	fmt.Fprintf(&out, "  <bpmndi:BPMNDiagram id=\"BPMNDiagram_1\">\n    <bpmndi:BPMNPlane id=\"BPMNPlane_1\" bpmnElement=%s>\n", bpmnAttr(process.id))
+	process.writeDI(&out, "      ")
+	out.WriteString("    </bpmndi:BPMNPlane>\n  </bpmndi:BPMNDiagram>\n</bpmn:definitions>\n")
+	return out.String()
+}
+
+// id returns a unique XML ID derived from a task reference or name
+func (b *bpmnBuilder) id(base string) string {
+	base = strings.Trim(bpmnUnsafeIDPattern.ReplaceAllString(base, "_"), "_")
+	id := base
+	for n := 2; b.ids[id]; n++ {
+		id = base + "_" + strconv.Itoa(n)
+	}
+	b.ids[id] = true
+	return id
+}
+
+func (b *bpmnBuilder) element(idBase, tag, name string) *bpmnElement {
+	e := &bpmnElement{id: b.id(idBase), tag: tag, name: name}
+	switch {
+	case strings.HasSuffix(tag, "Event"):
+		e.width, e.height = bpmnEventSize, bpmnEventSize
+	case strings.HasSuffix(tag, "Gateway"):
+		e.width, e.height = bpmnGatewaySize, bpmnGatewaySize
+	default:
+		e.width, e.height = bpmnTaskWidth, bpmnTaskHeight
+	}
+	return e
+}
+
+func (b *bpmnBuilder) flow(source, target *bpmnElement, name string) *bpmnFlow {
+	b.flows++
+	f := &bpmnFlow{id: "Flow_" + strconv.Itoa(b.flows), source: source, target: target, name: name}
+	source.outgoing = append(source.outgoing, f.id)
+	target.incoming = append(target.incoming, f.id)
+	return f
+}
+
+// addTaskList fills a process or subprocess with a task list between a start and an end event,
+// resolving `then` directives like BuildWorkflowGraph
+func (b *bpmnBuilder) addTaskList(container *bpmnElement, tasks model.TaskList, scope, startID, endID string) {
+	start := b.element(startID, "startEvent", "")
+	end := b.element(endID, "endEvent", "")
+
+	blocks := make([]*bpmnBlock, len(tasks))
+	positions := map[string]int{}
+	for i, task := range tasks {
+		blocks[i] = b.taskBlock(task, fmt.Sprintf("%s/%d/%s", scope, i, task.Key))
+		if _, exists := positions[task.Key]; !exists {
+			positions[task.Key] = i
+		}
+	}
+	// target resolves a flow directive to the element it leads to
+	target := func(i int, directive string) *bpmnElement {
+		switch model.FlowDirectiveType(directive) {
+		case "", model.FlowDirectiveContinue:
+			if i+1 < len(blocks) {
+				return blocks[i+1].entry
+			}
+			return end
+		case model.FlowDirectiveExit, model.FlowDirectiveEnd:
+			return end
+		}
+		if position, ok := positions[directive]; ok {
+			return blocks[position].entry
+		}
+		return nil
+	}
+
+	var flows []*bpmnFlow
+	positionOf := map[*bpmnElement]int{end: len(blocks)}
+	for i, block := range blocks {
+		positionOf[block.entry] = i
+	}
+	link := func(i int, source, target *bpmnElement, name string) *bpmnFlow {
+		f := b.flow(source, target, name)
+		f.skip = positionOf[target] > i+1
+		flows = append(flows, f)
+		return f
+	}
+	if len(blocks) == 0 {
+		link(-1, start, end, "")
+	} else {
+		link(-1, start, blocks[0].entry, "")
+	}
+	for i, task := range tasks {
+		switch t := task.Task.(type) {
+		case *model.RaiseTask:
+			// Raising an error ends the flow
+		case *model.SwitchTask:
+			gateway := blocks[i].entry
+			for _, item := range t.Switch {
+				for _, name := range sortedSwitchCases(item) {
+					switchCase := item[name]
+					directive := ""
+					if switchCase.Then != nil {
+						directive = switchCase.Then.Value
+					}
+					to := target(i, directive)
+					if to == nil {
+						continue
+					}
+					f := link(i, gateway, to, name)
+					if switchCase.When != nil {
+						f.condition = switchCase.When.Value
+					} else if gateway.defaultFlow == nil {
+						gateway.defaultFlow = f
+					}
+				}
+			}
+			if !hasDefaultCase(t) {
+				gateway.defaultFlow = link(i, gateway, target(i, ""), "default")
+			}
+		default:
+			directive := ""
+			if base := task.GetBase(); base != nil && base.Then != nil {
+				directive = base.Then.Value
+			}
+			if to := target(i, directive); to != nil {
+				for _, exit := range blocks[i].exits {
+					link(i, exit, to, "")
+				}
+			}
+		}
+	}
+
+	container.sequence = append(container.sequence, &bpmnBlock{entry: start, exits: []*bpmnElement{start}, elements: []*bpmnElement{start}})
+	container.elements = append(container.elements, start)
+	for _, block := range blocks {
+		container.sequence = append(container.sequence, block)
+		container.elements = append(container.elements, block.elements...)
+		container.flows = append(container.flows, block.flows...)
+	}
+	container.sequence = append(container.sequence, &bpmnBlock{entry: end, exits: []*bpmnElement{end}, elements: []*bpmnElement{end}})
+	container.elements = append(container.elements, end)
+	container.flows = append(container.flows, flows...)
+}
+
+// taskBlock translates a task to its block
+func (b *bpmnBuilder) taskBlock(task *model.TaskItem, reference string) *bpmnBlock {
+	single := func(e *bpmnElement) *bpmnBlock {
+		return &bpmnBlock{entry: e, exits: []*bpmnElement{e}, elements: []*bpmnElement{e}}
+	}
+
+	switch t := task.Task.(type) {
+	case *model.DoTask:
+		sub := b.element("Activity"+reference, "subProcess", task.Key)
+		b.addTaskList(sub, taskListOrEmpty(t.Do), reference+"/do", "Event"+reference+"/start", "Event"+reference+"/end")
+		return single(sub)
+	case *model.ForTask:
+		sub := b.element("Activity"+reference, "subProcess", task.Key)
+		sub.multiInstance = true
+		each := t.For.Each
+		if each == "" {
+			each = defaultIterationVar
+		}
+		sub.documentation = fmt.Sprintf("for each %s in %s", each, t.For.In)
+		if t.While != "" {
+			sub.documentation += " while " + t.While
+		}
+		b.addTaskList(sub, taskListOrEmpty(t.Do), reference+"/do", "Event"+reference+"/start", "Event"+reference+"/end")
+		return single(sub)
+	case *model.TryTask:
+		sub := b.element("Activity"+reference, "subProcess", task.Key)
+		b.addTaskList(sub, taskListOrEmpty(t.Try), reference+"/try", "Event"+reference+"/start", "Event"+reference+"/end")
+		block := single(sub)
+		if t.Catch == nil || t.Catch.Do == nil {
+			return block
+		}
+		// Errors leave the try through an error boundary event into the catch tasks
+		block.boundary = b.element("Event"+reference+"/catch", "boundaryEvent", "")
+		block.boundary.definition = "errorEventDefinition"
+		block.boundary.attachedTo = sub
+		block.catch = b.element("Activity"+reference+"/catch", "subProcess", "catch")
+		b.addTaskList(block.catch, *t.Catch.Do, reference+"/catch/do", "Event"+reference+"/catch/start", "Event"+reference+"/catch/end")
+		block.exits = append(block.exits, block.catch)
+		block.elements = append(block.elements, block.boundary, block.catch)
+		block.flows = append(block.flows, b.flow(block.boundary, block.catch, ""))
+		return block
+	case *model.ForkTask:
+		split := b.element("Gateway"+reference+"/split", "parallelGateway", task.Key)
+		// A competing fork continues with the first branch to complete
+		joinTag := "parallelGateway"
+		if t.Fork.Compete {
+			joinTag = "exclusiveGateway"
+		}
+		join := b.element("Gateway"+reference+"/join", joinTag, "")
+		block := &bpmnBlock{entry: split, exits: []*bpmnElement{join}, elements: []*bpmnElement{split}}
+		for i, branch := range taskListOrEmpty(t.Fork.Branches) {
+			branchBlock := b.taskBlock(branch, fmt.Sprintf("%s/fork/branches/%d/%s", reference, i, branch.Key))
+			block.branches = append(block.branches, branchBlock)
+			block.elements = append(block.elements, branchBlock.elements...)
+			block.flows = append(block.flows, branchBlock.flows...)
+			block.flows = append(block.flows, b.flow(split, branchBlock.entry, ""))
+			if _, raises := branch.Task.(*model.RaiseTask); raises {
+				// A raising branch ends the flow instead of reaching the join
+				continue
+			}
+			for _, exit := range branchBlock.exits {
+				block.flows = append(block.flows, b.flow(exit, join, ""))
+			}
+		}
+		block.elements = append(block.elements, join)
+		return block
+	case *model.SwitchTask:
+		return single(b.element("Gateway"+reference, "exclusiveGateway", task.Key))
+	case *model.WaitTask:
+		e := b.element("Event"+reference, "intermediateCatchEvent", task.Key)
+		e.definition = "timerEventDefinition"
+		e.timeDuration = bpmnDuration(t.Wait)
+		return single(e)
+	case *model.ListenTask:
+		e := b.element("Event"+reference, "intermediateCatchEvent", task.Key)
+		e.definition = "messageEventDefinition"
+		return single(e)
+	case *model.EmitTask:
+		e := b.element("Event"+reference, "intermediateThrowEvent", task.Key)
+		e.definition = "messageEventDefinition"
+		if t.Emit.Event.With != nil {
+			e.documentation = t.Emit.Event.With.Type
+		}
+		return single(e)
+	case *model.RaiseTask:
+		e := b.element("Event"+reference, "endEvent", task.Key)
+		e.definition = "errorEventDefinition"
+		return single(e)
+	case *model.SetTask:
+		return single(b.element("Activity"+reference, "scriptTask", task.Key))
+	default:
+		e := b.element("Activity"+reference, "serviceTask", task.Key)
+		e.documentation = taskTypeName(task)
+		return single(e)
+	}
+}
+
+// bpmnDuration writes a wait duration in ISO 8601
+func bpmnDuration(d *model.Duration) string {
+	if d == nil {
+		return ""
+	}
+	if d.AsInline() == nil {
+		return d.AsExpression()
+	}
+	total, _ := toDuration(d)
+	return "PT" + strconv.FormatFloat(total.Seconds(), 'f', -1, 64) + "S"
+}
+
+// measureBPMNSequence returns the extent of blocks laid out left to right on a center line
+func measureBPMNSequence(sequence []*bpmnBlock) (int, int, int) {
+	width, up, down := 0, 0, 0
+	for i, block := range sequence {
+		block.measure()
+		if i > 0 {
+			width += bpmnGap
+		}
+		width += block.width
+		up = max(up, block.up)
+		down = max(down, block.down)
+	}
+	return width, up, down
+}
+
+func (block *bpmnBlock) measure() {
+	switch {
+	case block.branches != nil:
+		branchWidth, height := 0, 0
+		for i, branch := range block.branches {
+			branch.measure()
+			branchWidth = max(branchWidth, branch.width)
+			if i > 0 {
+				height += bpmnBranchGap
+			}
+			height += branch.up + branch.down
+		}
+		block.width = 2*bpmnGatewaySize + 2*bpmnGap + branchWidth
+		block.up = max(height/2, bpmnGatewaySize/2)
+		block.down = max(height-height/2, bpmnGatewaySize/2)
+	default:
+		measureBPMNElement(block.entry)
+		block.width, block.up, block.down = block.entry.width, block.entry.height/2, block.entry.height-block.entry.height/2
+		if block.catch != nil {
+			measureBPMNElement(block.catch)
+			block.width = max(block.width, block.catch.width)
+			block.down += bpmnBranchGap + block.catch.height
+		}
+	}
+}
+
+// measureBPMNElement sizes a subprocess to fit its content
+func measureBPMNElement(e *bpmnElement) {
+	if e.tag != "subProcess" {
+		return
+	}
+	width, up, down := measureBPMNSequence(e.sequence)
+	e.width = width + 2*bpmnPadding
+	e.height = up + down + 2*bpmnPadding
+}
+
+// placeBPMNSequence positions measured blocks from x along the center line y
+func placeBPMNSequence(sequence []*bpmnBlock, x, y int) {
+	for _, block := range sequence {
+		block.place(x, y)
+		x += block.width + bpmnGap
+	}
+}
+
+func (block *bpmnBlock) place(x, y int) {
+	if block.branches != nil {
+		split, join := block.entry, block.exits[0]
+		split.x, split.y = x, y-bpmnGatewaySize/2
+		join.x, join.y = x+block.width-bpmnGatewaySize, y-bpmnGatewaySize/2
+		top := y - block.up
+		for _, branch := range block.branches {
+			branch.place(x+bpmnGatewaySize+bpmnGap, top+branch.up)
+			top += branch.up + branch.down + bpmnBranchGap
+		}
+		return
+	}
+
+	placeBPMNElement(block.entry, x, y-block.entry.height/2)
+	if block.catch != nil {
+		sub := block.entry
+		block.boundary.x = sub.x + sub.width - bpmnEventSize - bpmnPadding
+		block.boundary.y = sub.y + sub.height - bpmnEventSize/2
+		placeBPMNElement(block.catch, x, sub.y+sub.height+bpmnBranchGap)
+	}
+}
+
+// placeBPMNElement positions an element by its top left corner, and the content of subprocesses
+func placeBPMNElement(e *bpmnElement, x, y int) {
+	e.x, e.y = x, y
+	if e.tag == "subProcess" {
+		_, up, _ := measureBPMNSequence(e.sequence)
+		placeBPMNSequence(e.sequence, x+bpmnPadding, y+bpmnPadding+up)
+		e.routeY = y + bpmnPadding/2
+	}
+}
+
+// writeXML writes the semantic part of an element: its attributes, event definition and content
+func (e *bpmnElement) writeXML(out *strings.Builder, indent string) {
+	fmt.Fprintf(out, "%s<bpmn:%s id=%s", indent, e.tag, bpmnAttr(e.id))
+	if e.name != "" {
+		fmt.Fprintf(out, " name=%s", bpmnAttr(e.name))
+	}
+	if e.tag == "process" {
+		out.WriteString(` isExecutable="false"`)
+	}
+	if e.attachedTo != nil {
+		fmt.Fprintf(out, " attachedToRef=%s", bpmnAttr(e.attachedTo.id))
+	}
+	if e.defaultFlow != nil {
+		fmt.Fprintf(out, " default=%s", bpmnAttr(e.defaultFlow.id))
+	}
+
+	var children strings.Builder
+	inner := indent + "  "
+	if e.documentation != "" {
+		fmt.Fprintf(&children, "%s<bpmn:documentation>%s</bpmn:documentation>\n", inner, bpmnText(e.documentation))
+	}
+	for _, id := range e.incoming {
+		fmt.Fprintf(&children, "%s<bpmn:incoming>%s</bpmn:incoming>\n", inner, id)
+	}
+	for _, id := range e.outgoing {
+		fmt.Fprintf(&children, "%s<bpmn:outgoing>%s</bpmn:outgoing>\n", inner, id)
+	}
+	if e.multiInstance {
+		fmt.Fprintf(&children, "%s<bpmn:multiInstanceLoopCharacteristics isSequential=\"true\" />\n", inner)
+	}
+	switch {
+	case e.timeDuration != "":
+		fmt.Fprintf(&children, "%s<bpmn:%s>\n%s  <bpmn:timeDuration xsi:type=\"bpmn:tFormalExpression\">%s</bpmn:timeDuration>\n%s</bpmn:%s>\n",
+			inner, e.definition, inner, bpmnText(e.timeDuration), inner, e.definition)
+	case e.definition != "":
+		fmt.Fprintf(&children, "%s<bpmn:%s />\n", inner, e.definition)
+	}
+	for _, child := range e.elements {
+		child.writeXML(&children, inner)
+	}
+	for _, f := range e.flows {
+		fmt.Fprintf(&children, "%s<bpmn:sequenceFlow id=%s", inner, bpmnAttr(f.id))
+		if f.name != "" {
+			fmt.Fprintf(&children, " name=%s", bpmnAttr(f.name))
+		}
+		fmt.Fprintf(&children, " sourceRef=%s targetRef=%s", bpmnAttr(f.source.id), bpmnAttr(f.target.id))
+		if f.condition == "" {
+			children.WriteString(" />\n")
+			continue
+		}
+		fmt.Fprintf(&children, ">\n%s  <bpmn:conditionExpression xsi:type=\"bpmn:tFormalExpression\">%s</bpmn:conditionExpression>\n%s</bpmn:sequenceFlow>\n",
+			inner, bpmnText(f.condition), inner)
+	}
+
+	if children.Len() == 0 {
+		out.WriteString(" />\n")
+		return
+	}
+	fmt.Fprintf(out, ">\n%s%s</bpmn:%s>\n", children.String(), indent, e.tag)
+}
+
+// writeDI writes the shapes of the elements contained in a process or subprocess, then the edges
+// of its flows
+func (e *bpmnElement) writeDI(out *strings.Builder, indent string) {
+	for _, child := range e.elements {
+		fmt.Fprintf(out, "%s<bpmndi:BPMNShape id=%s bpmnElement=%s", indent, bpmnAttr(child.id+"_di"), bpmnAttr(child.id))
+		if child.tag == "subProcess" {
+			out.WriteString(` isExpanded="true"`)
+		}
+		fmt.Fprintf(out, ">\n%s  <dc:Bounds x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" />\n%s</bpmndi:BPMNShape>\n",
+			indent, child.x, child.y, child.width, child.height, indent)
+	}
+	for _, f := range e.flows {
+		fmt.Fprintf(out, "%s<bpmndi:BPMNEdge id=%s bpmnElement=%s>\n", indent, bpmnAttr(f.id+"_di"), bpmnAttr(f.id))
+		waypoints := bpmnWaypoints(f.source, f.target)
+		if f.skip {
+			// Pass over the elements in between
+			sourceCenter, targetCenter := f.source.x+f.source.width/2, f.target.x+f.target.width/2
+			waypoints = [][2]int{{sourceCenter, f.source.y}, {sourceCenter, e.routeY}, {targetCenter, e.routeY}, {targetCenter, f.target.y}}
+		}
+		for _, point := range waypoints {
+			fmt.Fprintf(out, "%s  <di:waypoint x=\"%d\" y=\"%d\" />\n", indent, point[0], point[1])
+		}
+		fmt.Fprintf(out, "%s</bpmndi:BPMNEdge>\n", indent)
+	}
+	for _, child := range e.elements {
+		if child.tag == "subProcess" {
+			child.writeDI(out, indent)
+		}
+	}
+}
+
+// bpmnWaypoints routes a flow with horizontal and vertical segments: forward flows leave to the
+// right (or from the top or bottom of a splitting gateway), flows to elements below go down and
+// flows back to earlier elements loop underneath
+func bpmnWaypoints(source, target *bpmnElement) [][2]int {
+	sourceRight, sourceMiddle := source.x+source.width, source.y+source.height/2
+	targetMiddle := target.y + target.height/2
+	sourceCenter, targetCenter := source.x+source.width/2, target.x+target.width/2
+	sourceBottom, targetBottom := source.y+source.height, target.y+target.height
+
+	switch {
+	case target.x >= sourceRight:
+		switch {
+		case sourceMiddle == targetMiddle:
+			return [][2]int{{sourceRight, sourceMiddle}, {target.x, targetMiddle}}
+		case strings.HasSuffix(source.tag, "Gateway"):
+			edge := source.y
+			if targetMiddle > sourceMiddle {
+				edge = sourceBottom
+			}
+			return [][2]int{{sourceCenter, edge}, {sourceCenter, targetMiddle}, {target.x, targetMiddle}}
+		case strings.HasSuffix(target.tag, "Gateway"):
+			edge := target.y
+			if sourceMiddle > targetMiddle {
+				edge = targetBottom
+			}
+			return [][2]int{{sourceRight, sourceMiddle}, {targetCenter, sourceMiddle}, {targetCenter, edge}}
+		}
+		middle := (sourceRight + target.x) / 2
+		return [][2]int{{sourceRight, sourceMiddle}, {middle, sourceMiddle}, {middle, targetMiddle}, {target.x, targetMiddle}}
+	case target.y >= sourceBottom:
+		if sourceCenter >= target.x && sourceCenter <= target.x+target.width {
+			return [][2]int{{sourceCenter, sourceBottom}, {sourceCenter, target.y}}
+		}
+		middle := (sourceBottom + target.y) / 2
+		return [][2]int{{sourceCenter, sourceBottom}, {sourceCenter, middle}, {targetCenter, middle}, {targetCenter, target.y}}
+	}
+	below := max(sourceBottom, targetBottom) + bpmnBranchGap
+	return [][2]int{{sourceCenter, sourceBottom}, {sourceCenter, below}, {targetCenter, below}, {targetCenter, targetBottom}}
+}
+
+// bpmnAttr quotes and escapes an attribute value
+func bpmnAttr(value string) string {
+	return `"` + bpmnText(value) + `"`
+}
+
+func bpmnText(value string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
@@ -0,0 +1,272 @@
+package workflows
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+)
+
+// xmlNode is a generic XML element for inspecting exported documents
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+func (n xmlNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func TestExportBPMN(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: bpmn
+  version: 1.0.0
+  title: Order <review>
+do:
+  - route:
+      switch:
+        - big:
+            when: ${ .total > 100 }
+            then: review
+        - small:
+            then: parallel
+  - review:
+      do:
+        - approve:
+            set:
+              approved: true
+        - stop:
+            set:
+              stopped: true
+            then: end
+      then: end
+  - parallel:
+      fork:
+        branches:
+          - notify:
+              call: http
+              with:
+                method: post
+                endpoint: http://localhost:8088/demo/notify
+          - each:
+              for:
+                in: ${ .items }
+              do:
+                - ship:
+                    wait:
+                      seconds: 90
+  - approved:
+      listen:
+        to:
+          one:
+            with:
+              type: com.example.approved
+  - announce:
+      emit:
+        event:
+          with:
+            source: https://example.com
+            type: com.example.done
+  - guarded:
+      try:
+        - risky:
+            raise:
+              error:
+                type: https://example.com/errors/risky
+                status: 500
+      catch:
+        do:
+          - recover:
+              set:
+                recovered: true
+`
+	workflowDef, err := parser.FromYAMLSource([]byte(definition))
+	if err != nil {
+		t.Fatalf("Failed to parse workflow: %v", err)
+	}
+	exported := ExportBPMN(workflowDef)
+
+	var root xmlNode
+	if err := xml.Unmarshal([]byte(exported), &root); err != nil {
+		t.Fatalf("Export is not well-formed XML: %v\n%s", err, exported)
+	}
+	if root.XMLName.Local != "definitions" || root.XMLName.Space != "http://www.omg.org/spec/BPMN/20100524/MODEL" {
+		t.Fatalf("Unexpected root element %v", root.XMLName)
+	}
+
+	// Index the flow nodes and flows with the element containing them
+	elements := map[string]xmlNode{}
+	parents := map[string]string{}
+	var flows []xmlNode
+	var flowParents []string
+	shapes := map[string]bool{}
+	edges := map[string]int{}
+	var walk func(node xmlNode, parent string)
+	walk = func(node xmlNode, parent string) {
+		id := node.attr("id")
+		switch node.XMLName.Local {
+		case "sequenceFlow":
+			flows = append(flows, node)
+			flowParents = append(flowParents, parent)
+		case "BPMNShape":
+			shapes[node.attr("bpmnElement")] = true
+		case "BPMNEdge":
+			for _, child := range node.Nodes {
+				if child.XMLName.Local == "waypoint" {
+					edges[node.attr("bpmnElement")]++
+				}
+			}
+		default:
+			if id != "" && node.XMLName.Space == root.XMLName.Space {
+				elements[id] = node
+				parents[id] = parent
+			}
+		}
+		for _, child := range node.Nodes {
+			if node.XMLName.Local == "process" || node.XMLName.Local == "subProcess" {
+				walk(child, id)
+			} else {
+				walk(child, parent)
+			}
+		}
+	}
+	for _, child := range root.Nodes {
+		walk(child, "")
+	}
+
+	count := map[string]int{}
+	for id, element := range elements {
+		count[element.XMLName.Local]++
+		if element.XMLName.Local != "process" && !shapes[id] {
+			t.Errorf("Element %s has no shape", id)
+		}
+	}
+	for tag, expected := range map[string]int{
+		"process": 1, "exclusiveGateway": 1, "parallelGateway": 2, "subProcess": 4,
+		"intermediateCatchEvent": 2, "intermediateThrowEvent": 1, "boundaryEvent": 1, "serviceTask": 1, "scriptTask": 3,
+	} {
+		if count[tag] != expected {
+			t.Errorf("Expected %d %s elements, got %d", expected, tag, count[tag])
+		}
+	}
+
+	for i, flow := range flows {
+		id := flow.attr("id")
+		source, target := flow.attr("sourceRef"), flow.attr("targetRef")
+		if _, ok := elements[source]; !ok {
+			t.Errorf("Flow %s has unknown source %s", id, source)
+		}
+		if _, ok := elements[target]; !ok {
+			t.Errorf("Flow %s has unknown target %s", id, target)
+		}
+		if parents[source] != flowParents[i] || parents[target] != flowParents[i] {
+			t.Errorf("Flow %s from %s to %s crosses a subprocess boundary", id, source, target)
+		}
+		if edges[id] < 2 {
+			t.Errorf("Flow %s has %d waypoints", id, edges[id])
+		}
+	}
+
+	route := elements["Gateway_do_0_route"]
+	var conditions []string
+	for _, flow := range flows {
+		if flow.attr("sourceRef") != route.attr("id") {
+			continue
+		}
+		if flow.attr("id") == route.attr("default") {
+			if flow.attr("name") != "small" || flow.attr("targetRef") != "Gateway_do_2_parallel_split" {
+				t.Errorf("Unexpected default flow %+v", flow.Attrs)
+			}
+			continue
+		}
+		for _, child := range flow.Nodes {
+			conditions = append(conditions, strings.TrimSpace(child.Content))
+		}
+	}
+	if len(conditions) != 1 || conditions[0] != "${ .total > 100 }" {
+		t.Errorf("Expected the big case as the only condition, got %v", conditions)
+	}
+
+	for _, fragment := range []string{
+		`name="Order &lt;review&gt;"`,
+		`<bpmn:multiInstanceLoopCharacteristics isSequential="true" />`,
+		"<bpmn:documentation>for each item in ${ .items }</bpmn:documentation>",
+		`<bpmn:timeDuration xsi:type="bpmn:tFormalExpression">PT90S</bpmn:timeDuration>`,
+		`attachedToRef="Activity_do_5_guarded"`,
+		"<bpmn:documentation>com.example.done</bpmn:documentation>",
+		"<bpmn:documentation>call:http</bpmn:documentation>",
+	} {
+		if !strings.Contains(exported, fragment) {
+			t.Errorf("Expected the export to contain %q:\n%s", fragment, exported)
+		}
+	}
+	risky := elements["Event_do_5_guarded_try_0_risky"]
+	if risky.XMLName.Local != "endEvent" || risky.Nodes[len(risky.Nodes)-1].XMLName.Local != "errorEventDefinition" {
+		t.Errorf("Expected the raise task to be an error end event, got %+v", risky)
+	}
+}
+
+func TestExportBPMNRaisingBranch(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: bpmn-raise
+  version: 1.0.0
+do:
+  - parallel:
+      fork:
+        branches:
+          - notify:
+              set:
+                notified: true
+          - fail:
+              raise:
+                error:
+                  type: https://example.com/errors/failed
+                  status: 500
+`
+	workflowDef, err := parser.FromYAMLSource([]byte(definition))
+	if err != nil {
+		t.Fatalf("Failed to parse workflow: %v", err)
+	}
+	exported := ExportBPMN(workflowDef)
+
+	var root xmlNode
+	if err := xml.Unmarshal([]byte(exported), &root); err != nil {
+		t.Fatalf("Export is not well-formed XML: %v\n%s", err, exported)
+	}
+	var flows []xmlNode
+	var walk func(node xmlNode)
+	walk = func(node xmlNode) {
+		if node.XMLName.Local == "sequenceFlow" {
+			flows = append(flows, node)
+		}
+		for _, child := range node.Nodes {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	joinInputs := 0
+	for _, flow := range flows {
+		if flow.attr("sourceRef") == "Event_do_0_parallel_fork_branches_1_fail" {
+			t.Errorf("Expected the raising branch to end the flow, got flow %+v", flow.Attrs)
+		}
+		if flow.attr("targetRef") == "Gateway_do_0_parallel_join" {
+			joinInputs++
+		}
+	}
+	if joinInputs != 1 {
+		t.Errorf("Expected 1 flow into the join, got %d\n%s", joinInputs, exported)
+	}
+}
@@ -0,0 +1,121 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// Chat stream event types relayed to /chatbot/threads/{id}/stream subscribers. A new message.start
+// (e.g. for a retried activity or a correction round) replaces any text streamed before it.
+const (
+	ChatStreamMessageStart = "message.start"
+	ChatStreamMessageDelta = "message.delta"
+	ChatStreamMessageStop  = "message.stop"
+	ChatStreamMessageError = "message.error"
+)
+
+// chatStreamPublishTimeout bounds how long a slow subscriber can hold up token streaming
+const chatStreamPublishTimeout = 100 * time.Millisecond
+
+// chatStreamHeartbeatInterval is how often GenerateReply heartbeats while the provider call runs
+const chatStreamHeartbeatInterval = 2 * time.Second
+
+// chatStreams relays reply deltas from the chatbot activities to the API. The broker is process-local:
+// a subscriber only sees the deltas of activities that run in its own process. cmd/api runs the worker
+// next to the API, but with several replicas, or a worker in a separate process, deltas generated
+// elsewhere are dropped; the reply itself is unaffected and stays available from the thread.
+var chatStreams MessageBroker = NewInMemoryBroker()
+
+// ChatStreamEvent is a streamed fragment of an assistant reply
+type ChatStreamEvent struct {
+	Type       string `json:"type"`
+	ThreadID   string `json:"thread_id"`
+	Attempt    int32  `json:"attempt"`
+	Text       string `json:"text,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SubscribeChatStream subscribes to the reply deltas streamed for a chat thread
+func SubscribeChatStream(ctx context.Context, threadID string) (BrokerSubscription, error) {
+	return chatStreams.Subscribe(ctx, chatStreamChannel(threadID))
+}
+
+func chatStreamChannel(threadID string) string {
+	return "chatbot/" + threadID + "/stream"
+}
+
+// GenerateReply asks the configured provider for the next assistant message, publishing text
+// deltas for the thread as they arrive. It heartbeats on a ticker for as long as the provider call
+// runs, so a provider that is slow to send its first token is not mistaken for a dead worker.
+func (a *ChatbotActivities) GenerateReply(ctx context.Context, request LLMRequest) (*LLMResponse, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("GenerateReply activity started")
+
+	if a.provider == nil {
+		return nil, fmt.Errorf("no LLM provider configured, set ANTHROPIC_API_KEY or LLM_PROVIDER")
+	}
+
+	attempt := activity.GetInfo(ctx).Attempt
+	publish := func(event ChatStreamEvent) {
+		event.ThreadID = request.ThreadID
+		event.Attempt = attempt
+		publishCtx, cancel := context.WithTimeout(ctx, chatStreamPublishTimeout)
+		defer cancel()
+		if err := chatStreams.Publish(publishCtx, chatStreamChannel(request.ThreadID), BrokerMessage{Payload: event}); err != nil {
+			logger.Warn("Dropped chat stream event", "type", event.Type, "error", err)
+		}
+	}
+
+	publish(ChatStreamEvent{Type: ChatStreamMessageStart})
+
+	var streamed atomic.Int64
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(chatStreamHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx, streamed.Load())
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	response, err := a.provider.Complete(ctx, request, func(text string) {
+		streamed.Add(int64(len(text)))
+		publish(ChatStreamEvent{Type: ChatStreamMessageDelta, Text: text})
+	})
+	if err != nil {
+		publish(ChatStreamEvent{Type: ChatStreamMessageError, Error: err.Error()})
+		return nil, err
+	}
+
+	if len(response.Message.Content) == 0 {
+		publish(ChatStreamEvent{Type: ChatStreamMessageError, Error: "empty response content"})
+		return nil, fmt.Errorf("empty response content from LLM provider")
+	}
+
+	publish(ChatStreamEvent{Type: ChatStreamMessageStop, StopReason: response.StopReason})
+	if response.StopReason == StopMaxTokens {
+		logger.Warn("Response was truncated due to max_tokens limit",
+			"input_tokens", response.Usage.InputTokens,
+			"output_tokens", response.Usage.OutputTokens)
+	} else {
+		logger.Info("Response generated",
+			"stop_reason", response.StopReason,
+			"input_tokens", response.Usage.InputTokens,
+			"output_tokens", response.Usage.OutputTokens)
+	}
+
+	return response, nil
+}
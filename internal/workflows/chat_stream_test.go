@@ -0,0 +1,110 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestGenerateReplyStreamsAnthropic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-test","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":5,"output_tokens":0}}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world"}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":3}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		}
+		for _, event := range events {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event[0], event[1])
+		}
+	}))
+	defer server.Close()
+
+	activities := NewChatbotActivitiesWithProvider(NewAnthropicProvider("test", "", option.WithBaseURL(server.URL)))
+
+	subscription, err := SubscribeChatStream(context.Background(), "thread-stream")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer subscription.Close()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.GenerateReply)
+
+	result, err := env.ExecuteActivity(activities.GenerateReply, LLMRequest{
+		ThreadID:     "thread-stream",
+		SystemPrompt: "system",
+		Messages:     []ChatMessage{NewTextMessage(RoleUser, "Hi")},
+	})
+	if err != nil {
+		t.Fatalf("Activity failed: %v", err)
+	}
+
+	var response LLMResponse
+	if err := result.Get(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Message.Text() != "Hello, world" || response.StopReason != StopEndTurn {
+		t.Errorf("Expected accumulated text, got %+v", response)
+	}
+
+	var types, text string
+	for len(subscription.Messages()) > 0 {
+		event := (<-subscription.Messages()).Payload.(ChatStreamEvent)
+		types += event.Type + " "
+		text += event.Text
+	}
+	if types != "message.start message.delta message.delta message.stop " {
+		t.Errorf("Unexpected stream events: %s", types)
+	}
+	if text != "Hello, world" {
+		t.Errorf("Expected streamed deltas to form the reply, got %q", text)
+	}
+}
+
+// slowProvider replies after a delay without streaming any deltas
+type slowProvider struct {
+	delay time.Duration
+}
+
+func (p slowProvider) Complete(ctx context.Context, request LLMRequest, onDelta func(text string)) (*LLMResponse, error) {
+	time.Sleep(p.delay)
+	return &LLMResponse{Message: NewTextMessage(RoleAssistant, "done"), StopReason: StopEndTurn}, nil
+}
+
+func TestGenerateReplyHeartbeatsWithoutDeltas(t *testing.T) {
+	activities := NewChatbotActivitiesWithProvider(slowProvider{delay: chatStreamHeartbeatInterval + 500*time.Millisecond})
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities.GenerateReply)
+
+	var heartbeats atomic.Int32
+	env.SetOnActivityHeartbeatListener(func(activityInfo *activity.Info, details converter.EncodedValues) {
+		heartbeats.Add(1)
+	})
+
+	if _, err := env.ExecuteActivity(activities.GenerateReply, LLMRequest{
+		ThreadID: "thread-slow",
+		Messages: []ChatMessage{NewTextMessage(RoleUser, "Hi")},
+	}); err != nil {
+		t.Fatalf("Activity failed: %v", err)
+	}
+	if heartbeats.Load() == 0 {
+		t.Error("Expected a heartbeat while the provider was silent")
+	}
+}
@@ -0,0 +1,187 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// UpdateConfigUpdate is the update that changes the configuration of a chat thread
+const UpdateConfigUpdate = "update-config"
+
+// ChatbotInvalidConfigErrorType is returned when a thread configuration is rejected
+const ChatbotInvalidConfigErrorType = "InvalidConfig"
+
+// Workflow definition formats the assistant can be asked to produce
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Limits and defaults of the thread configuration
+const (
+	defaultMaxRetries = 4
+	maxRetriesLimit   = 10
+	maxTokensLimit    = 64000
+	maxTemperature    = 1.0
+)
+
+// ChatbotConfig configures how a chat thread generates replies. Zero values select the defaults:
+// the provider's default model, 4096 max tokens, the provider's default temperature, the built-in
+// system prompt, YAML and 4 attempts to produce a valid workflow.
+type ChatbotConfig struct {
+	Model              string   `json:"model,omitempty"`
+	MaxTokens          int      `json:"max_tokens,omitempty"`
+	Temperature        *float64 `json:"temperature,omitempty"`
+	SystemPrompt       string   `json:"system_prompt,omitempty"`        // replaces the built-in system prompt
+	SystemPromptAppend string   `json:"system_prompt_append,omitempty"` // appended to the system prompt
+	Format             string   `json:"format,omitempty"`               // "yaml" or "json"
+	MaxRetries         int      `json:"max_retries,omitempty"`          // attempts to produce a valid workflow per message
+}
+
+// Validate checks the configuration values are within the supported ranges
+func (c ChatbotConfig) Validate() error {
+	if c.MaxTokens < 0 || c.MaxTokens > maxTokensLimit {
+		return fmt.Errorf("max_tokens must be between 1 and %d, or 0 for the default", maxTokensLimit)
+	}
+	if c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > maxTemperature) {
+		return fmt.Errorf("temperature must be between 0 and %g", maxTemperature)
+	}
+	if c.Format != "" && c.Format != FormatYAML && c.Format != FormatJSON {
+		return fmt.Errorf("format must be %q or %q", FormatYAML, FormatJSON)
+	}
+	if c.MaxRetries < 0 || c.MaxRetries > maxRetriesLimit {
+		return fmt.Errorf("max_retries must be between 1 and %d, or 0 for the default", maxRetriesLimit)
+	}
+	return nil
+}
+
+// withDefaults fills in the defaults the workflow relies on
+func (c ChatbotConfig) withDefaults() ChatbotConfig {
+	if c.Format == "" {
+		c.Format = FormatYAML
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = defaultMaxRetries
+	}
+	return c
+}
+
+// ChatbotConfigUpdate changes the configuration of a thread. A zero value in Config leaves the field
+// unchanged, so fields return to their defaults by being named in Reset instead.
+type ChatbotConfigUpdate struct {
+	Config ChatbotConfig `json:"config"`
+	Reset  []string      `json:"reset,omitempty"` // JSON names of the fields to reset, e.g. "system_prompt"
+}
+
+// apply returns the configuration with the fields named in Reset cleared, then the fields set in
+// Config overriding the current values
+func (c ChatbotConfig) apply(update ChatbotConfigUpdate) (ChatbotConfig, error) {
+	for _, field := range update.Reset {
+		switch field {
+		case "model":
+			c.Model = ""
+		case "max_tokens":
+			c.MaxTokens = 0
+		case "temperature":
+			c.Temperature = nil
+		case "system_prompt":
+			c.SystemPrompt = ""
+		case "system_prompt_append":
+			c.SystemPromptAppend = ""
+		case "format":
+			c.Format = ""
+		case "max_retries":
+			c.MaxRetries = 0
+		default:
+			return c, fmt.Errorf("unknown config field %q in reset", field)
+		}
+	}
+	return c.merge(update.Config).withDefaults(), nil
+}
+
+// merge returns the configuration with the fields set in update overriding the current values
+func (c ChatbotConfig) merge(update ChatbotConfig) ChatbotConfig {
+	if update.Model != "" {
+		c.Model = update.Model
+	}
+	if update.MaxTokens != 0 {
+		c.MaxTokens = update.MaxTokens
+	}
+	if update.Temperature != nil {
+		c.Temperature = update.Temperature
+	}
+	if update.SystemPrompt != "" {
+		c.SystemPrompt = update.SystemPrompt
+	}
+	if update.SystemPromptAppend != "" {
+		c.SystemPromptAppend = update.SystemPromptAppend
+	}
+	if update.Format != "" {
+		c.Format = update.Format
+	}
+	if update.MaxRetries != 0 {
+		c.MaxRetries = update.MaxRetries
+	}
+	return c
+}
+
+// chatbotSystemPrompt builds the system prompt for a thread configuration
+func chatbotSystemPrompt(config ChatbotConfig) string {
+	prompt := defaultChatbotSystemPrompt
+	if config.SystemPrompt != "" {
+		prompt = config.SystemPrompt
+	}
+
+	var sections []string
+	sections = append(sections, prompt)
+	if config.Format == FormatJSON {
+		sections = append(sections, "Provide workflow definitions in JSON instead of YAML, in a ```json code block.")
+	}
+	if config.SystemPromptAppend != "" {
+		sections = append(sections, config.SystemPromptAppend)
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// setUpdateConfigHandler registers the update that changes the thread configuration. Fields left
+// unset in the request keep their current value unless they are reset; the merged configuration is
+// returned.
+func setUpdateConfigHandler(ctx workflow.Context, state *ChatbotState) error {
+	return workflow.SetUpdateHandlerWithOptions(ctx, UpdateConfigUpdate,
+		func(ctx workflow.Context, update ChatbotConfigUpdate) (*ChatbotConfig, error) {
+			if state.IsProcessing {
+				return nil, errChatbotBusy()
+			}
+			config, err := state.Config.apply(update)
+			if err != nil {
+				return nil, errInvalidChatbotConfig(err)
+			}
+			state.Config = config
+			state.SystemPrompt = chatbotSystemPrompt(state.Config)
+			state.interactions++
+			return &state.Config, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, update ChatbotConfigUpdate) error {
+				config, err := state.Config.apply(update)
+				if err == nil {
+					err = config.Validate()
+				}
+				if err != nil {
+					return errInvalidChatbotConfig(err)
+				}
+				if state.IsProcessing {
+					return errChatbotBusy()
+				}
+				return nil
+			},
+		},
+	)
+}
+
+func errInvalidChatbotConfig(err error) error {
+	return temporal.NewNonRetryableApplicationError(err.Error(), ChatbotInvalidConfigErrorType, nil)
+}
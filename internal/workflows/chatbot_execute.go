@@ -0,0 +1,164 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ExecuteWorkflowUpdate is the update that starts a workflow generated in a chat thread
+const ExecuteWorkflowUpdate = "execute-workflow"
+
+// ChatbotNoWorkflowErrorType is returned when the thread has no validated workflow to execute
+const ChatbotNoWorkflowErrorType = "NoWorkflow"
+
+// GeneratedWorkflow is a validated workflow definition produced by the assistant
+type GeneratedWorkflow struct {
+	MessageIndex int       `json:"message_index"` // index of the assistant message in the conversation
+	WorkflowCode string    `json:"workflow_code"`
+	Fixes        []AutoFix `json:"fixes,omitempty"` // auto-fixes applied to the code in the message
+}
+
+// ThreadExecution is a workflow execution started from a chat thread
+type ThreadExecution struct {
+	WorkflowID   string         `json:"workflow_id"`
+	RunID        string         `json:"run_id"`
+	MessageIndex int            `json:"message_index"`
+	Status       string         `json:"status"` // "running", "completed", "failed" or "cancelled"
+	Output       interface{}    `json:"output,omitempty"`
+	Error        *WorkflowError `json:"error,omitempty"`
+	StartedAt    time.Time      `json:"started_at"`
+	ClosedAt     *time.Time     `json:"closed_at,omitempty"`
+}
+
+// ExecuteWorkflowRequest selects the generated workflow to execute. Without a message index the
+// most recently validated workflow is executed.
+type ExecuteWorkflowRequest struct {
+	MessageIndex *int `json:"message_index,omitempty"`
+}
+
+// setExecuteWorkflowHandler registers the update that starts a generated workflow as a child
+// execution. The execution outlives the thread; its outcome is summarised in the conversation.
+func setExecuteWorkflowHandler(ctx workflow.Context, state *ChatbotState) error {
+	return workflow.SetUpdateHandlerWithOptions(ctx, ExecuteWorkflowUpdate,
+		func(ctx workflow.Context, request ExecuteWorkflowRequest) (*ThreadExecution, error) {
+			generated, err := selectGeneratedWorkflow(state, request)
+			if err != nil {
+				return nil, err
+			}
+
+			state.executionsStarted++
+			options := workflow.ChildWorkflowOptions{
+				WorkflowID:        fmt.Sprintf("yaml-workflow-%s-%d", state.ThreadID, state.executionsStarted),
+				ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+			}
+			// Unregistered search attributes would fail the start command and with it every
+			// workflow task of the thread, so the child is tagged only when the thread was
+			if searchAttributesRegistered(ctx) {
+				options.TypedSearchAttributes = ServerlessSearchAttributes([]byte(generated.WorkflowCode))
+			}
+			childCtx := workflow.WithChildOptions(ctx, options)
+			child := workflow.ExecuteChildWorkflow(childCtx, ExecuteServerlessYAMLWorkflow, generated.WorkflowCode, nil)
+
+			var started workflow.Execution
+			if err := child.GetChildWorkflowExecution().Get(ctx, &started); err != nil {
+				return nil, fmt.Errorf("failed to start workflow: %w", err)
+			}
+
+			state.Executions = append(state.Executions, ThreadExecution{
+				WorkflowID:   started.ID,
+				RunID:        started.RunID,
+				MessageIndex: generated.MessageIndex,
+				Status:       WorkflowStatusRunning,
+				StartedAt:    workflow.Now(ctx),
+			})
+			response := state.Executions[len(state.Executions)-1]
+
+			workflow.Go(ctx, func(ctx workflow.Context) {
+				var output interface{}
+				err := child.Get(ctx, &output)
+				recordThreadExecutionOutcome(ctx, state, started.ID, output, err)
+			})
+
+			return &response, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, request ExecuteWorkflowRequest) error {
+				_, err := selectGeneratedWorkflow(state, request)
+				return err
+			},
+		},
+	)
+}
+
+// selectGeneratedWorkflow returns the generated workflow requested for execution
+func selectGeneratedWorkflow(state *ChatbotState, request ExecuteWorkflowRequest) (*GeneratedWorkflow, error) {
+	if len(state.Workflows) == 0 {
+		return nil, temporal.NewApplicationError("no validated workflow in this thread", ChatbotNoWorkflowErrorType)
+	}
+	if request.MessageIndex == nil {
+		return &state.Workflows[len(state.Workflows)-1], nil
+	}
+	for i := range state.Workflows {
+		if state.Workflows[i].MessageIndex == *request.MessageIndex {
+			return &state.Workflows[i], nil
+		}
+	}
+	return nil, temporal.NewApplicationError(
+		fmt.Sprintf("message %d does not contain a validated workflow", *request.MessageIndex), ChatbotNoWorkflowErrorType)
+}
+
+// recordThreadExecutionOutcome stores the outcome of an execution started from the thread and
+// summarises it in the conversation once the assistant is not mid-reply
+func recordThreadExecutionOutcome(ctx workflow.Context, state *ChatbotState, workflowID string, output interface{}, err error) {
+	var execution *ThreadExecution
+	for i := range state.Executions {
+		if state.Executions[i].WorkflowID == workflowID {
+			execution = &state.Executions[i]
+		}
+	}
+	if execution == nil {
+		return
+	}
+
+	now := workflow.Now(ctx)
+	execution.ClosedAt = &now
+	switch {
+	case err == nil:
+		execution.Status = WorkflowStatusCompleted
+		execution.Output = output
+	case temporal.IsCanceledError(err):
+		execution.Status = WorkflowStatusCancelled
+		execution.Error = newRuntimeError(workflowID, err)
+	default:
+		execution.Status = WorkflowStatusFailed
+		execution.Error = newRuntimeError(workflowID, err)
+	}
+
+	summary := executionSummaryMessage(execution)
+	if awaitErr := workflow.Await(ctx, func() bool { return !state.IsProcessing }); awaitErr != nil {
+		return
+	}
+	state.Conversation = append(state.Conversation, NewTextMessage(RoleUser, summary))
+	state.interactions++
+}
+
+// executionSummaryMessage describes an execution outcome for the assistant to iterate on
+func executionSummaryMessage(execution *ThreadExecution) string {
+	switch execution.Status {
+	case WorkflowStatusCompleted:
+		outputJSON, err := json.MarshalIndent(execution.Output, "", "  ")
+		if err != nil {
+			outputJSON = []byte(fmt.Sprintf("%v", execution.Output))
+		}
+		return fmt.Sprintf("I executed the workflow from message %d as %s and it completed successfully with output:\n\n```json\n%s\n```",
+			execution.MessageIndex, execution.WorkflowID, outputJSON)
+	default:
+		return fmt.Sprintf("I executed the workflow from message %d as %s and it %s:\n\n%s\n\nPlease fix the workflow so that it executes successfully.",
+			execution.MessageIndex, execution.WorkflowID, execution.Status, execution.Error.Detail)
+	}
+}
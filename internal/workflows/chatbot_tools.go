@@ -0,0 +1,154 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Tools the assistant can call while composing a reply
+const (
+	ToolValidateWorkflow  = "validate_workflow"
+	ToolDryRunWorkflow    = "dry_run_workflow"
+	ToolListDemoEndpoints = "list_demo_endpoints"
+)
+
+// maxToolRounds bounds the tool calls the assistant can make for a single reply
+const maxToolRounds = 8
+
+// DemoEndpoint describes an endpoint of the demo API that generated workflows can call
+type DemoEndpoint struct {
+	Method      string `json:"method"`
+	Endpoint    string `json:"endpoint"`
+	Description string `json:"description"`
+}
+
+// demoEndpoints lists example demo endpoints. The demo handler accepts any method on any path
+// under /demo/ and responds after a random 1-5 second delay.
+var demoEndpoints = []DemoEndpoint{
+	{Method: "GET", Endpoint: "http://localhost:8088/demo/orders/{id}", Description: "Fetch an order"},
+	{Method: "POST", Endpoint: "http://localhost:8088/demo/orders", Description: "Create an order"},
+	{Method: "POST", Endpoint: "http://localhost:8088/demo/payments", Description: "Process a payment"},
+	{Method: "GET", Endpoint: "http://localhost:8088/demo/inventory/{sku}", Description: "Check stock for a product"},
+	{Method: "POST", Endpoint: "http://localhost:8088/demo/shipments", Description: "Schedule a shipment"},
+	{Method: "POST", Endpoint: "http://localhost:8088/demo/notifications", Description: "Send a notification"},
+}
+
+// chatbotTools returns the tool definitions sent to the model
+func chatbotTools() []ToolDefinition {
+	workflowProperty := map[string]interface{}{
+		"type":        "string",
+		"description": "The complete workflow definition in YAML (or JSON)",
+	}
+	return []ToolDefinition{
+		{
+			Name:        ToolValidateWorkflow,
+			Description: "Validate a Serverless Workflow definition against the specification. Returns the validation errors, if any, with their path, line and column.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"workflow": workflowProperty},
+				"required":   []string{"workflow"},
+			},
+		},
+		{
+			Name:        ToolDryRunWorkflow,
+			Description: "Execute a Serverless Workflow definition without side effects. Calls are simulated; returns the output and the status of every task.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"workflow": workflowProperty,
+					"input": map[string]interface{}{
+						"type":        "object",
+						"description": "Optional workflow input document",
+					},
+				},
+				"required": []string{"workflow"},
+			},
+		},
+		{
+			Name:        ToolListDemoEndpoints,
+			Description: "List the demo API endpoints that workflows may call.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+	}
+}
+
+// executeChatbotTools runs the tools requested in the message and returns the user message
+// carrying their results
+func executeChatbotTools(ctx workflow.Context, state *ChatbotState, message ChatMessage) ChatMessage {
+	results := ChatMessage{Role: RoleUser}
+	for _, toolUse := range message.ToolUses() {
+		output, isError := executeChatbotTool(ctx, state, toolUse.ID, toolUse.Name, toolUse.Input)
+		results.Content = append(results.Content, ContentBlock{
+			Type:      BlockToolResult,
+			Text:      output,
+			ToolUseID: toolUse.ID,
+			IsError:   isError,
+		})
+	}
+	return results
+}
+
+// executeChatbotTool runs a single tool and returns its JSON encoded result
+func executeChatbotTool(ctx workflow.Context, state *ChatbotState, toolUseID, name string, input json.RawMessage) (string, bool) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Executing chatbot tool", "tool", name)
+
+	var args struct {
+		Workflow string                 `json:"workflow"`
+		Input    map[string]interface{} `json:"input"`
+	}
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &args); err != nil {
+			return toolResult(map[string]string{"error": "invalid tool input: " + err.Error()}), true
+		}
+	}
+
+	switch name {
+	case ToolValidateWorkflow:
+		if args.Workflow == "" {
+			return toolResult(map[string]string{"error": "workflow is required"}), true
+		}
+		if issues := ValidateWorkflowSource([]byte(args.Workflow)); len(issues) > 0 {
+			return toolResult(map[string]interface{}{"valid": false, "errors": issues}), false
+		}
+		return toolResult(map[string]interface{}{"valid": true}), false
+
+	case ToolDryRunWorkflow:
+		if args.Workflow == "" {
+			return toolResult(map[string]string{"error": "workflow is required"}), true
+		}
+		childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+			WorkflowID:               fmt.Sprintf("chatbot-dry-run-%s-%s", state.ThreadID, toolUseID),
+			WorkflowExecutionTimeout: time.Minute,
+		})
+		var result DryRunResult
+		err := workflow.ExecuteChildWorkflow(childCtx, DryRunServerlessWorkflow, DryRunRequest{
+			Workflow: args.Workflow,
+			Input:    args.Input,
+		}).Get(ctx, &result)
+		if err != nil {
+			return toolResult(map[string]string{"error": "dry run failed: " + err.Error()}), true
+		}
+		return toolResult(result), false
+
+	case ToolListDemoEndpoints:
+		return toolResult(demoEndpoints), false
+
+	default:
+		return toolResult(map[string]string{"error": "unknown tool " + name}), true
+	}
+}
+
+func toolResult(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(encoded)
+}
@@ -0,0 +1,63 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertWorkflow re-serialises a YAML or JSON workflow definition as JSON or YAML after checking
+// it with the sdk-go parser. The source's key order, and for YAML its comments and quoting, are
+// kept unless normalize is set, in which case the definition is written from the parsed model:
+// unknown properties are dropped and the keys of maps such as set or with are sorted. Task lists
+// keep their order either way.
+func ConvertWorkflow(source []byte, to string, normalize bool) ([]byte, error) {
+	if to != FormatJSON && to != FormatYAML {
+		return nil, fmt.Errorf("unsupported format %q, expected json or yaml", to)
+	}
+	workflowDef, err := parser.FromYAMLSource(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow definition: %w", err)
+	}
+
+	fromJSON := strings.HasPrefix(strings.TrimSpace(string(source)), "{")
+	if normalize {
+		source, err = json.Marshal(workflowDef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialise workflow: %w", err)
+		}
+		fromJSON = true
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(source, &root); err != nil || len(root.Content) == 0 {
+		return nil, fmt.Errorf("failed to read workflow definition: %v", err)
+	}
+
+	var converted []byte
+	if to == FormatJSON {
+		converted, err = nodeToJSON(root.Content[0])
+	} else {
+		if fromJSON {
+			// JSON reads as flow style YAML with quoted strings; write block style instead
+			clearNodeStyle(&root)
+		}
+		converted, err = encodeYAMLNode(&root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialise workflow: %w", err)
+	}
+	return []byte(strings.TrimSpace(string(converted)) + "\n"), nil
+}
+
+// clearNodeStyle resets the style of a node tree so it is written in the default block style,
+// quoting only the strings that need it
+func clearNodeStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearNodeStyle(child)
+	}
+}
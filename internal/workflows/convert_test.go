@@ -0,0 +1,87 @@
+package workflows
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertWorkflow(t *testing.T) {
+	source := `# Greets twice
+document:
+  dsl: "1.0.0"
+  namespace: test
+  name: convert
+  version: 1.0.0
+do:
+  - second:
+      set:
+        zeta: "${ .name }"
+        alpha: 1
+  - first:
+      call: http
+      with:
+        method: get
+        endpoint: http://localhost:8088/demo/hello
+`
+	tests := []struct {
+		name      string
+		source    string
+		to        string
+		normalize bool
+		expected  string
+	}{
+		{
+			name:     "YAML to JSON keeps key order",
+			source:   source,
+			to:       FormatJSON,
+			expected: `{"document":{"dsl":"1.0.0","namespace":"test","name":"convert","version":"1.0.0"},"do":[{"second":{"set":{"zeta":"${ .name }","alpha":1}}},{"first":{"call":"http","with":{"method":"get","endpoint":"http://localhost:8088/demo/hello"}}}]}`,
+		},
+		{
+			name:      "normalised JSON",
+			source:    source,
+			to:        FormatJSON,
+			normalize: true,
+			expected:  `{"document":{"dsl":"1.0.0","namespace":"test","name":"convert","version":"1.0.0"},"do":[{"second":{"set":{"alpha":1,"zeta":"${ .name }"}}},{"first":{"call":"http","with":{"method":"get","endpoint":"http://localhost:8088/demo/hello"}}}]}`,
+		},
+		{
+			name:     "YAML to YAML keeps comments and quoting",
+			source:   source,
+			to:       FormatYAML,
+			expected: "# Greets twice\ndocument:\n  dsl: \"1.0.0\"\n",
+		},
+		{
+			name:     "JSON to YAML",
+			source:   `{"document": {"dsl": "1.0.0", "namespace": "test", "name": "convert", "version": "1.0.0"}, "do": [{"greet": {"set": {"greeting": "hello", "count": "1"}}}]}`,
+			to:       FormatYAML,
+			expected: "document:\n  dsl: 1.0.0\n  namespace: test\n  name: convert\n  version: 1.0.0\ndo:\n  - greet:\n      set:\n        greeting: hello\n        count: \"1\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := ConvertWorkflow([]byte(tt.source), tt.to, tt.normalize)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			output := string(converted)
+			if tt.to == FormatJSON {
+				output = strings.Join(strings.Fields(output), "")
+				expected := strings.Join(strings.Fields(tt.expected), "")
+				if output != expected {
+					t.Errorf("Expected\n%s\ngot\n%s", expected, output)
+				}
+				return
+			}
+			if !strings.HasPrefix(output, tt.expected) {
+				t.Errorf("Expected output starting with\n%s\ngot\n%s", tt.expected, output)
+			}
+		})
+	}
+
+	if _, err := ConvertWorkflow([]byte("document: {}\n"), FormatJSON, false); err == nil {
+		t.Error("Expected an error for an invalid definition")
+	}
+	if _, err := ConvertWorkflow([]byte(source), "toml", false); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
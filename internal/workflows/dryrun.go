@@ -0,0 +1,94 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"go.temporal.io/sdk/workflow"
+)
+
+// dryRunKey marks a workflow or activity context whose calls must not reach external systems
+const dryRunKey contextKey = "dryRun"
+
+// DryRunRequest is the input of DryRunServerlessWorkflow
+type DryRunRequest struct {
+	Workflow string                 `json:"workflow"` // YAML or JSON definition
+	Input    map[string]interface{} `json:"input,omitempty"`
+}
+
+// DryRunResult reports how a definition would execute
+type DryRunResult struct {
+	Valid  bool                      `json:"valid"`
+	Error  string                    `json:"error,omitempty"`
+	Output map[string]interface{}    `json:"output,omitempty"`
+	Tasks  map[string]*TaskExecution `json:"tasks,omitempty"`
+}
+
+// DryRunServerlessWorkflow executes a definition without side effects: call tasks are not sent and
+// return a simulated response, while control flow, set tasks and expressions run as usual.
+// Definition and execution errors are reported in the result rather than failing the workflow.
+func DryRunServerlessWorkflow(ctx workflow.Context, request DryRunRequest) (*DryRunResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("DryRunServerlessWorkflow workflow started")
+
+	workflowDef, err := parser.FromYAMLSource([]byte(request.Workflow))
+	if err != nil {
+		return &DryRunResult{Valid: false, Error: err.Error()}, nil
+	}
+
+	workflowState := &WorkflowState{
+		State: make(map[string]interface{}),
+		Tasks: make(map[string]*TaskExecution),
+	}
+
+	output, err := executeWorkflowDefinitionWithState(workflow.WithValue(ctx, dryRunKey, true), workflowDef, workflowState, request.Input)
+	result := &DryRunResult{Valid: true, Output: output, Tasks: workflowState.Tasks}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// isDryRun reports whether the workflow context executes a dry run
+func isDryRun(ctx workflow.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey).(bool)
+	return dryRun
+}
+
+// isDryRunActivity reports whether the activity executes on behalf of a dry run
+func isDryRunActivity(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey).(bool)
+	return dryRun
+}
+
+// dryRunCallResult returns the simulated response of a call task, or nil for other tasks
+func dryRunCallResult(taskItem *model.TaskItem) interface{} {
+	switch task := taskItem.Task.(type) {
+	case *model.CallHTTP:
+		return dryRunHTTPResult(task)
+	case *model.CallOpenAPI, *model.CallGRPC, *model.CallAsyncAPI:
+		return map[string]interface{}{"dry_run": true, "call": taskTypeName(taskItem)}
+	default:
+		return nil
+	}
+}
+
+// dryRunHTTPResult returns the simulated response of an HTTP call
+func dryRunHTTPResult(httpTask *model.CallHTTP) HTTPCallResult {
+	return HTTPCallResult{
+		Status: 200,
+		Body: map[string]interface{}{
+			"dry_run":  true,
+			"method":   httpTask.With.Method,
+			"endpoint": httpTask.With.Endpoint.String(),
+		},
+		Headers: map[string]string{},
+	}
+}
+
+// dryRunFunctionResult returns the simulated result of a native function call
+func dryRunFunctionResult(name string) map[string]interface{} {
+	return map[string]interface{}{"dry_run": true, "call": fmt.Sprintf("call:%s", name)}
+}
@@ -0,0 +1,105 @@
+package workflows
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// WorkflowEventsQuery returns up to a limit of the execution events recorded after a given event
+// ID; a limit of 0 or above WorkflowEventsPageSize returns a full page
+const WorkflowEventsQuery = "get-workflow-events"
+
+// WorkflowEventsPageSize is the most events WorkflowEventsQuery returns at once
+const WorkflowEventsPageSize = 100
+
+// EventOutputLimit is the size in bytes of JSON output an event carries. Larger task outputs are
+// left out of the event, which is marked OutputTruncated; the task in the workflow state keeps it.
+const EventOutputLimit = 4096
+
+// Execution event types. Workflow status changes are reported as "workflow.<status>",
+// e.g. "workflow.suspended" or "workflow.completed".
+const (
+	EventTaskStarted   = "task.started"
+	EventTaskCompleted = "task.completed"
+	EventTaskSkipped   = "task.skipped"
+	EventTaskFaulted   = "task.faulted"
+)
+
+// ExecutionEvent is a task lifecycle or workflow status change, numbered in recording order
+type ExecutionEvent struct {
+	ID     int64          `json:"id"`
+	Type   string         `json:"type"`
+	Time   time.Time      `json:"time"`
+	Task   string         `json:"task,omitempty"` // task reference
+	Name   string         `json:"name,omitempty"`
+	Status string         `json:"status"`
+	Output interface{}    `json:"output,omitempty"`
+	Error  *WorkflowError `json:"error,omitempty"`
+
+	OutputTruncated bool `json:"output_truncated,omitempty"`
+}
+
+// WorkflowStatusEvent returns the event type reported when the workflow enters a status
+func WorkflowStatusEvent(status string) string {
+	return "workflow." + status
+}
+
+// IsTerminalEvent reports whether no further events follow the event
+func IsTerminalEvent(event ExecutionEvent) bool {
+	switch event.Type {
+	case WorkflowStatusEvent(WorkflowStatusCompleted), WorkflowStatusEvent(WorkflowStatusFailed), WorkflowStatusEvent(WorkflowStatusCancelled):
+		return true
+	default:
+		return false
+	}
+}
+
+// setEventsQueryHandler exposes the recorded events through WorkflowEventsQuery
+func setEventsQueryHandler(ctx workflow.Context, state *WorkflowState) error {
+	return workflow.SetQueryHandler(ctx, WorkflowEventsQuery, func(afterID int64, limit int) ([]ExecutionEvent, error) {
+		return state.eventsAfter(afterID, limit), nil
+	})
+}
+
+// setStatus changes the workflow status and records the change
+func (s *WorkflowState) setStatus(ctx workflow.Context, status string) {
+	if s.Status == status && len(s.Events) > 0 {
+		return
+	}
+	s.Status = status
+	s.recordEvent(ctx, ExecutionEvent{Type: WorkflowStatusEvent(status), Status: status})
+}
+
+// recordEvent appends an event with the next sequence number, leaving out an output larger than
+// EventOutputLimit
+func (s *WorkflowState) recordEvent(ctx workflow.Context, event ExecutionEvent) {
+	event.ID = int64(len(s.Events) + 1)
+	event.Time = workflow.Now(ctx)
+	if event.Output != nil {
+		if encoded, err := json.Marshal(event.Output); err != nil || len(encoded) > EventOutputLimit {
+			event.Output = nil
+			event.OutputTruncated = true
+		}
+	}
+	s.Events = append(s.Events, event)
+}
+
+// eventsAfter returns up to limit events recorded after the given event ID
+func (s *WorkflowState) eventsAfter(afterID int64, limit int) []ExecutionEvent {
+	if afterID < 0 {
+		afterID = 0
+	}
+	if afterID >= int64(len(s.Events)) {
+		return []ExecutionEvent{}
+	}
+	if limit <= 0 || limit > WorkflowEventsPageSize {
+		limit = WorkflowEventsPageSize
+	}
+	events := s.Events[afterID:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
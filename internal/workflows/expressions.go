@@ -0,0 +1,350 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// RuntimeName is exposed to expressions as $runtime.name
+	RuntimeName = "serverless-workflow-backend"
+	// RuntimeVersion is exposed to expressions as $runtime.version
+	RuntimeVersion = "0.1.0"
+)
+
+// SecretEnvPrefix prefixes the worker environment variables exposed as $secrets: the secret
+// `apiKey` is read from SW_SECRET_apiKey. Other variables are never visible to workflows.
+const SecretEnvPrefix = "SW_SECRET_"
+
+// InvalidExpressionErrorType is returned for expressions jq cannot parse or compile; it is not
+// retried, so errors wrapping it must go through wrapExpressionError
+const InvalidExpressionErrorType = "InvalidExpression"
+
+const (
+	// runtimeArgumentsKey carries the workflow-level *RuntimeArguments through the workflow context
+	runtimeArgumentsKey contextKey = "runtimeArguments"
+	// taskDescriptorKey carries the *TaskDescriptor of the task being executed
+	taskDescriptorKey contextKey = "taskDescriptor"
+	// taskScopeKey carries the JSON pointer of the task list being executed, e.g. "/do/0/processOrder/do"
+	taskScopeKey contextKey = "taskScope"
+)
+
+// WorkflowDescriptor is exposed to expressions as $workflow
+type WorkflowDescriptor struct {
+	ID         string                 `json:"id"`
+	Definition map[string]interface{} `json:"definition,omitempty"`
+	Input      interface{}            `json:"input"`
+	StartedAt  time.Time              `json:"startedAt"`
+}
+
+// TaskDescriptor is exposed to expressions as $task
+type TaskDescriptor struct {
+	Name      string    `json:"name"`
+	Reference string    `json:"reference"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// RuntimeArguments are the spec runtime arguments available to every expression
+type RuntimeArguments struct {
+	Context  map[string]interface{} `json:"context"`
+	Input    interface{}            `json:"input"`
+	Output   interface{}            `json:"output,omitempty"`
+	Workflow *WorkflowDescriptor    `json:"workflow,omitempty"`
+	Task     *TaskDescriptor        `json:"task,omitempty"`
+	// Secrets lists the names declared in use.secrets; values are resolved from the worker's
+	// SecretEnvPrefix environment variables inside the activity so they never reach workflow history
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// EvaluateValueRequest represents a request to evaluate every expression inside a value
+type EvaluateValueRequest struct {
+	Value     interface{}            `json:"value"`
+	Context   map[string]interface{} `json:"context"`
+	Arguments *RuntimeArguments      `json:"arguments,omitempty"`
+}
+
+// withRuntimeArguments initialises the runtime arguments for a workflow execution
+func withRuntimeArguments(ctx workflow.Context, workflowDef *model.Workflow, input map[string]interface{}) (workflow.Context, error) {
+	definition, err := workflowDef.AsMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe workflow definition: %w", err)
+	}
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+
+	args := &RuntimeArguments{
+		Context: map[string]interface{}{},
+		Workflow: &WorkflowDescriptor{
+			ID:         workflow.GetInfo(ctx).WorkflowExecution.ID,
+			Definition: definition,
+			Input:      input,
+			StartedAt:  workflow.Now(ctx),
+		},
+	}
+	if workflowDef.Use != nil {
+		args.Secrets = workflowDef.Use.Secrets
+	}
+
+	ctx = workflow.WithValue(ctx, runtimeArgumentsKey, args)
+	return workflow.WithValue(ctx, taskScopeKey, "/do"), nil
+}
+
+// withTaskScope sets the JSON pointer of the task list that nested tasks are executed from
+func withTaskScope(ctx workflow.Context, scope string) workflow.Context {
+	return workflow.WithValue(ctx, taskScopeKey, scope)
+}
+
+// withTaskDescriptor describes the task at the given index of the current task list
+func withTaskDescriptor(ctx workflow.Context, index int, taskItem *model.TaskItem) (workflow.Context, *TaskDescriptor) {
+	descriptor := &TaskDescriptor{
+		Name:      taskItem.Key,
+		Reference: fmt.Sprintf("%s/%d/%s", currentTaskScope(ctx), index, taskItem.Key),
+		StartedAt: workflow.Now(ctx),
+	}
+	return workflow.WithValue(ctx, taskDescriptorKey, descriptor), descriptor
+}
+
+// currentTaskScope returns the JSON pointer of the task list being executed
+func currentTaskScope(ctx workflow.Context) string {
+	if scope, ok := ctx.Value(taskScopeKey).(string); ok {
+		return scope
+	}
+	return "/do"
+}
+
+// currentTaskReference returns the JSON pointer of the task being executed
+func currentTaskReference(ctx workflow.Context) string {
+	if descriptor, ok := ctx.Value(taskDescriptorKey).(*TaskDescriptor); ok {
+		return descriptor.Reference
+	}
+	return currentTaskScope(ctx)
+}
+
+// expressionArguments builds the runtime arguments for the expressions in value, evaluated against
+// the given task input. The arguments are recorded in history with every activity, so the workflow
+// definition is only sent when one of the expressions references $workflow.
+func expressionArguments(ctx workflow.Context, input interface{}, value interface{}) *RuntimeArguments {
+	args := &RuntimeArguments{Context: map[string]interface{}{}}
+	if workflowArgs, ok := ctx.Value(runtimeArgumentsKey).(*RuntimeArguments); ok {
+		*args = *workflowArgs
+		if args.Workflow != nil && !referencesWorkflow(value) {
+			descriptor := *args.Workflow
+			descriptor.Definition = nil
+			args.Workflow = &descriptor
+		}
+	}
+	if descriptor, ok := ctx.Value(taskDescriptorKey).(*TaskDescriptor); ok {
+		args.Task = descriptor
+	}
+	args.Input = input
+	return args
+}
+
+// referencesWorkflow reports whether any expression in value may read $workflow
+func referencesWorkflow(value interface{}) bool {
+	if expression, ok := value.(string); ok {
+		return strings.Contains(expression, "$workflow")
+	}
+	encoded, err := json.Marshal(value)
+	return err != nil || strings.Contains(string(encoded), "$workflow")
+}
+
+// exportTaskOutput applies the task's export.as to the workflow context ($context)
+func exportTaskOutput(ctx workflow.Context, taskItem *model.TaskItem, state map[string]interface{}, output interface{}) error {
+	base := taskItem.GetBase()
+	if base == nil || base.Export == nil || base.Export.As == nil {
+		return nil
+	}
+	workflowArgs, ok := ctx.Value(runtimeArgumentsKey).(*RuntimeArguments)
+	if !ok {
+		return nil
+	}
+
+	exportAs := base.Export.As.AsStringOrMap()
+	args := expressionArguments(ctx, state, exportAs)
+	args.Output = output
+
+	var exported interface{}
+	err := workflow.ExecuteActivity(ctx, EvaluateValueActivity, EvaluateValueRequest{
+		Value:     exportAs,
+		Context:   toExpressionInput(output),
+		Arguments: args,
+	}).Get(ctx, &exported)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate export of task '%s': %w", taskItem.Key, err)
+	}
+
+	exportedContext, ok := exported.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("export of task '%s' must produce an object, got %T", taskItem.Key, exported)
+	}
+	workflowArgs.Context = exportedContext
+	return nil
+}
+
+// toExpressionInput wraps non-object outputs so they can be passed as expression input
+func toExpressionInput(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+	normalized, err := toJSONValue(value)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	if m, ok := normalized.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{"value": normalized}
+}
+
+// EvaluateValueActivity evaluates every runtime expression found in a value and returns the result
+func EvaluateValueActivity(ctx context.Context, req EvaluateValueRequest) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Evaluating value")
+
+	return evaluateValue(req.Value, req.Context, req.Arguments)
+}
+
+// evaluateValue recursively evaluates expressions in maps, slices and strings
+func evaluateValue(value interface{}, input interface{}, args *RuntimeArguments) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			evaluated, err := evaluateValue(item, input, args)
+			if err != nil {
+				return nil, wrapExpressionError(err, "%s", key)
+			}
+			result[key] = evaluated
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			evaluated, err := evaluateValue(item, input, args)
+			if err != nil {
+				return nil, wrapExpressionError(err, "[%d]", i)
+			}
+			result[i] = evaluated
+		}
+		return result, nil
+	case string:
+		if !model.IsStrictExpr(v) {
+			return v, nil
+		}
+		return evaluateExpression(v, input, args)
+	default:
+		return v, nil
+	}
+}
+
+// evaluateExpression evaluates a jq runtime expression against the input with the runtime
+// arguments bound as $context, $input, $output, $workflow, $task, $runtime and $secrets.
+func evaluateExpression(expression string, input interface{}, args *RuntimeArguments) (interface{}, error) {
+	names, values, err := expressionVariables(input, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := gojq.Parse(model.SanitizeExpr(expression))
+	var code *gojq.Code
+	if err == nil {
+		code, err = gojq.Compile(query, gojq.WithVariables(names))
+	}
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError("failed to compile expression", InvalidExpressionErrorType, err)
+	}
+
+	normalizedInput, err := toJSONValue(input)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := code.Run(normalizedInput, values...)
+	result, ok := iter.Next()
+	if !ok {
+		return nil, nil
+	}
+	if err, isErr := result.(error); isErr {
+		return nil, fmt.Errorf("expression evaluation error: %w", err)
+	}
+	return result, nil
+}
+
+// wrapExpressionError adds context to an expression error. Temporal decides whether to retry an
+// activity from the top-level error only, so an invalid expression is re-wrapped as a non-retryable
+// application error rather than with %w.
+func wrapExpressionError(err error, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	var invalid *temporal.ApplicationError
+	if errors.As(err, &invalid) && invalid.Type() == InvalidExpressionErrorType {
+		return temporal.NewNonRetryableApplicationError(message+": "+invalid.Message(), InvalidExpressionErrorType, errors.Unwrap(invalid))
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// expressionVariables returns the jq variable names and values for the runtime arguments
+func expressionVariables(input interface{}, args *RuntimeArguments) ([]string, []interface{}, error) {
+	if args == nil {
+		args = &RuntimeArguments{Input: input}
+	}
+
+	secrets := map[string]interface{}{}
+	for _, name := range args.Secrets {
+		if value, ok := os.LookupEnv(SecretEnvPrefix + name); ok {
+			secrets[name] = value
+		}
+	}
+
+	variables := map[string]interface{}{
+		"$context":  args.Context,
+		"$input":    args.Input,
+		"$output":   args.Output,
+		"$workflow": args.Workflow,
+		"$task":     args.Task,
+		"$runtime":  map[string]interface{}{"name": RuntimeName, "version": RuntimeVersion},
+		"$secrets":  secrets,
+	}
+
+	names := make([]string, 0, len(variables))
+	values := make([]interface{}, 0, len(variables))
+	for name, value := range variables {
+		normalized, err := toJSONValue(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		names = append(names, name)
+		values = append(values, normalized)
+	}
+	return names, values, nil
+}
+
+// toJSONValue converts a value to the plain JSON types jq operates on
+func toJSONValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, float64, int:
+		return v, nil
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(bytes, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
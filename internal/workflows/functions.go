@@ -0,0 +1,181 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+type contextKey string
+
+// functionCatalogKey carries the workflow's `use.functions` through the workflow context
+const functionCatalogKey contextKey = "functionCatalog"
+
+// functionCallsKey carries the catalog functions being called, outermost first, to detect recursion
+const functionCallsKey contextKey = "functionCalls"
+
+// UnknownFunctionErrorType is returned when `call: <name>` names neither a catalog function nor a
+// native function registered on the worker
+const UnknownFunctionErrorType = "UnknownFunction"
+
+// NativeFunction is a Go function that workflow definitions can invoke with `call: <name>`. It
+// receives the task's evaluated `with` arguments.
+type NativeFunction func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+var (
+	nativeFunctionsMu sync.RWMutex
+	nativeFunctions   = map[string]NativeFunction{}
+)
+
+// RegisterFunction registers a native function that workflow definitions can invoke with
+// `call: <name>`. Calls run in CallFunctionActivity, which resolves the name on the worker, so
+// every worker of the task queue should register the same functions.
+func RegisterFunction(name string, fn NativeFunction) {
+	nativeFunctionsMu.Lock()
+	defer nativeFunctionsMu.Unlock()
+	nativeFunctions[name] = fn
+}
+
+// RegisteredFunctions returns the names of all registered native functions
+func RegisteredFunctions() []string {
+	nativeFunctionsMu.RLock()
+	defer nativeFunctionsMu.RUnlock()
+
+	names := make([]string, 0, len(nativeFunctions))
+	for name := range nativeFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupFunction returns the native function registered under name
+func lookupFunction(name string) (NativeFunction, bool) {
+	nativeFunctionsMu.RLock()
+	defer nativeFunctionsMu.RUnlock()
+	fn, ok := nativeFunctions[name]
+	return fn, ok
+}
+
+// registerNativeFunctions registers the activity that runs native functions
+func registerNativeFunctions(w worker.Worker) {
+	w.RegisterActivity(CallFunctionActivity)
+}
+
+// CallFunctionRequest is a call to a native function
+type CallFunctionRequest struct {
+	Function  string                 `json:"function"`
+	Arguments map[string]interface{} `json:"arguments"`
+	DryRun    bool                   `json:"dry_run,omitempty"`
+}
+
+// CallFunctionActivity runs a native function. The name is resolved here rather than in workflow
+// code, whose decisions must not depend on what the worker happens to have registered.
+func CallFunctionActivity(ctx context.Context, req CallFunctionRequest) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Calling native function", "function", req.Function)
+
+	fn, ok := lookupFunction(req.Function)
+	if !ok {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unknown function '%s'", req.Function), UnknownFunctionErrorType, nil)
+	}
+	if req.DryRun {
+		return dryRunFunctionResult(req.Function), nil
+	}
+	return fn(ctx, req.Arguments)
+}
+
+// withFunctionCatalog makes the workflow's `use.functions` available to nested task execution
+func withFunctionCatalog(ctx workflow.Context, workflowDef *model.Workflow) workflow.Context {
+	if workflowDef.Use == nil || len(workflowDef.Use.Functions) == 0 {
+		return ctx
+	}
+	return workflow.WithValue(ctx, functionCatalogKey, workflowDef.Use.Functions)
+}
+
+// executeFunctionCall resolves `call: <name>` against the workflow's function catalog; any other
+// name is called as a native function
+func executeFunctionCall(ctx workflow.Context, taskName string, callTask *model.CallFunction, state map[string]interface{}) (interface{}, error) {
+	logger := workflow.GetLogger(ctx)
+
+	args, err := evaluateFunctionArguments(ctx, callTask, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if catalog, ok := ctx.Value(functionCatalogKey).(model.NamedTaskMap); ok {
+		if function, exists := catalog[callTask.Call]; exists {
+			logger.Info("Executing catalog function", "task", taskName, "function", callTask.Call)
+			return executeCatalogFunction(ctx, callTask.Call, function, state, args)
+		}
+	}
+
+	logger.Info("Executing native function", "task", taskName, "function", callTask.Call)
+	var result interface{}
+	err = workflow.ExecuteActivity(ctx, CallFunctionActivity, CallFunctionRequest{
+		Function:  callTask.Call,
+		Arguments: args,
+		DryRun:    isDryRun(ctx),
+	}).Get(ctx, &result)
+	if err != nil {
+		return nil, fmt.Errorf("function '%s' failed: %w", callTask.Call, err)
+	}
+	return result, nil
+}
+
+// executeCatalogFunction runs a `use.functions` entry as a task nested in the calling task, with
+// the caller's state merged with the `with` arguments as input. A function that calls itself,
+// directly or through other functions, is rejected.
+func executeCatalogFunction(ctx workflow.Context, name string, function model.Task, state map[string]interface{}, args map[string]interface{}) (interface{}, error) {
+	calls, _ := ctx.Value(functionCallsKey).([]string)
+	calls = append(append([]string{}, calls...), name)
+	for _, call := range calls[:len(calls)-1] {
+		if call == name {
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("function '%s' calls itself: %s", name, strings.Join(calls, " -> ")), ErrorTypeValidation, nil)
+		}
+	}
+	ctx = workflow.WithValue(ctx, functionCallsKey, calls)
+
+	functionState := make(map[string]interface{}, len(state)+len(args))
+	for key, value := range state {
+		functionState[key] = value
+	}
+	for key, value := range args {
+		functionState[key] = value
+	}
+
+	ctx = withTaskScope(ctx, currentTaskReference(ctx)+"/function")
+	result, _, err := runTask(ctx, 0, &model.TaskItem{Key: name, Task: function}, functionState)
+	if err != nil {
+		return nil, fmt.Errorf("function '%s' failed: %w", name, err)
+	}
+	return result, nil
+}
+
+// evaluateFunctionArguments evaluates the expressions in the task's `with` arguments against its input
+func evaluateFunctionArguments(ctx workflow.Context, callTask *model.CallFunction, state map[string]interface{}) (map[string]interface{}, error) {
+	if len(callTask.With) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	var args map[string]interface{}
+	err := workflow.ExecuteActivity(ctx, EvaluateValueActivity, EvaluateValueRequest{
+		Value:     callTask.With,
+		Context:   state,
+		Arguments: expressionArguments(ctx, state, callTask.With),
+	}).Get(ctx, &args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate arguments of function '%s': %w", callTask.Call, err)
+	}
+	return args, nil
+}
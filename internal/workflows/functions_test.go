@@ -0,0 +1,200 @@
+package workflows
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestFunctionCalls(t *testing.T) {
+	greet := func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{"greeting": "Hello, " + args["name"].(string)}, nil
+	}
+	RegisterFunction("greet", greet)
+	t.Cleanup(func() { unregisterTestFunction("greet") })
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(EvaluateExpressionActivity)
+	env.RegisterActivity(CallFunctionActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: function-calls
+  version: 1.0.0
+use:
+  functions:
+    markProcessed:
+      set:
+        processed: true
+    skipped:
+      if: ${ .user == "nobody" }
+      set:
+        ran: true
+do:
+  - prepare:
+      set:
+        user: world
+  - callCatalogFunction:
+      call: markProcessed
+  - callSkippedFunction:
+      call: skipped
+  - callNativeFunction:
+      call: greet
+      with:
+        name: ${ .user }
+`, nil)
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Expected workflow to complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+
+	catalogResult, _ := result["callCatalogFunction"].(map[string]interface{})
+	if catalogResult["processed"] != true {
+		t.Errorf("Expected catalog function to set processed, got %v", result["callCatalogFunction"])
+	}
+	if result["callSkippedFunction"] != nil {
+		t.Errorf("Expected the if condition of the catalog function to skip it, got %v", result["callSkippedFunction"])
+	}
+	nativeResult, _ := result["callNativeFunction"].(map[string]interface{})
+	if nativeResult["greeting"] != "Hello, world" {
+		t.Errorf("Expected native function greeting, got %v", result["callNativeFunction"])
+	}
+}
+
+// unregisterTestFunction removes a native function registered by a test
+func unregisterTestFunction(name string) {
+	nativeFunctionsMu.Lock()
+	defer nativeFunctionsMu.Unlock()
+	delete(nativeFunctions, name)
+}
+
+func TestUnknownFunctionCallFails(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(CallFunctionActivity)
+
+	attempts := 0
+	env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args converter.EncodedValues) {
+		attempts++
+	})
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: unknown-function
+  version: 1.0.0
+do:
+  - callMissing:
+      call: doesNotExist
+`, nil)
+
+	err := env.GetWorkflowError()
+	if err == nil || !strings.Contains(err.Error(), "unknown function 'doesNotExist'") {
+		t.Fatalf("Expected workflow to fail for an unknown function, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected the unknown function not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestCatalogFunctionRecords(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: catalog-history
+  version: 1.0.0
+use:
+  functions:
+    markProcessed:
+      set:
+        processed: true
+      export:
+        as: '${ { marked: true } }'
+do:
+  - mark:
+      call: markProcessed
+  - check:
+      set:
+        marked: ${ $context.marked }
+`, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	var result map[string]interface{}
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if check, _ := result["check"].(map[string]interface{}); check["marked"] != true {
+		t.Errorf("Expected the export of the catalog function to apply, got %v", result["check"])
+	}
+
+	encoded, err := env.QueryWorkflow("get-workflow-state")
+	if err != nil {
+		t.Fatalf("Failed to query workflow state: %v", err)
+	}
+	var state WorkflowState
+	if err := encoded.Get(&state); err != nil {
+		t.Fatalf("Failed to decode workflow state: %v", err)
+	}
+	execution := state.Tasks["/do/0/mark/function/0/markProcessed"]
+	if execution == nil || execution.Status != TaskStatusCompleted || execution.Parent != "/do/0/mark" {
+		t.Errorf("Expected the function body to be recorded under the calling task, got %+v", execution)
+	}
+}
+
+func TestRecursiveCatalogFunctionFails(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: recursive-functions
+  version: 1.0.0
+use:
+  functions:
+    ping:
+      call: pong
+    pong:
+      call: ping
+do:
+  - start:
+      call: ping
+`, nil)
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Expected workflow to complete")
+	}
+	err := env.GetWorkflowError()
+	if err == nil || !strings.Contains(err.Error(), "function 'ping' calls itself: ping -> pong -> ping") {
+		t.Fatalf("Expected the recursion to fail the workflow, got %v", err)
+	}
+}
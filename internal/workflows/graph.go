@@ -0,0 +1,314 @@
+package workflows
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+)
+
+// Graph formats
+const (
+	GraphFormatJSON    = "json"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatDOT     = "dot"
+)
+
+// Node types besides the task types
+const (
+	GraphNodeStart = "start"
+	GraphNodeEnd   = "end"
+	GraphNodeEntry = "entry"
+	GraphNodeExit  = "exit"
+)
+
+// Graph node IDs of the workflow start and end; task nodes use their task reference
+const (
+	graphStartID = "start"
+	graphEndID   = "end"
+)
+
+// WorkflowGraph is the control flow of a workflow definition. Task nodes are identified by their
+// task reference, e.g. /do/0/fetch, the same as in the execution history.
+type WorkflowGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a task or a flow marker. Composite tasks (do, for, fork, try) contain their
+// children and are entered and left through their entry and exit nodes.
+type GraphNode struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"` // task type as in the execution history, or start, end, entry or exit
+	Label  string `json:"label"`
+	Parent string `json:"parent,omitempty"` // ID of the enclosing composite task
+}
+
+// GraphEdge is a transition between two nodes
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Label  string `json:"label,omitempty"` // switch case, catch or branch name
+}
+
+// BuildWorkflowGraph builds the graph of a workflow from its task list, following `then`
+// transitions and nesting the tasks of do, for, fork and try
+func BuildWorkflowGraph(workflowDef *model.Workflow) *WorkflowGraph {
+	g := &WorkflowGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	g.addNode(graphStartID, GraphNodeStart, "start", "")
+	g.addNode(graphEndID, GraphNodeEnd, "end", "")
+
+	var tasks model.TaskList
+	if workflowDef.Do != nil {
+		tasks = *workflowDef.Do
+	}
+	g.addTaskList(tasks, "/do", "", graphStartID, graphEndID, "")
+	return g
+}
+
+func (g *WorkflowGraph) addNode(id, nodeType, label, parent string) {
+	g.Nodes = append(g.Nodes, GraphNode{ID: id, Type: nodeType, Label: label, Parent: parent})
+}
+
+func (g *WorkflowGraph) addEdge(source, target, label string) {
+	g.Edges = append(g.Edges, GraphEdge{Source: source, Target: target, Label: label})
+}
+
+// addTaskList adds a task list flowing from the entry node to the exit node
+func (g *WorkflowGraph) addTaskList(tasks model.TaskList, scope, parent, entry, exit, entryLabel string) {
+	if len(tasks) == 0 {
+		g.addEdge(entry, exit, entryLabel)
+		return
+	}
+
+	references := make([]string, len(tasks))
+	positions := map[string]int{}
+	for i, task := range tasks {
+		references[i] = fmt.Sprintf("%s/%d/%s", scope, i, task.Key)
+		if _, exists := positions[task.Key]; !exists {
+			positions[task.Key] = i
+		}
+	}
+	// target resolves a flow directive to the node it leads to
+	target := func(i int, directive string) string {
+		switch model.FlowDirectiveType(directive) {
+		case "", model.FlowDirectiveContinue:
+			if i+1 < len(tasks) {
+				return graphEntry(tasks[i+1], references[i+1])
+			}
+			return exit
+		case model.FlowDirectiveExit:
+			return exit
+		case model.FlowDirectiveEnd:
+			return graphEndID
+		}
+		if position, ok := positions[directive]; ok {
+			return graphEntry(tasks[position], references[position])
+		}
+		return ""
+	}
+
+	g.addEdge(entry, graphEntry(tasks[0], references[0]), entryLabel)
+	for i, task := range tasks {
+		g.addTask(task, references[i], parent)
+		from := graphExit(task, references[i])
+
+		switch t := task.Task.(type) {
+		case *model.RaiseTask:
+			// Raising an error leaves the flow
+		case *model.SwitchTask:
+			for _, item := range t.Switch {
+				for _, name := range sortedSwitchCases(item) {
+					directive := ""
+					if item[name].Then != nil {
+						directive = item[name].Then.Value
+					}
+					if to := target(i, directive); to != "" {
+						g.addEdge(from, to, name)
+					}
+				}
+			}
+			if !hasDefaultCase(t) {
+				g.addEdge(from, target(i, ""), "default")
+			}
+		default:
+			directive := ""
+			if base := task.GetBase(); base != nil && base.Then != nil {
+				directive = base.Then.Value
+			}
+			if to := target(i, directive); to != "" {
+				g.addEdge(from, to, "")
+			}
+		}
+	}
+}
+
+// addTask adds the node of a task and, for composite tasks, its entry and exit nodes and children
+func (g *WorkflowGraph) addTask(task *model.TaskItem, reference, parent string) {
+	g.addNode(reference, taskTypeName(task), task.Key, parent)
+	if !isCompositeTask(task) {
+		return
+	}
+
+	entry, exit := reference+"#entry", reference+"#exit"
+	g.addNode(entry, GraphNodeEntry, "", reference)
+	g.addNode(exit, GraphNodeExit, "", reference)
+
+	switch t := task.Task.(type) {
+	case *model.DoTask:
+		g.addTaskList(taskListOrEmpty(t.Do), reference+"/do", reference, entry, exit, "")
+	case *model.ForTask:
+		g.addTaskList(taskListOrEmpty(t.Do), reference+"/do", reference, entry, exit, "")
+	case *model.ForkTask:
+		branches := taskListOrEmpty(t.Fork.Branches)
+		for i, branch := range branches {
+			branchReference := fmt.Sprintf("%s/fork/branches/%d/%s", reference, i, branch.Key)
+			g.addTask(branch, branchReference, reference)
+			g.addEdge(entry, graphEntry(branch, branchReference), "")
+			g.addEdge(graphExit(branch, branchReference), exit, "")
+		}
+	case *model.TryTask:
+		g.addTaskList(taskListOrEmpty(t.Try), reference+"/try", reference, entry, exit, "")
+		if t.Catch != nil && t.Catch.Do != nil {
+			g.addTaskList(*t.Catch.Do, reference+"/catch/do", reference, entry, exit, "catch")
+		}
+	}
+}
+
+// isCompositeTask reports whether a task contains other tasks
+func isCompositeTask(task *model.TaskItem) bool {
+	switch task.Task.(type) {
+	case *model.DoTask, *model.ForTask, *model.ForkTask, *model.TryTask:
+		return true
+	}
+	return false
+}
+
+// graphEntry returns the node flow enters a task through
+func graphEntry(task *model.TaskItem, reference string) string {
+	if isCompositeTask(task) {
+		return reference + "#entry"
+	}
+	return reference
+}
+
+// graphExit returns the node flow leaves a task from
+func graphExit(task *model.TaskItem, reference string) string {
+	if isCompositeTask(task) {
+		return reference + "#exit"
+	}
+	return reference
+}
+
+func taskListOrEmpty(tasks *model.TaskList) model.TaskList {
+	if tasks == nil {
+		return nil
+	}
+	return *tasks
+}
+
+// children groups the nodes by parent, keeping their order
+func (g *WorkflowGraph) children() map[string][]GraphNode {
+	children := map[string][]GraphNode{}
+	for _, node := range g.Nodes {
+		children[node.Parent] = append(children[node.Parent], node)
+	}
+	return children
+}
+
+var mermaidUnsafePattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// Mermaid renders the graph as a Mermaid flowchart; composite tasks become subgraphs
+func (g *WorkflowGraph) Mermaid() string {
+	ids := map[string]string{}
+	used := map[string]bool{}
+	for _, node := range g.Nodes {
+		id := strings.Trim(mermaidUnsafePattern.ReplaceAllString(node.ID, "_"), "_")
+		if id == "end" {
+			// end is a Mermaid keyword
+			id = "end_"
+		}
+		for base, n := id, 2; used[id]; n++ {
+			id = base + "_" + strconv.Itoa(n)
+		}
+		used[id] = true
+		ids[node.ID] = id
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	children := g.children()
+	var write func(parent string, indent string)
+	write = func(parent string, indent string) {
+		for _, node := range children[parent] {
+			id := ids[node.ID]
+			switch {
+			case len(children[node.ID]) > 0:
+				fmt.Fprintf(&b, "%ssubgraph %s[\"%s\"]\n", indent, id, mermaidText(node.Label+" ("+node.Type+")"))
+				write(node.ID, indent+"  ")
+				fmt.Fprintf(&b, "%send\n", indent)
+			case node.Type == GraphNodeStart || node.Type == GraphNodeEnd:
+				fmt.Fprintf(&b, "%s%s((\"%s\"))\n", indent, id, node.Label)
+			case node.Type == GraphNodeEntry || node.Type == GraphNodeExit:
+				fmt.Fprintf(&b, "%s%s((\" \"))\n", indent, id)
+			case node.Type == "switch":
+				fmt.Fprintf(&b, "%s%s{\"%s\"}\n", indent, id, mermaidText(node.Label))
+			default:
+				fmt.Fprintf(&b, "%s%s[\"%s<br/>%s\"]\n", indent, id, mermaidText(node.Label), mermaidText(node.Type))
+			}
+		}
+	}
+	write("", "  ")
+
+	for _, edge := range g.Edges {
+		if edge.Label != "" {
+			fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", ids[edge.Source], mermaidText(edge.Label), ids[edge.Target])
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.Source], ids[edge.Target])
+		}
+	}
+	return b.String()
+}
+
+func mermaidText(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
+
+// DOT renders the graph in the Graphviz DOT language; composite tasks become clusters
+func (g *WorkflowGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph workflow {\n  rankdir=TB;\n  node [shape=box, style=rounded];\n")
+	children := g.children()
+	var write func(parent string, indent string)
+	write = func(parent string, indent string) {
+		for _, node := range children[parent] {
+			switch {
+			case len(children[node.ID]) > 0:
+				fmt.Fprintf(&b, "%ssubgraph %s {\n%s  label=%s;\n", indent, strconv.Quote("cluster_"+node.ID), indent, strconv.Quote(node.Label+" ("+node.Type+")"))
+				write(node.ID, indent+"  ")
+				fmt.Fprintf(&b, "%s}\n", indent)
+			case node.Type == GraphNodeStart || node.Type == GraphNodeEnd:
+				fmt.Fprintf(&b, "%s%s [shape=circle, label=%s];\n", indent, strconv.Quote(node.ID), strconv.Quote(node.Label))
+			case node.Type == GraphNodeEntry || node.Type == GraphNodeExit:
+				fmt.Fprintf(&b, "%s%s [shape=point];\n", indent, strconv.Quote(node.ID))
+			case node.Type == "switch":
+				fmt.Fprintf(&b, "%s%s [shape=diamond, label=%s];\n", indent, strconv.Quote(node.ID), strconv.Quote(node.Label))
+			default:
+				fmt.Fprintf(&b, "%s%s [label=%s];\n", indent, strconv.Quote(node.ID), strconv.Quote(node.Label+"\n"+node.Type))
+			}
+		}
+	}
+	write("", "  ")
+
+	for _, edge := range g.Edges {
+		if edge.Label != "" {
+			fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", strconv.Quote(edge.Source), strconv.Quote(edge.Target), strconv.Quote(edge.Label))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", strconv.Quote(edge.Source), strconv.Quote(edge.Target))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
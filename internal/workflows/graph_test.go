@@ -0,0 +1,137 @@
+package workflows
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+)
+
+func TestBuildWorkflowGraph(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: graph
+  version: 1.0.0
+do:
+  - route:
+      switch:
+        - big:
+            when: ${ .total > 100 }
+            then: review
+        - small:
+            then: parallel
+  - review:
+      do:
+        - approve:
+            set:
+              approved: true
+      then: end
+  - parallel:
+      fork:
+        branches:
+          - notify:
+              call: http
+              with:
+                method: post
+                endpoint: http://localhost:8088/demo/notify
+          - each:
+              for:
+                in: ${ .items }
+              do:
+                - ship:
+                    wait:
+                      seconds: 1
+  - guarded:
+      try:
+        - risky:
+            raise:
+              error:
+                type: https://example.com/errors/risky
+                status: 500
+      catch:
+        do:
+          - recover:
+              set:
+                recovered: true
+`
+	workflowDef, err := parser.FromYAMLSource([]byte(definition))
+	if err != nil {
+		t.Fatalf("Failed to parse workflow: %v", err)
+	}
+	graph := BuildWorkflowGraph(workflowDef)
+
+	nodes := map[string]GraphNode{}
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	for id, expected := range map[string]GraphNode{
+		"/do/1/review/do/0/approve":                     {Type: "set", Parent: "/do/1/review"},
+		"/do/2/parallel/fork/branches/0/notify":         {Type: "call:http", Parent: "/do/2/parallel"},
+		"/do/2/parallel/fork/branches/1/each/do/0/ship": {Type: "wait", Parent: "/do/2/parallel/fork/branches/1/each"},
+		"/do/3/guarded/catch/do/0/recover":              {Type: "set", Parent: "/do/3/guarded"},
+		"/do/3/guarded#entry":                           {Type: GraphNodeEntry, Parent: "/do/3/guarded"},
+	} {
+		node, ok := nodes[id]
+		if !ok || node.Type != expected.Type || node.Parent != expected.Parent {
+			t.Errorf("Expected node %s %+v, got %+v", id, expected, node)
+		}
+	}
+
+	edges := map[string]bool{}
+	for _, edge := range graph.Edges {
+		edges[edge.Source+" -> "+edge.Target+" "+edge.Label] = true
+	}
+	for _, expected := range []string{
+		"start -> /do/0/route ",
+		"/do/0/route -> /do/1/review#entry big",
+		"/do/0/route -> /do/2/parallel#entry small",
+		"/do/1/review#entry -> /do/1/review/do/0/approve ",
+		"/do/1/review/do/0/approve -> /do/1/review#exit ",
+		"/do/1/review#exit -> end ",
+		"/do/2/parallel#entry -> /do/2/parallel/fork/branches/1/each#entry ",
+		"/do/2/parallel/fork/branches/1/each#exit -> /do/2/parallel#exit ",
+		"/do/2/parallel#exit -> /do/3/guarded#entry ",
+		"/do/3/guarded#entry -> /do/3/guarded/try/0/risky ",
+		"/do/3/guarded#entry -> /do/3/guarded/catch/do/0/recover catch",
+		"/do/3/guarded#exit -> end ",
+	} {
+		if !edges[expected] {
+			t.Errorf("Missing edge %q", expected)
+		}
+	}
+	for edge := range edges {
+		if strings.HasPrefix(edge, "/do/3/guarded/try/0/risky ->") {
+			t.Errorf("Expected no edge out of a raise task, got %q", edge)
+		}
+		if strings.HasPrefix(edge, "/do/0/route") && strings.HasSuffix(edge, "default") {
+			t.Errorf("Expected no default edge for a switch with a default case, got %q", edge)
+		}
+	}
+
+	mermaid := graph.Mermaid()
+	for _, expected := range []string{
+		"flowchart TD\n",
+		"  start((\"start\"))\n",
+		"  do_0_route{\"route\"}\n",
+		"  subgraph do_1_review[\"review (do)\"]\n",
+		"  do_0_route -->|\"big\"| do_1_review_entry\n",
+		"  do_1_review_exit --> end_\n",
+	} {
+		if !strings.Contains(mermaid, expected) {
+			t.Errorf("Expected Mermaid output to contain %q, got\n%s", expected, mermaid)
+		}
+	}
+
+	dot := graph.DOT()
+	for _, expected := range []string{
+		"digraph workflow {\n",
+		"  subgraph \"cluster_/do/2/parallel\" {\n",
+		"  \"/do/0/route\" [shape=diamond, label=\"route\"];\n",
+		"  \"/do/0/route\" -> \"/do/1/review#entry\" [label=\"big\"];\n",
+	} {
+		if !strings.Contains(dot, expected) {
+			t.Errorf("Expected DOT output to contain %q, got\n%s", expected, dot)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Task execution statuses recorded in WorkflowState.Tasks
+const (
+	TaskStatusPending   = "pending"
+	TaskStatusRunning   = "running"
+	TaskStatusCompleted = "completed"
+	TaskStatusSkipped   = "skipped"
+	TaskStatusFaulted   = "faulted"
+)
+
+// executionRecorderKey carries the root *WorkflowState that task executions are recorded into
+const executionRecorderKey contextKey = "executionRecorder"
+
+// TaskExecution records the execution of a single task. Executions are keyed by the task's
+// JSON pointer reference (e.g. /do/1/processOrderSwitch) and linked into a tree via Parent/Children.
+type TaskExecution struct {
+	Reference string         `json:"reference"`
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Parent    string         `json:"parent,omitempty"`
+	Children  []string       `json:"children,omitempty"`
+	Status    string         `json:"status"`
+	StartedAt *time.Time     `json:"started_at,omitempty"`
+	EndedAt   *time.Time     `json:"ended_at,omitempty"`
+	Runs      int            `json:"runs"` // times the task started, e.g. once per iteration of an enclosing for
+	Input     interface{}    `json:"input,omitempty"`
+	Output    interface{}    `json:"output,omitempty"`
+	Error     *WorkflowError `json:"error,omitempty"`
+}
+
+// Serverless Workflow spec error types
+const (
+	ErrorTypeRuntime    = "https://serverlessworkflow.io/spec/1.0.0/errors/runtime"
+	ErrorTypeTimeout    = "https://serverlessworkflow.io/spec/1.0.0/errors/timeout"
+	ErrorTypeValidation = "https://serverlessworkflow.io/spec/1.0.0/errors/validation"
+)
+
+// WorkflowError is a Serverless Workflow spec error object
+type WorkflowError struct {
+	Type     string `json:"type"`
+	Status   int    `json:"status"`
+	Title    string `json:"title,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// newRuntimeError builds the spec runtime error raised by a task
+func newRuntimeError(reference string, err error) *WorkflowError {
+	return &WorkflowError{
+		Type:     ErrorTypeRuntime,
+		Status:   500,
+		Title:    "Task execution failed",
+		Detail:   err.Error(),
+		Instance: reference,
+	}
+}
+
+// withExecutionRecorder records task executions into the workflow state and registers every
+// task of the definition as pending
+func withExecutionRecorder(ctx workflow.Context, workflowDef *model.Workflow, state *WorkflowState) workflow.Context {
+	if state.Tasks == nil {
+		state.Tasks = make(map[string]*TaskExecution)
+	}
+	if workflowDef.Do != nil {
+		state.registerPendingTasks(*workflowDef.Do, "/do", "")
+	}
+	return workflow.WithValue(ctx, executionRecorderKey, state)
+}
+
+// registerPendingTasks adds a pending execution for every task in the list and its nested scopes
+func (s *WorkflowState) registerPendingTasks(tasks model.TaskList, scope string, parent string) {
+	for i, taskItem := range tasks {
+		reference := fmt.Sprintf("%s/%d/%s", scope, i, taskItem.Key)
+		s.ensureTask(reference, taskItem.Key, taskTypeName(taskItem), parent)
+
+		switch task := taskItem.Task.(type) {
+		case *model.DoTask:
+			if task.Do != nil {
+				s.registerPendingTasks(*task.Do, reference+"/do", reference)
+			}
+		case *model.ForTask:
+			if task.Do != nil {
+				s.registerPendingTasks(*task.Do, reference+"/do", reference)
+			}
+		case *model.ForkTask:
+			if task.Fork.Branches != nil {
+				s.registerPendingTasks(*task.Fork.Branches, reference+"/fork/branches", reference)
+			}
+		case *model.TryTask:
+			if task.Try != nil {
+				s.registerPendingTasks(*task.Try, reference+"/try", reference)
+			}
+			if task.Catch != nil && task.Catch.Do != nil {
+				s.registerPendingTasks(*task.Catch.Do, reference+"/catch/do", reference)
+			}
+		}
+	}
+}
+
+// ensureTask returns the execution for the reference, creating a pending one if needed
+func (s *WorkflowState) ensureTask(reference, name, taskType, parent string) *TaskExecution {
+	if execution, ok := s.Tasks[reference]; ok {
+		return execution
+	}
+
+	execution := &TaskExecution{
+		Reference: reference,
+		Name:      name,
+		Type:      taskType,
+		Parent:    parent,
+		Status:    TaskStatusPending,
+	}
+	s.Tasks[reference] = execution
+	if parentExecution, ok := s.Tasks[parent]; ok {
+		parentExecution.Children = append(parentExecution.Children, reference)
+	}
+	return execution
+}
+
+// executionRecorder returns the workflow state task executions are recorded into, if any
+func executionRecorder(ctx workflow.Context) *WorkflowState {
+	state, _ := ctx.Value(executionRecorderKey).(*WorkflowState)
+	return state
+}
+
+// recordTaskStarted marks the task as running and makes it the current task
+func recordTaskStarted(ctx workflow.Context, reference, name, taskType, parent string, input map[string]interface{}) {
+	state := executionRecorder(ctx)
+	if state == nil {
+		return
+	}
+
+	now := workflow.Now(ctx)
+	execution := state.ensureTask(reference, name, taskType, parent)
+	execution.Status = TaskStatusRunning
+	execution.StartedAt = &now
+	execution.EndedAt = nil
+	execution.Runs++
+	execution.Input = snapshotState(input)
+	execution.Output = nil
+	execution.Error = nil
+	state.CurrentTask = reference
+	state.recordEvent(ctx, ExecutionEvent{Type: EventTaskStarted, Task: reference, Name: name, Status: TaskStatusRunning})
+}
+
+// recordTaskCompleted marks the task as completed with its output
+func recordTaskCompleted(ctx workflow.Context, reference string, output interface{}) {
+	recordTaskEnded(ctx, reference, TaskStatusCompleted, output, nil)
+}
+
+// recordTaskSkipped marks the task as skipped because its `if` condition was not met
+func recordTaskSkipped(ctx workflow.Context, reference string) {
+	recordTaskEnded(ctx, reference, TaskStatusSkipped, nil, nil)
+}
+
+// recordTaskFaulted marks the task as faulted with the spec error it raised
+func recordTaskFaulted(ctx workflow.Context, reference string, err error) {
+	recordTaskEnded(ctx, reference, TaskStatusFaulted, nil, newRuntimeError(reference, err))
+}
+
+func recordTaskEnded(ctx workflow.Context, reference, status string, output interface{}, taskErr *WorkflowError) {
+	state := executionRecorder(ctx)
+	if state == nil {
+		return
+	}
+	execution, ok := state.Tasks[reference]
+	if !ok {
+		return
+	}
+
+	now := workflow.Now(ctx)
+	execution.Status = status
+	execution.EndedAt = &now
+	execution.Output = output
+	execution.Error = taskErr
+	if state.CurrentTask == reference {
+		state.CurrentTask = execution.Parent
+	}
+	state.recordEvent(ctx, ExecutionEvent{
+		Type:   taskEventTypes[status],
+		Task:   reference,
+		Name:   execution.Name,
+		Status: status,
+		Output: output,
+		Error:  taskErr,
+	})
+}
+
+// taskEventTypes maps the final task statuses onto their event types
+var taskEventTypes = map[string]string{
+	TaskStatusCompleted: EventTaskCompleted,
+	TaskStatusSkipped:   EventTaskSkipped,
+	TaskStatusFaulted:   EventTaskFaulted,
+}
+
+// recordSubtreeEnded settles the still pending or running descendants of a task, used for
+// branches executed inside a single activity where nested tasks cannot be observed
+func recordSubtreeEnded(ctx workflow.Context, reference, status string) {
+	state := executionRecorder(ctx)
+	if state == nil {
+		return
+	}
+
+	now := workflow.Now(ctx)
+	prefix := reference + "/"
+	for ref, execution := range state.Tasks {
+		if !strings.HasPrefix(ref, prefix) {
+			continue
+		}
+		if execution.Status != TaskStatusPending && execution.Status != TaskStatusRunning {
+			continue
+		}
+		execution.Status = status
+		execution.EndedAt = &now
+	}
+}
+
+// snapshotState copies the top level of the state so later mutations do not alter the record
+func snapshotState(state map[string]interface{}) map[string]interface{} {
+	if state == nil {
+		return nil
+	}
+	snapshot := make(map[string]interface{}, len(state))
+	for key, value := range state {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+// taskTypeName returns the spec task type of a task item, e.g. "call:http", "fork" or "set"
+func taskTypeName(taskItem *model.TaskItem) string {
+	switch task := taskItem.Task.(type) {
+	case *model.CallHTTP:
+		return "call:http"
+	case *model.CallOpenAPI:
+		return "call:openapi"
+	case *model.CallGRPC:
+		return "call:grpc"
+	case *model.CallAsyncAPI:
+		return "call:asyncapi"
+	case *model.CallFunction:
+		return "call:" + task.Call
+	case *model.DoTask:
+		return "do"
+	case *model.ForkTask:
+		return "fork"
+	case *model.EmitTask:
+		return "emit"
+	case *model.ForTask:
+		return "for"
+	case *model.ListenTask:
+		return "listen"
+	case *model.RaiseTask:
+		return "raise"
+	case *model.RunTask:
+		return "run"
+	case *model.SetTask:
+		return "set"
+	case *model.SwitchTask:
+		return "switch"
+	case *model.TryTask:
+		return "try"
+	case *model.WaitTask:
+		return "wait"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,92 @@
+package workflows
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Workflow execution statuses reported in WorkflowState.Status
+const (
+	WorkflowStatusRunning   = "running"
+	WorkflowStatusSuspended = "suspended"
+	WorkflowStatusCancelled = "cancelled"
+	WorkflowStatusCompleted = "completed"
+	WorkflowStatusFailed    = "failed"
+)
+
+// Updates controlling a running serverless workflow execution. They return the resulting status;
+// one that does not apply to the current status is rejected with ExecutionStateErrorType.
+const (
+	SuspendUpdate = "suspend"
+	ResumeUpdate  = "resume"
+)
+
+// ExecutionStateErrorType is returned when a suspend or resume does not apply to the execution's
+// status, e.g. resuming an execution that is not suspended
+const ExecutionStateErrorType = "InvalidExecutionState"
+
+// setExecutionControlHandlers registers the suspend and resume updates for the lifetime of the
+// workflow. Suspension takes effect between tasks: the running task finishes, the next one waits
+// for resume.
+func setExecutionControlHandlers(ctx workflow.Context, state *WorkflowState) error {
+	controls := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{SuspendUpdate, WorkflowStatusRunning, WorkflowStatusSuspended},
+		{ResumeUpdate, WorkflowStatusSuspended, WorkflowStatusRunning},
+	}
+	for _, control := range controls {
+		control := control
+		err := workflow.SetUpdateHandlerWithOptions(ctx, control.name,
+			func(ctx workflow.Context) (string, error) {
+				workflow.GetLogger(ctx).Info("Workflow status changed by update", "update", control.name, "status", control.to)
+				state.setStatus(ctx, control.to)
+				return state.Status, nil
+			},
+			workflow.UpdateHandlerOptions{
+				Validator: func(ctx workflow.Context) error {
+					if state.Status != control.from {
+						return temporal.NewApplicationError(
+							fmt.Sprintf("cannot %s an execution that is %s", control.name, state.Status), ExecutionStateErrorType)
+					}
+					return nil
+				},
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compensatingKey marks the disconnected context catch tasks run on after a cancellation
+const compensatingKey contextKey = "compensating"
+
+// withCompensation returns a context that survives the workflow's cancellation, for running the
+// catch tasks of a try task once the execution has been cancelled
+func withCompensation(ctx workflow.Context) workflow.Context {
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	return workflow.WithValue(ctx, compensatingKey, true)
+}
+
+// awaitResumed blocks while the workflow is suspended. Compensation after a cancellation is not
+// held back by a suspension.
+func awaitResumed(ctx workflow.Context) error {
+	state := executionRecorder(ctx)
+	if state == nil || state.Status != WorkflowStatusSuspended {
+		return nil
+	}
+	if compensating, _ := ctx.Value(compensatingKey).(bool); compensating {
+		return nil
+	}
+
+	workflow.GetLogger(ctx).Info("Waiting for workflow to be resumed")
+	return workflow.Await(ctx, func() bool {
+		return state.Status != WorkflowStatusSuspended
+	})
+}
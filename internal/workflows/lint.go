@@ -0,0 +1,482 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+)
+
+// Lint rules
+const (
+	LintUnreachableTask      = "unreachable-task"
+	LintUnknownThenTarget    = "unknown-then-target"
+	LintUndefinedReference   = "undefined-reference"
+	LintSwitchWithoutDefault = "switch-without-default"
+	LintEndpointNotAllowed   = "endpoint-not-allowed"
+	LintDuplicateTaskName    = "duplicate-task-name"
+	LintUnsupportedTaskType  = "unsupported-task-type"
+	LintUnfollowedThen       = "unfollowed-then"
+	LintUndefinedFunction    = "undefined-function"
+)
+
+// LintParseError is the rule reported when a definition does not parse
+const LintParseError = "parse"
+
+// Lint issue severities
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+// LintRule describes a lint rule and its default severity
+type LintRule struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+var lintRules = []LintRule{
+	{Name: LintUnreachableTask, Severity: LintSeverityWarning, Description: "Task follows a task ending the flow with `then: end` or `then: exit` and no `then` directive targets it"},
+	{Name: LintUnknownThenTarget, Severity: LintSeverityError, Description: "`then` names a task that does not exist in the same task list"},
+	{Name: LintUndefinedReference, Severity: LintSeverityWarning, Description: "Expression references data that no earlier task produces"},
+	{Name: LintSwitchWithoutDefault, Severity: LintSeverityWarning, Description: "Switch has no default case (a case without `when`)"},
+	{Name: LintEndpointNotAllowed, Severity: LintSeverityError, Description: "HTTP endpoint is outside the allowed endpoints"},
+	{Name: LintDuplicateTaskName, Severity: LintSeverityError, Description: "Task name is used more than once; task results are stored by name"},
+	{Name: LintUnsupportedTaskType, Severity: LintSeverityError, Description: "Task type or option is not supported by this engine, or not where it appears (fork branches run only do, http and set)"},
+	{Name: LintUnfollowedThen, Severity: LintSeverityError, Description: "`then` jumps to a task other than the next one, which the engine does not follow as it runs the tasks of a list in order"},
+	{Name: LintUndefinedFunction, Severity: LintSeverityError, Description: "Called function is neither defined in use.functions nor registered with the engine"},
+}
+
+// LintRules returns the available lint rules
+func LintRules() []LintRule {
+	return append([]LintRule(nil), lintRules...)
+}
+
+// defaultAllowedEndpoints are the endpoint prefixes allowed when none are configured
+var defaultAllowedEndpoints = []string{demoBaseURL + "/"}
+
+// LintOptions configures the lint rules
+type LintOptions struct {
+	Disabled         []string          `json:"disabled,omitempty"`          // rules not to run
+	Severity         map[string]string `json:"severity,omitempty"`          // severity overrides by rule
+	AllowedEndpoints []string          `json:"allowed_endpoints,omitempty"` // endpoint prefixes; defaults to the demo API
+	// Functions are the native functions calls may name besides use.functions. They are passed in
+	// rather than read from the registry so that linting inside workflow code stays deterministic.
+	Functions []string `json:"functions,omitempty"`
+}
+
+// LintIssue is a problem found in a workflow definition
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"` // JSON pointer to the offending node
+	Message  string `json:"message"`
+}
+
+// HasLintErrors reports whether any issue has error severity
+func HasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// LintWorkflowSource parses a YAML or JSON definition and lints it. A definition that does not
+// parse is reported as a single parse issue.
+func LintWorkflowSource(source []byte, options LintOptions) []LintIssue {
+	workflowDef, err := parser.FromYAMLSource(source)
+	if err != nil {
+		return []LintIssue{{Rule: LintParseError, Severity: LintSeverityError, Path: "", Message: err.Error()}}
+	}
+	return LintWorkflow(workflowDef, options)
+}
+
+// LintWorkflow checks a parsed workflow for logic errors the schema validation does not catch
+func LintWorkflow(workflowDef *model.Workflow, options LintOptions) []LintIssue {
+	l := &linter{
+		options:   options,
+		disabled:  map[string]bool{},
+		functions: map[string]bool{},
+		taskPaths: map[string]string{},
+		produced:  map[string]bool{"result": true},
+		issues:    []LintIssue{},
+	}
+	for _, rule := range options.Disabled {
+		l.disabled[rule] = true
+	}
+	for _, function := range options.Functions {
+		l.functions[function] = true
+	}
+	if len(l.options.AllowedEndpoints) == 0 {
+		l.options.AllowedEndpoints = defaultAllowedEndpoints
+	}
+	if workflowDef.Use != nil {
+		l.catalog = workflowDef.Use.Functions
+	}
+	if workflowDef.Do != nil {
+		l.lintTaskList(*workflowDef.Do, "/do", workflowTaskList)
+	}
+	return l.issues
+}
+
+// describeLintIssues lists the issues for the conversation
+func describeLintIssues(issues []LintIssue) string {
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = fmt.Sprintf("- %s %s at %s: %s", issue.Severity, issue.Rule, issue.Path, issue.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// taskListContext is what runs a task list, which limits the task types the engine supports in it
+type taskListContext int
+
+const (
+	workflowTaskList taskListContext = iota // run by the workflow interpreter
+	branchTaskList                          // fork branches, run by ExecuteBranchActivity: do, http and set
+	branchDoTaskList                        // the do list of a fork branch: http and set
+)
+
+type linter struct {
+	options   LintOptions
+	disabled  map[string]bool
+	catalog   model.NamedTaskMap
+	functions map[string]bool   // native functions from the options
+	taskPaths map[string]string // first path of each task name
+	produced  map[string]bool   // state keys produced by the tasks linted so far
+	issues    []LintIssue
+}
+
+func (l *linter) report(rule, path, format string, args ...interface{}) {
+	if l.disabled[rule] {
+		return
+	}
+	severity := LintSeverityError
+	for _, r := range lintRules {
+		if r.Name == rule {
+			severity = r.Severity
+		}
+	}
+	if override, ok := l.options.Severity[rule]; ok {
+		severity = override
+	}
+	l.issues = append(l.issues, LintIssue{Rule: rule, Severity: severity, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// lintTaskList checks the flow directives of a task list, then each of its tasks in order
+func (l *linter) lintTaskList(tasks model.TaskList, path string, context taskListContext) {
+	names := map[string]bool{}
+	targets := map[string]bool{}
+	for _, task := range tasks {
+		names[task.Key] = true
+	}
+	for _, task := range tasks {
+		for _, target := range thenDirectives(task) {
+			targets[target] = true
+		}
+	}
+
+	ended := false
+	for i, task := range tasks {
+		taskPath := fmt.Sprintf("%s/%d/%s", path, i, escapePointer(task.Key))
+
+		for _, target := range thenDirectives(task) {
+			switch {
+			case !isFlowDirective(target) && !names[target]:
+				l.report(LintUnknownThenTarget, taskPath, "then target %q is not a task in this list", target)
+			case !isSequentialThen(tasks, i, target, path == "/do"):
+				l.report(LintUnfollowedThen, taskPath, "then %q is not followed by this engine, which runs the tasks of a list in order", target)
+			}
+		}
+
+		if targets[task.Key] {
+			ended = false
+		}
+		if ended {
+			l.report(LintUnreachableTask, taskPath, "task %q is unreachable: an earlier task ends the flow and no then directive targets it", task.Key)
+		}
+		if base := task.GetBase(); base != nil && base.If == nil && base.Then != nil && base.Then.IsTermination() {
+			ended = true
+		}
+
+		l.lintTask(task, taskPath, context)
+	}
+}
+
+// lintTask checks a single task and the task lists nested in it
+func (l *linter) lintTask(task *model.TaskItem, path string, context taskListContext) {
+	if first, ok := l.taskPaths[task.Key]; ok {
+		l.report(LintDuplicateTaskName, path, "task name %q is already used at %s", task.Key, first)
+	} else {
+		l.taskPaths[task.Key] = path
+	}
+
+	l.lintExpressions(task, path)
+
+	switch task.Task.(type) {
+	case *model.CallHTTP, *model.SetTask:
+		// Supported everywhere
+	case *model.DoTask:
+		if context == branchDoTaskList {
+			l.report(LintUnsupportedTaskType, path, "do tasks are not supported inside the do task of a fork branch")
+		}
+	default:
+		if context != workflowTaskList {
+			l.report(LintUnsupportedTaskType, path, "%s tasks are not supported inside fork branches", taskTypeName(task))
+			l.markProduced(task)
+			return
+		}
+	}
+
+	switch t := task.Task.(type) {
+	case *model.CallHTTP:
+		l.lintEndpoint(t, path)
+	case *model.CallFunction:
+		if _, ok := l.catalog[t.Call]; !ok && !l.functions[t.Call] {
+			l.report(LintUndefinedFunction, path, "function %q is neither defined in use.functions nor registered with the engine", t.Call)
+		}
+	case *model.CallAsyncAPI, *model.SetTask:
+		// Supported, nothing further to check
+	case *model.SwitchTask:
+		if !hasDefaultCase(t) {
+			l.report(LintSwitchWithoutDefault, path, "switch %q has no default case; add a case without `when`", task.Key)
+		}
+	case *model.DoTask:
+		if t.Do != nil && context != branchDoTaskList {
+			nested := workflowTaskList
+			if context == branchTaskList {
+				nested = branchDoTaskList
+			}
+			l.lintTaskList(*t.Do, path+"/do", nested)
+		}
+	case *model.ForTask:
+		if t.Do != nil {
+			l.lintTaskList(*t.Do, path+"/do", workflowTaskList)
+		}
+	case *model.ForkTask:
+		if t.Fork.Branches != nil {
+			l.lintTaskList(*t.Fork.Branches, path+"/fork/branches", branchTaskList)
+		}
+	case *model.TryTask:
+		if t.Try != nil {
+			l.lintTaskList(*t.Try, path+"/try", workflowTaskList)
+		}
+		if t.Catch != nil && t.Catch.Retry != nil {
+			l.report(LintUnsupportedTaskType, path+"/catch/retry", "catch.retry is not supported by this engine; failed tasks are not retried")
+		}
+		if t.Catch != nil && t.Catch.Do != nil {
+			errorName := "error"
+			if t.Catch.As != "" {
+				errorName = t.Catch.As
+			}
+			l.produced[errorName] = true
+			l.lintTaskList(*t.Catch.Do, path+"/catch/do", workflowTaskList)
+		}
+	default:
+		l.report(LintUnsupportedTaskType, path, "%s tasks are not supported by this engine", taskTypeName(task))
+	}
+
+	l.markProduced(task)
+}
+
+// markProduced records the state keys a task produces
+func (l *linter) markProduced(task *model.TaskItem) {
+	l.produced[task.Key] = true
+	if set := task.AsSetTask(); set != nil {
+		for key := range set.Set {
+			l.produced[key] = true
+		}
+	}
+}
+
+// lintEndpoint checks the endpoint of an HTTP call against the allowed prefixes
+func (l *linter) lintEndpoint(call *model.CallHTTP, path string) {
+	if call.With.Endpoint == nil {
+		return
+	}
+	endpoint := call.With.Endpoint.String()
+	if endpoint == "" || strings.Contains(endpoint, "${") {
+		return
+	}
+	for _, prefix := range l.options.AllowedEndpoints {
+		if strings.HasPrefix(endpoint, prefix) || endpoint == strings.TrimSuffix(prefix, "/") {
+			return
+		}
+	}
+	l.report(LintEndpointNotAllowed, path+"/with/endpoint", "endpoint %q is not under an allowed endpoint (%s)", endpoint, strings.Join(l.options.AllowedEndpoints, ", "))
+}
+
+// Keys not checked for references: transformations of the task's own input and output, and
+// nested task lists, which are linted task by task
+var skippedExpressionKeys = map[string]bool{"input": true, "output": true, "export": true, "do": true, "fork": true, "try": true, "catch": true}
+
+// lintExpressions checks the state references in the task's runtime expressions
+func (l *linter) lintExpressions(task *model.TaskItem, path string) {
+	encoded, err := json.Marshal(task.Task)
+	if err != nil {
+		return
+	}
+	var definition map[string]interface{}
+	if err := json.Unmarshal(encoded, &definition); err != nil {
+		return
+	}
+	for _, key := range sortedKeys(definition) {
+		if skippedExpressionKeys[key] {
+			continue
+		}
+		l.lintValueExpressions(definition[key], path+"/"+escapePointer(key))
+	}
+}
+
+func (l *linter) lintValueExpressions(value interface{}, path string) {
+	switch v := value.(type) {
+	case string:
+		for _, reference := range expressionReferences(v) {
+			if !l.produced[reference] && !taskResultKeyPattern.MatchString(reference) {
+				l.report(LintUndefinedReference, path, "expression references .%s, which no earlier task produces", reference)
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			l.lintValueExpressions(v[key], path+"/"+escapePointer(key))
+		}
+	case []interface{}:
+		for i, item := range v {
+			l.lintValueExpressions(item, path+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+var (
+	// stateReferencePattern matches top-level paths such as .order or .order.id, but not
+	// $input.order, .a.b's .b or (.x).y's .y
+	stateReferencePattern = regexp.MustCompile(`(?:^|[^\w$.\])"'])\.([A-Za-z_][A-Za-z0-9_]*)`)
+	stringLiteralPattern  = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	taskResultKeyPattern  = regexp.MustCompile(`^task_\d+_result$`)
+)
+
+// expressionReferences returns the top-level state keys referenced in the runtime expressions
+// of a string, in order of appearance
+func expressionReferences(value string) []string {
+	var references []string
+	seen := map[string]bool{}
+	for _, expression := range runtimeExpressions(value) {
+		expression = stringLiteralPattern.ReplaceAllString(expression, `""`)
+		for _, match := range stateReferencePattern.FindAllStringSubmatch(expression, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				references = append(references, match[1])
+			}
+		}
+	}
+	return references
+}
+
+// runtimeExpressions returns the bodies of the ${ ... } expressions in a string
+func runtimeExpressions(value string) []string {
+	var expressions []string
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			return expressions
+		}
+		depth := 0
+		end := -1
+		for i := start + 1; i < len(value) && end < 0; i++ {
+			switch value[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			return append(expressions, value[start+2:])
+		}
+		expressions = append(expressions, value[start+2:end])
+		value = value[end+1:]
+	}
+}
+
+// thenDirectives returns the then directives of a task, including those of switch cases
+func thenDirectives(task *model.TaskItem) []string {
+	var directives []string
+	if base := task.GetBase(); base != nil && base.Then != nil {
+		directives = append(directives, base.Then.Value)
+	}
+	if switchTask := task.AsSwitchTask(); switchTask != nil {
+		for _, item := range switchTask.Switch {
+			for _, name := range sortedSwitchCases(item) {
+				if then := item[name].Then; then != nil {
+					directives = append(directives, then.Value)
+				}
+			}
+		}
+	}
+	return directives
+}
+
+// isSequentialThen reports whether a then directive of the task at index leads where the engine goes
+// anyway: the next task of the list, or the end of the list. Ending the workflow from a nested list
+// would continue with the parent list, so end only matches at the end of the top-level list.
+func isSequentialThen(tasks model.TaskList, index int, target string, topLevel bool) bool {
+	last := index == len(tasks)-1
+	switch model.FlowDirectiveType(target) {
+	case model.FlowDirectiveContinue:
+		return true
+	case model.FlowDirectiveExit:
+		return last
+	case model.FlowDirectiveEnd:
+		return last && topLevel
+	}
+	return !last && tasks[index+1].Key == target
+}
+
+func isFlowDirective(value string) bool {
+	switch model.FlowDirectiveType(value) {
+	case model.FlowDirectiveContinue, model.FlowDirectiveExit, model.FlowDirectiveEnd:
+		return true
+	}
+	return false
+}
+
+func hasDefaultCase(switchTask *model.SwitchTask) bool {
+	for _, item := range switchTask.Switch {
+		for _, switchCase := range item {
+			if switchCase.When == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+
+func sortedSwitchCases(item model.SwitchItem) []string {
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,276 @@
+package workflows
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLintWorkflow(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: lint
+  version: 1.0.0
+do:
+  - getOrder:
+      call: http
+      with:
+        method: get
+        endpoint: http://localhost:8088/demo/orders/1
+  - route:
+      switch:
+        - paid:
+            when: ${ .getOrder.status == "paid" }
+            then: ship
+        - pending:
+            when: ${ .payment.status == "pending" }
+            then: remind
+  - ship:
+      call: http
+      with:
+        method: post
+        endpoint: https://shipping.example.com/shipments
+        body:
+          order: ${ .getOrder.id }
+          note: ${ "see .getOrder" }
+      then: end
+  - cleanup:
+      set:
+        done: true
+  - getOrder:
+      wait:
+        seconds: 1
+`
+	issues := LintWorkflowSource([]byte(definition), LintOptions{})
+
+	expected := []LintIssue{
+		{Rule: LintUnknownThenTarget, Path: "/do/1/route"},
+		{Rule: LintUndefinedReference, Path: "/do/1/route/switch/1/pending/when"},
+		{Rule: LintSwitchWithoutDefault, Path: "/do/1/route"},
+		{Rule: LintUnfollowedThen, Path: "/do/2/ship"},
+		{Rule: LintEndpointNotAllowed, Path: "/do/2/ship/with/endpoint"},
+		{Rule: LintUnreachableTask, Path: "/do/3/cleanup"},
+		{Rule: LintUnreachableTask, Path: "/do/4/getOrder"},
+		{Rule: LintDuplicateTaskName, Path: "/do/4/getOrder"},
+		{Rule: LintUnsupportedTaskType, Path: "/do/4/getOrder"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i, issue := range issues {
+		if issue.Rule != expected[i].Rule || issue.Path != expected[i].Path || issue.Message == "" {
+			t.Errorf("Issue %d: expected %s at %s, got %+v", i, expected[i].Rule, expected[i].Path, issue)
+		}
+	}
+	if !HasLintErrors(issues) {
+		t.Error("Expected error severity issues")
+	}
+
+	configured := LintWorkflowSource([]byte(definition), LintOptions{
+		Disabled:         []string{LintUnreachableTask, LintDuplicateTaskName, LintUnsupportedTaskType, LintUnfollowedThen, LintUnknownThenTarget},
+		Severity:         map[string]string{LintEndpointNotAllowed: LintSeverityWarning},
+		AllowedEndpoints: []string{"http://localhost:8088/demo/", "https://shipping.example.com/"},
+	})
+	if len(configured) != 2 || HasLintErrors(configured) {
+		t.Errorf("Expected only the reference and switch warnings, got %+v", configured)
+	}
+}
+
+func TestLintUnsupportedInBranchesAndJumps(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: lint-branches
+  version: 1.0.0
+do:
+  - check:
+      switch:
+        - retry:
+            when: ${ .retry }
+            then: check
+        - proceed:
+            then: notify
+  - notify:
+      fork:
+        branches:
+          - email:
+              call: http
+              with:
+                method: post
+                endpoint: http://localhost:8088/demo/email
+          - steps:
+              do:
+                - mark:
+                    set:
+                      marked: true
+                - nested:
+                    do:
+                      - inner:
+                          set:
+                            inner: true
+          - loop:
+              for:
+                each: item
+                in: .items
+              do:
+                - echo:
+                    set:
+                      item: ${ $item }
+      then: done
+  - skipped:
+      set:
+        skipped: true
+  - done:
+      set:
+        done: true
+      then: end
+`
+	issues := LintWorkflowSource([]byte(definition), LintOptions{Disabled: []string{LintUndefinedReference}})
+
+	expected := []LintIssue{
+		{Rule: LintUnfollowedThen, Path: "/do/0/check"},
+		{Rule: LintUnfollowedThen, Path: "/do/1/notify"},
+		{Rule: LintUnsupportedTaskType, Path: "/do/1/notify/fork/branches/1/steps/do/1/nested"},
+		{Rule: LintUnsupportedTaskType, Path: "/do/1/notify/fork/branches/2/loop"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i, issue := range issues {
+		if issue.Rule != expected[i].Rule || issue.Path != expected[i].Path {
+			t.Errorf("Issue %d: expected %s at %s, got %+v", i, expected[i].Rule, expected[i].Path, issue)
+		}
+	}
+}
+
+func TestLintEngineRulesDisabledIndependently(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: lint-engine-rules
+  version: 1.0.0
+do:
+  - audit:
+      call: auditLog
+      then: pause
+  - skipped:
+      set:
+        skipped: true
+  - pause:
+      wait:
+        seconds: 1
+`
+	rules := map[string]string{
+		LintUndefinedFunction:   "/do/0/audit",
+		LintUnfollowedThen:      "/do/0/audit",
+		LintUnsupportedTaskType: "/do/2/pause",
+	}
+	for disabled := range rules {
+		t.Run(disabled, func(t *testing.T) {
+			issues := LintWorkflowSource([]byte(definition), LintOptions{Disabled: []string{disabled}})
+
+			reported := map[string]string{}
+			for _, issue := range issues {
+				reported[issue.Rule] = issue.Path
+			}
+			for rule, path := range rules {
+				switch {
+				case rule == disabled && reported[rule] != "":
+					t.Errorf("Expected %s to be disabled, got it at %s", rule, reported[rule])
+				case rule != disabled && reported[rule] != path:
+					t.Errorf("Expected %s at %s, got %+v", rule, path, issues)
+				}
+			}
+		})
+	}
+}
+
+func TestLintNativeFunctions(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: lint-native-functions
+  version: 1.0.0
+do:
+  - audit:
+      call: auditLog
+`
+	// The registry is not consulted: only the functions passed in the options are known
+	RegisterFunction("auditLog", func(ctx context.Context, args map[string]interface{}) (interface{}, error) { return nil, nil })
+	t.Cleanup(func() { unregisterTestFunction("auditLog") })
+
+	issues := LintWorkflowSource([]byte(definition), LintOptions{})
+	if len(issues) != 1 || issues[0].Rule != LintUndefinedFunction {
+		t.Errorf("Expected %s without the function in the options, got %+v", LintUndefinedFunction, issues)
+	}
+	if issues := LintWorkflowSource([]byte(definition), LintOptions{Functions: []string{"auditLog"}}); len(issues) != 0 {
+		t.Errorf("Expected no issues with the function in the options, got %+v", issues)
+	}
+}
+
+func TestLintTryTask(t *testing.T) {
+	definition := `document:
+  dsl: 1.0.0
+  namespace: test
+  name: lint-try
+  version: 1.0.0
+do:
+  - guarded:
+      try:
+        - risky:
+            wait:
+              seconds: 1
+      catch:
+        as: failure
+        retry:
+          limit:
+            attempt:
+              count: 3
+        do:
+          - report:
+              set:
+                status: ${ .failure.status }
+`
+	issues := LintWorkflowSource([]byte(definition), LintOptions{})
+
+	expected := []string{"/do/0/guarded/try/0/risky", "/do/0/guarded/catch/retry"}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i, issue := range issues {
+		if issue.Rule != LintUnsupportedTaskType || issue.Path != expected[i] {
+			t.Errorf("Issue %d: expected %s at %s, got %+v", i, LintUnsupportedTaskType, expected[i], issue)
+		}
+	}
+}
+
+func TestLintWorkflowParseError(t *testing.T) {
+	issues := LintWorkflowSource([]byte("document: {}\ndo: not-a-list\n"), LintOptions{})
+	if len(issues) != 1 || issues[0].Rule != LintParseError || issues[0].Severity != LintSeverityError {
+		t.Errorf("Expected a parse issue, got %+v", issues)
+	}
+}
+
+func TestExpressionReferences(t *testing.T) {
+	tests := map[string][]string{
+		"${ .order.id }": {"order"},
+		`${ { id: .order.id, total: .cart.sum } }`: {"order", "cart"},
+		"${ $input.order + $context.x }":           nil,
+		`${ "literal .text" }`:                     nil,
+		"${ (.items | length) > 0 }":               {"items"},
+		"plain .text without expressions":          nil,
+		"${ .a } and ${ .b }":                      {"a", "b"},
+	}
+	for expression, expected := range tests {
+		references := expressionReferences(expression)
+		if len(references) != len(expected) {
+			t.Errorf("%s: expected %v, got %v", expression, expected, references)
+			continue
+		}
+		for i := range references {
+			if references[i] != expected[i] {
+				t.Errorf("%s: expected %v, got %v", expression, expected, references)
+			}
+		}
+	}
+}
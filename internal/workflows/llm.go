@@ -0,0 +1,130 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// Chat message roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Content block types
+const (
+	BlockText       = "text"
+	BlockThinking   = "thinking"
+	BlockToolUse    = "tool_use"
+	BlockToolResult = "tool_result"
+)
+
+// Reasons a provider stopped generating
+const (
+	StopEndTurn   = "end_turn"
+	StopToolUse   = "tool_use"
+	StopMaxTokens = "max_tokens"
+)
+
+// ChatMessage is a provider-neutral conversation message
+type ChatMessage struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock is a piece of message content: text, thinking, a tool call or a tool result
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`        // text, thinking, or the result of a tool_result block
+	Signature string          `json:"signature,omitempty"`   // thinking
+	ID        string          `json:"id,omitempty"`          // tool_use
+	Name      string          `json:"name,omitempty"`        // tool_use
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	IsError   bool            `json:"is_error,omitempty"`    // tool_result
+}
+
+// NewTextMessage creates a message with a single text block
+func NewTextMessage(role, text string) ChatMessage {
+	return ChatMessage{Role: role, Content: []ContentBlock{{Type: BlockText, Text: text}}}
+}
+
+// Text returns the text blocks of the message joined by newlines
+func (m ChatMessage) Text() string {
+	var texts []string
+	for _, block := range m.Content {
+		if block.Type == BlockText {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// ToolUses returns the tool calls requested in the message
+func (m ChatMessage) ToolUses() []ContentBlock {
+	var toolUses []ContentBlock
+	for _, block := range m.Content {
+		if block.Type == BlockToolUse {
+			toolUses = append(toolUses, block)
+		}
+	}
+	return toolUses
+}
+
+// ToolDefinition describes a tool the model may call; InputSchema is a JSON schema object
+type ToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// LLMRequest asks a provider for the next assistant message
+type LLMRequest struct {
+	ThreadID     string           `json:"thread_id"`
+	SystemPrompt string           `json:"system_prompt"`
+	Messages     []ChatMessage    `json:"messages"`
+	Tools        []ToolDefinition `json:"tools,omitempty"`
+	Model        string           `json:"model,omitempty"` // overrides the provider's default model
+	MaxTokens    int              `json:"max_tokens,omitempty"`
+	Temperature  *float64         `json:"temperature,omitempty"`
+}
+
+// LLMUsage reports the tokens consumed by a request
+type LLMUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+// LLMResponse is the assistant message generated for a request
+type LLMResponse struct {
+	Message    ChatMessage `json:"message"`
+	StopReason string      `json:"stop_reason"`
+	Usage      LLMUsage    `json:"usage"`
+}
+
+// LLMProvider generates assistant messages. Providers that stream call onDelta with each text
+// fragment as it arrives; others call it once with the full text.
+type LLMProvider interface {
+	Complete(ctx context.Context, request LLMRequest, onDelta func(text string)) (*LLMResponse, error)
+}
+
+// defaultMaxTokens is used when a request does not set MaxTokens
+const defaultMaxTokens = 4096
+
+// NewLLMProviderFromEnv selects the provider from the environment. LLM_PROVIDER=openai uses
+// OPENAI_BASE_URL (default http://localhost:11434/v1), OPENAI_API_KEY and LLM_MODEL; otherwise
+// Anthropic is used with ANTHROPIC_API_KEY and LLM_MODEL. It returns nil when no provider is configured.
+func NewLLMProviderFromEnv() LLMProvider {
+	switch os.Getenv("LLM_PROVIDER") {
+	case "openai":
+		return NewOpenAIProvider(os.Getenv("OPENAI_BASE_URL"), os.Getenv("OPENAI_API_KEY"), os.Getenv("LLM_MODEL"))
+	default:
+		apiKey := getClaudeAPIKey()
+		if apiKey == "" {
+			return nil
+		}
+		return NewAnthropicProvider(apiKey, os.Getenv("LLM_MODEL"))
+	}
+}
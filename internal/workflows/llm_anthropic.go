@@ -0,0 +1,162 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// AnthropicProvider generates messages with Claude through the streaming Messages API
+type AnthropicProvider struct {
+	client anthropic.Client
+	model  anthropic.Model
+}
+
+// NewAnthropicProvider creates a Claude provider; an empty model selects Claude Sonnet 4
+func NewAnthropicProvider(apiKey string, model string, opts ...option.RequestOption) *AnthropicProvider {
+	if model == "" {
+		model = string(anthropic.ModelClaude4Sonnet20250514)
+	}
+	return &AnthropicProvider{
+		client: anthropic.NewClient(append([]option.RequestOption{option.WithAPIKey(apiKey)}, opts...)...),
+		model:  anthropic.Model(model),
+	}
+}
+
+// Complete streams the next message from Claude
+func (p *AnthropicProvider) Complete(ctx context.Context, request LLMRequest, onDelta func(text string)) (*LLMResponse, error) {
+	messages, err := toAnthropicMessages(request.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens := request.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+	model := p.model
+	if request.Model != "" {
+		model = anthropic.Model(request.Model)
+	}
+	params := anthropic.MessageNewParams{
+		Model:     model,
+		MaxTokens: int64(maxTokens),
+		System: []anthropic.TextBlockParam{
+			{Text: request.SystemPrompt},
+		},
+		Messages: messages,
+	}
+	if request.Temperature != nil {
+		params.Temperature = anthropic.Float(*request.Temperature)
+	}
+	for _, tool := range request.Tools {
+		params.Tools = append(params.Tools, toAnthropicTool(tool))
+	}
+
+	stream := p.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var message anthropic.Message
+	for stream.Next() {
+		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, fmt.Errorf("failed to accumulate Claude stream: %w", err)
+		}
+		if event.Type == "content_block_delta" && event.Delta.Type == "text_delta" && onDelta != nil {
+			onDelta(event.Delta.Text)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to call Claude API: %w", err)
+	}
+
+	return fromAnthropicMessage(message), nil
+}
+
+// toAnthropicMessages converts conversation messages to Anthropic message params
+func toAnthropicMessages(messages []ChatMessage) ([]anthropic.MessageParam, error) {
+	params := make([]anthropic.MessageParam, 0, len(messages))
+	for _, message := range messages {
+		var blocks []anthropic.ContentBlockParamUnion
+		for _, block := range message.Content {
+			switch block.Type {
+			case BlockText:
+				blocks = append(blocks, anthropic.NewTextBlock(block.Text))
+			case BlockThinking:
+				blocks = append(blocks, anthropic.NewThinkingBlock(block.Signature, block.Text))
+			case BlockToolUse:
+				var input interface{} = map[string]interface{}{}
+				if len(block.Input) > 0 && json.Unmarshal(block.Input, &input) != nil {
+					return nil, fmt.Errorf("invalid input for tool call %s", block.ID)
+				}
+				blocks = append(blocks, anthropic.NewToolUseBlock(block.ID, input, block.Name))
+			case BlockToolResult:
+				blocks = append(blocks, anthropic.ContentBlockParamUnion{OfToolResult: &anthropic.ToolResultBlockParam{
+					ToolUseID: block.ToolUseID,
+					IsError:   anthropic.Bool(block.IsError),
+					Content: []anthropic.ToolResultBlockParamContentUnion{
+						{OfText: &anthropic.TextBlockParam{Text: block.Text}},
+					},
+				}})
+			}
+		}
+		if message.Role == RoleAssistant {
+			params = append(params, anthropic.NewAssistantMessage(blocks...))
+		} else {
+			params = append(params, anthropic.NewUserMessage(blocks...))
+		}
+	}
+	return params, nil
+}
+
+// toAnthropicTool converts a tool definition to an Anthropic tool param
+func toAnthropicTool(tool ToolDefinition) anthropic.ToolUnionParam {
+	schema := anthropic.ToolInputSchemaParam{Properties: tool.InputSchema["properties"]}
+	// The schema may have been JSON round-tripped as activity input
+	switch required := tool.InputSchema["required"].(type) {
+	case []string:
+		schema.Required = required
+	case []interface{}:
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	return anthropic.ToolUnionParam{OfTool: &anthropic.ToolParam{
+		Name:        tool.Name,
+		Description: anthropic.String(tool.Description),
+		InputSchema: schema,
+	}}
+}
+
+// fromAnthropicMessage converts a Claude message to a provider-neutral response
+func fromAnthropicMessage(message anthropic.Message) *LLMResponse {
+	response := &LLMResponse{
+		Message:    ChatMessage{Role: RoleAssistant, Content: []ContentBlock{}},
+		StopReason: string(message.StopReason),
+		Usage: LLMUsage{
+			InputTokens:  message.Usage.InputTokens,
+			OutputTokens: message.Usage.OutputTokens,
+		},
+	}
+	for _, block := range message.Content {
+		switch block.Type {
+		case BlockText:
+			response.Message.Content = append(response.Message.Content, ContentBlock{Type: BlockText, Text: block.Text})
+		case BlockThinking:
+			response.Message.Content = append(response.Message.Content, ContentBlock{Type: BlockThinking, Text: block.Thinking, Signature: block.Signature})
+		case BlockToolUse:
+			response.Message.Content = append(response.Message.Content, ContentBlock{
+				Type:  BlockToolUse,
+				ID:    block.ID,
+				Name:  block.Name,
+				Input: block.Input,
+			})
+		}
+	}
+	return response
+}
@@ -0,0 +1,49 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ScriptedProvider is a deterministic LLMProvider that replays scripted responses in order.
+// It records the requests it receives so tests can assert on the conversation sent.
+type ScriptedProvider struct {
+	mu        sync.Mutex
+	responses []LLMResponse
+	requests  []LLMRequest
+}
+
+// NewScriptedProvider creates a provider that returns the responses in order
+func NewScriptedProvider(responses ...LLMResponse) *ScriptedProvider {
+	return &ScriptedProvider{responses: responses}
+}
+
+// NewScriptedTextResponse creates a scripted assistant reply with a single text block
+func NewScriptedTextResponse(text string) LLMResponse {
+	return LLMResponse{Message: NewTextMessage(RoleAssistant, text), StopReason: StopEndTurn}
+}
+
+// Complete returns the next scripted response, or an error once the script is exhausted
+func (p *ScriptedProvider) Complete(ctx context.Context, request LLMRequest, onDelta func(text string)) (*LLMResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests = append(p.requests, request)
+	if len(p.requests) > len(p.responses) {
+		return nil, fmt.Errorf("scripted provider has no response for request %d", len(p.requests))
+	}
+
+	response := p.responses[len(p.requests)-1]
+	if text := response.Message.Text(); text != "" && onDelta != nil {
+		onDelta(text)
+	}
+	return &response, nil
+}
+
+// Requests returns the requests received so far
+func (p *ScriptedProvider) Requests() []LLMRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]LLMRequest(nil), p.requests...)
+}
@@ -0,0 +1,212 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultOpenAIBaseURL points at a local Ollama server, which exposes an OpenAI-compatible API
+const defaultOpenAIBaseURL = "http://localhost:11434/v1"
+
+// OpenAIProvider generates messages with any server implementing the OpenAI Chat Completions API,
+// such as Ollama, vLLM, LM Studio or llama.cpp. It does not request `stream: true`: each reply
+// reaches chat stream subscribers as a single delta once it is complete.
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIProvider creates an OpenAI-compatible provider; an empty base URL targets a local Ollama
+func NewOpenAIProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAIProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Complete requests the next message from the chat completions endpoint. Responses are not
+// streamed; onDelta receives the full text once.
+func (p *OpenAIProvider) Complete(ctx context.Context, request LLMRequest, onDelta func(text string)) (*LLMResponse, error) {
+	body := openAIRequest{
+		Model:       p.model,
+		Messages:    toOpenAIMessages(request.SystemPrompt, request.Messages),
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+	}
+	if request.Model != "" {
+		body.Model = request.Model
+	}
+	if body.MaxTokens == 0 {
+		body.MaxTokens = defaultMaxTokens
+	}
+	for _, tool := range request.Tools {
+		var openAITool openAITool
+		openAITool.Type = "function"
+		openAITool.Function.Name = tool.Name
+		openAITool.Function.Description = tool.Description
+		openAITool.Function.Parameters = tool.InputSchema
+		body.Tools = append(body.Tools, openAITool)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chat completion request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call chat completions API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat completion response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("chat completions API returned %d: %s", resp.StatusCode, respBody)
+	}
+
+	var completion openAIResponse
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return nil, fmt.Errorf("failed to decode chat completion response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from chat completions API")
+	}
+
+	choice := completion.Choices[0]
+	response := &LLMResponse{
+		Message:    ChatMessage{Role: RoleAssistant, Content: []ContentBlock{}},
+		StopReason: StopEndTurn,
+		Usage: LLMUsage{
+			InputTokens:  completion.Usage.PromptTokens,
+			OutputTokens: completion.Usage.CompletionTokens,
+		},
+	}
+	if choice.Message.Content != "" {
+		response.Message.Content = append(response.Message.Content, ContentBlock{Type: BlockText, Text: choice.Message.Content})
+		if onDelta != nil {
+			onDelta(choice.Message.Content)
+		}
+	}
+	for _, call := range choice.Message.ToolCalls {
+		// Arguments are a JSON string that servers do not validate; some send "" for no arguments
+		arguments := call.Function.Arguments
+		if strings.TrimSpace(arguments) == "" {
+			arguments = "{}"
+		}
+		if !json.Valid([]byte(arguments)) {
+			return nil, fmt.Errorf("tool call %s to %s has invalid JSON arguments: %s", call.ID, call.Function.Name, arguments)
+		}
+		response.Message.Content = append(response.Message.Content, ContentBlock{
+			Type:  BlockToolUse,
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: json.RawMessage(arguments),
+		})
+	}
+	switch {
+	case len(choice.Message.ToolCalls) > 0:
+		response.StopReason = StopToolUse
+	case choice.FinishReason == "length":
+		response.StopReason = StopMaxTokens
+	}
+	return response, nil
+}
+
+// toOpenAIMessages converts the conversation to chat completion messages; tool results become
+// "tool" role messages
+func toOpenAIMessages(systemPrompt string, messages []ChatMessage) []openAIMessage {
+	converted := []openAIMessage{{Role: "system", Content: systemPrompt}}
+	for _, message := range messages {
+		var toolResults []openAIMessage
+		converted = append(converted, openAIMessage{Role: message.Role, Content: message.Text()})
+		current := &converted[len(converted)-1]
+
+		for _, block := range message.Content {
+			switch block.Type {
+			case BlockToolUse:
+				var call openAIToolCall
+				call.ID = block.ID
+				call.Type = "function"
+				call.Function.Name = block.Name
+				call.Function.Arguments = string(block.Input)
+				current.ToolCalls = append(current.ToolCalls, call)
+			case BlockToolResult:
+				toolResults = append(toolResults, openAIMessage{Role: "tool", Content: block.Text, ToolCallID: block.ToolUseID})
+			}
+		}
+
+		// A message carrying only tool results is replaced by the tool messages
+		if len(toolResults) > 0 && current.Content == "" && len(current.ToolCalls) == 0 {
+			converted = converted[:len(converted)-1]
+		}
+		converted = append(converted, toolResults...)
+	}
+	return converted
+}
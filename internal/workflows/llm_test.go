@@ -0,0 +1,116 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProvider(t *testing.T) {
+	var received openAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"list_demo_endpoints","arguments":"{}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":7,"completion_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1/", "", "llama3")
+	response, err := provider.Complete(context.Background(), LLMRequest{
+		SystemPrompt: "system",
+		Messages: []ChatMessage{
+			NewTextMessage(RoleUser, "Validate this"),
+			{Role: RoleAssistant, Content: []ContentBlock{{Type: BlockToolUse, ID: "call_0", Name: ToolValidateWorkflow, Input: json.RawMessage(`{"workflow":"x"}`)}}},
+			{Role: RoleUser, Content: []ContentBlock{{Type: BlockToolResult, ToolUseID: "call_0", Text: `{"valid":false}`}}},
+		},
+		Tools: chatbotTools(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if received.Model != "llama3" || len(received.Tools) != 3 {
+		t.Errorf("Unexpected request %+v", received)
+	}
+	roles := ""
+	for _, message := range received.Messages {
+		roles += message.Role + " "
+	}
+	if roles != "system user assistant tool " {
+		t.Errorf("Unexpected message roles: %s", roles)
+	}
+	if calls := received.Messages[2].ToolCalls; len(calls) != 1 || calls[0].Function.Arguments != `{"workflow":"x"}` {
+		t.Errorf("Expected the tool call to be forwarded, got %+v", calls)
+	}
+	if received.Messages[3].ToolCallID != "call_0" {
+		t.Errorf("Expected the tool result to reference its call, got %+v", received.Messages[3])
+	}
+
+	toolUses := response.Message.ToolUses()
+	if response.StopReason != StopToolUse || len(toolUses) != 1 || toolUses[0].Name != ToolListDemoEndpoints {
+		t.Errorf("Unexpected response %+v", response)
+	}
+	if response.Usage.InputTokens != 7 || response.Usage.OutputTokens != 2 {
+		t.Errorf("Unexpected usage %+v", response.Usage)
+	}
+}
+
+func TestOpenAIProviderToolCallArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      string
+		wantErr   bool
+	}{
+		{name: "object", arguments: `{\"workflow\":\"x\"}`, want: `{"workflow":"x"}`},
+		{name: "empty", arguments: ``, want: `{}`},
+		{name: "invalid", arguments: `{\"workflow\":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"validate_workflow","arguments":"` + tt.arguments + `"}}]},"finish_reason":"tool_calls"}]}`))
+			}))
+			defer server.Close()
+
+			response, err := NewOpenAIProvider(server.URL, "", "llama3").Complete(context.Background(), LLMRequest{}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error for invalid tool call arguments")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if toolUses := response.Message.ToolUses(); len(toolUses) != 1 || string(toolUses[0].Input) != tt.want {
+				t.Errorf("Expected arguments %s, got %+v", tt.want, toolUses)
+			}
+		})
+	}
+}
+
+func TestScriptedProviderExhausted(t *testing.T) {
+	provider := NewScriptedProvider(NewScriptedTextResponse("only reply"))
+
+	var deltas string
+	response, err := provider.Complete(context.Background(), LLMRequest{}, func(text string) { deltas += text })
+	if err != nil || response.Message.Text() != "only reply" || deltas != "only reply" {
+		t.Fatalf("Unexpected first response %+v, %v", response, err)
+	}
+	if _, err := provider.Complete(context.Background(), LLMRequest{}, nil); err == nil {
+		t.Error("Expected an error once the script is exhausted")
+	}
+	if len(provider.Requests()) != 2 {
+		t.Errorf("Expected both requests to be recorded, got %d", len(provider.Requests()))
+	}
+}
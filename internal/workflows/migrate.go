@@ -0,0 +1,685 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults for document properties 0.8 definitions do not have
+const (
+	migratedNamespace   = "default"
+	migratedName        = "migrated-workflow"
+	migratedVersion     = "0.0.1"
+	defaultIterationVar = "item"
+	placeholderGRPCHost = "localhost"
+	placeholderGRPCPort = 50051
+)
+
+var (
+	legacyVersionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+	nameUnsafePattern    = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// MigrationIssue is a part of a 0.8 definition that was not translated, or only approximately
+type MigrationIssue struct {
+	Path    string `json:"path"` // JSON pointer into the 0.8 definition
+	Message string `json:"message"`
+}
+
+// MigrationResult is a 0.8 definition migrated to DSL 1.0
+type MigrationResult struct {
+	Workflow string           `json:"workflow"`
+	Format   string           `json:"format"`
+	Issues   []MigrationIssue `json:"issues"`
+}
+
+// IsLegacyWorkflow reports whether a YAML or JSON definition uses the 0.x `states` format
+func IsLegacyWorkflow(source []byte) bool {
+	var definition map[string]interface{}
+	if err := yaml.Unmarshal(source, &definition); err != nil {
+		return false
+	}
+	_, hasStates := definition["states"]
+	_, hasDocument := definition["document"]
+	return hasStates && !hasDocument
+}
+
+// MigrateLegacyWorkflow translates a 0.8 definition (states, functions and transitions) into a
+// DSL 1.0 task list written in the given format. States run in order from the start state;
+// transitions that do not lead to the next state become `then` directives, which are reported as
+// this engine runs tasks in order and does not follow them. Constructs without a 1.0 equivalent
+// are left out and reported.
+func MigrateLegacyWorkflow(source []byte, format string) (*MigrationResult, error) {
+	if format != FormatJSON && format != FormatYAML {
+		return nil, fmt.Errorf("unsupported format %q, expected json or yaml", format)
+	}
+	var definition map[string]interface{}
+	if err := yaml.Unmarshal(source, &definition); err != nil {
+		return nil, fmt.Errorf("failed to read workflow definition: %w", err)
+	}
+	if !IsLegacyWorkflow(source) {
+		return nil, fmt.Errorf("not a 0.8 workflow definition: expected `states` and no `document`")
+	}
+
+	m := &migrator{
+		functions: namedObjects(definition["functions"]),
+		events:    namedObjects(definition["events"]),
+		issues:    []MigrationIssue{},
+	}
+	migrated := m.migrateWorkflow(definition)
+
+	workflowDef, err := buildWorkflow(migrated)
+	if err != nil {
+		return nil, fmt.Errorf("migrated workflow is invalid: %w", err)
+	}
+	written, err := writeWorkflow(workflowDef, format)
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationResult{Workflow: written, Format: format, Issues: m.issues}, nil
+}
+
+// buildWorkflow parses a definition assembled as maps and lists, checking it against the model
+func buildWorkflow(definition map[string]interface{}) (*model.Workflow, error) {
+	encoded, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+	return parser.FromJSONSource(encoded)
+}
+
+// writeWorkflow writes a workflow canonically in the given format
+func writeWorkflow(workflowDef *model.Workflow, format string) (string, error) {
+	canonical, err := json.Marshal(workflowDef)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialise workflow: %w", err)
+	}
+	converted, err := ConvertWorkflow(canonical, format, false)
+	if err != nil {
+		return "", err
+	}
+	return string(converted), nil
+}
+
+type migrator struct {
+	functions map[string]map[string]interface{}
+	events    map[string]map[string]interface{}
+	issues    []MigrationIssue
+}
+
+func (m *migrator) report(path, format string, args ...interface{}) {
+	m.issues = append(m.issues, MigrationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (m *migrator) migrateWorkflow(definition map[string]interface{}) map[string]interface{} {
+	name := migratedWorkflowName(definition)
+	document := map[string]interface{}{
+		"dsl":       "1.0.0",
+		"namespace": migratedNamespace,
+		"name":      name,
+		"version":   m.migrateVersion(stringValue(definition["version"])),
+	}
+	if title := stringValue(definition["name"]); title != "" {
+		document["title"] = title
+	}
+	if summary := stringValue(definition["description"]); summary != "" {
+		document["summary"] = summary
+	}
+	migrated := map[string]interface{}{"document": document}
+
+	if secrets, ok := definition["secrets"].([]interface{}); ok && len(secrets) > 0 {
+		migrated["use"] = map[string]interface{}{"secrets": secrets}
+	}
+	for _, key := range []string{"errors", "retries", "auth", "timeouts"} {
+		if _, ok := definition[key]; ok {
+			m.report("/"+key, "`%s` definitions are not migrated", key)
+		}
+	}
+	if _, ok := definition["functions"].(string); ok {
+		m.report("/functions", "functions defined in an external file are not migrated")
+	}
+
+	states, _ := definition["states"].([]interface{})
+	migrated["do"] = m.migrateStates(states, startStateName(definition["start"]))
+	return migrated
+}
+
+// migrateStates orders the states by following the transitions from the start state and
+// translates each to a task
+func (m *migrator) migrateStates(states []interface{}, start string) []interface{} {
+	byName := map[string]int{}
+	for i, raw := range states {
+		state, ok := raw.(map[string]interface{})
+		if !ok {
+			m.report(fmt.Sprintf("/states/%d", i), "state is not an object")
+			continue
+		}
+		if name := stringValue(state["name"]); name != "" {
+			byName[name] = i
+		}
+	}
+
+	var order []int
+	visited := map[int]bool{}
+	var visit func(i int)
+	visit = func(i int) {
+		state, ok := states[i].(map[string]interface{})
+		if visited[i] || !ok {
+			return
+		}
+		visited[i] = true
+		order = append(order, i)
+		for _, next := range stateTransitions(state) {
+			if j, ok := byName[next]; ok {
+				visit(j)
+			}
+		}
+	}
+	if i, ok := byName[start]; ok {
+		visit(i)
+	}
+	// States not reachable from the start keep their declaration order
+	for i := range states {
+		visit(i)
+	}
+
+	tasks := []interface{}{}
+	var names []string
+	var thens []string
+	var jumps [][]stateJump
+	for _, i := range order {
+		state := states[i].(map[string]interface{})
+		path := fmt.Sprintf("/states/%d", i)
+		name := stringValue(state["name"])
+		if name == "" {
+			name = fmt.Sprintf("state%d", i+1)
+		}
+		task, ok := m.migrateState(state, path)
+		if !ok {
+			continue
+		}
+		tasks = append(tasks, map[string]interface{}{name: task})
+		names = append(names, name)
+		thens = append(thens, m.flowDirective(state, path))
+		jumps = append(jumps, stateJumps(state, path))
+	}
+
+	for i, stateJumps := range jumps {
+		last := i == len(names)-1
+		for _, jump := range stateJumps {
+			if jump.target == "continue" || (jump.target == "end" && last) || (!last && jump.target == names[i+1]) {
+				continue
+			}
+			m.report(jump.path, "`then: %s` is not followed by this engine, which runs tasks in order", jump.target)
+		}
+	}
+
+	// Transitions to the following task are implicit
+	for i, then := range thens {
+		if then == "" || (i+1 < len(names) && then == names[i+1]) {
+			continue
+		}
+		task := tasks[i].(map[string]interface{})[names[i]].(map[string]interface{})
+		task["then"] = then
+	}
+	return tasks
+}
+
+// migrateState translates a state to the body of a task
+func (m *migrator) migrateState(state map[string]interface{}, path string) (map[string]interface{}, bool) {
+	var task map[string]interface{}
+	switch stateType := stringValue(state["type"]); stateType {
+	case "operation":
+		actions := m.migrateActions(state["actions"], path+"/actions")
+		switch {
+		case len(actions) == 0:
+			m.report(path, "operation state has no actions that could be migrated")
+			task = map[string]interface{}{"set": map[string]interface{}{}}
+		case stringValue(state["actionMode"]) == "parallel":
+			task = map[string]interface{}{"fork": map[string]interface{}{"branches": actions}}
+		case len(actions) == 1:
+			for _, body := range actions[0].(map[string]interface{}) {
+				task = body.(map[string]interface{})
+			}
+		default:
+			task = map[string]interface{}{"do": actions}
+		}
+	case "switch":
+		task = m.migrateSwitch(state, path)
+	case "parallel":
+		task = m.migrateParallel(state, path)
+	case "foreach":
+		task = m.migrateForEach(state, path)
+	case "sleep":
+		task = map[string]interface{}{"wait": stringValue(state["duration"])}
+	case "event":
+		task = m.migrateEventState(state, path)
+	case "inject":
+		data, _ := state["data"].(map[string]interface{})
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		task = map[string]interface{}{"set": data}
+	default:
+		m.report(path, "%q states are not supported and were left out", stateType)
+		return nil, false
+	}
+
+	if filter, ok := state["stateDataFilter"].(map[string]interface{}); ok {
+		if input := stringValue(filter["input"]); input != "" {
+			task["input"] = map[string]interface{}{"from": runtimeExpression(input)}
+		}
+		if output := stringValue(filter["output"]); output != "" {
+			task["output"] = map[string]interface{}{"as": runtimeExpression(output)}
+		}
+	}
+	for _, key := range []string{"onErrors", "timeouts", "compensatedBy", "usedForCompensation"} {
+		if _, ok := state[key]; ok {
+			m.report(path+"/"+key, "`%s` is not migrated", key)
+		}
+	}
+	return task, true
+}
+
+// flowDirective returns the `then` of a state: its transition, end, or nothing
+func (m *migrator) flowDirective(state map[string]interface{}, path string) string {
+	if end, ok := state["end"]; ok && end != false {
+		if endObject, ok := end.(map[string]interface{}); ok && (endObject["compensate"] == true || endObject["continueAs"] != nil || len(toList(endObject["produceEvents"])) > 0) {
+			m.report(path+"/end", "end compensation, continueAs and produceEvents are not migrated")
+		}
+		return "end"
+	}
+	return transitionTarget(state["transition"])
+}
+
+func (m *migrator) migrateSwitch(state map[string]interface{}, path string) map[string]interface{} {
+	cases := []interface{}{}
+	for i, raw := range toList(state["dataConditions"]) {
+		condition, _ := raw.(map[string]interface{})
+		conditionPath := fmt.Sprintf("%s/dataConditions/%d", path, i)
+		name := stringValue(condition["name"])
+		if name == "" {
+			name = fmt.Sprintf("case%d", i+1)
+		}
+		switchCase := map[string]interface{}{
+			"when": runtimeExpression(stringValue(condition["condition"])),
+			"then": m.conditionTarget(condition, conditionPath),
+		}
+		cases = append(cases, map[string]interface{}{name: switchCase})
+	}
+	if len(toList(state["eventConditions"])) > 0 {
+		m.report(path+"/eventConditions", "event conditions are not supported; use a listen task before the switch")
+	}
+	if defaultCondition, ok := state["defaultCondition"].(map[string]interface{}); ok {
+		cases = append(cases, map[string]interface{}{"default": map[string]interface{}{
+			"then": m.conditionTarget(defaultCondition, path+"/defaultCondition"),
+		}})
+	}
+	return map[string]interface{}{"switch": cases}
+}
+
+// conditionTarget returns the flow directive of a switch condition
+func (m *migrator) conditionTarget(condition map[string]interface{}, path string) string {
+	if end, ok := condition["end"]; ok && end != false {
+		return "end"
+	}
+	if target := transitionTarget(condition["transition"]); target != "" {
+		return target
+	}
+	m.report(path, "condition has no transition or end; continuing with the next task")
+	return "continue"
+}
+
+func (m *migrator) migrateParallel(state map[string]interface{}, path string) map[string]interface{} {
+	branches := []interface{}{}
+	for i, raw := range toList(state["branches"]) {
+		branch, _ := raw.(map[string]interface{})
+		branchPath := fmt.Sprintf("%s/branches/%d", path, i)
+		name := stringValue(branch["name"])
+		if name == "" {
+			name = fmt.Sprintf("branch%d", i+1)
+		}
+		actions := m.migrateActions(branch["actions"], branchPath+"/actions")
+		branches = append(branches, map[string]interface{}{name: map[string]interface{}{"do": actions}})
+	}
+
+	fork := map[string]interface{}{"branches": branches}
+	if stringValue(state["completionType"]) == "atLeast" {
+		fork["compete"] = true
+		if completed := fmt.Sprint(state["numCompleted"]); completed != "1" {
+			m.report(path+"/numCompleted", "fork competes on the first branch to complete, not %s", completed)
+		}
+	}
+	return map[string]interface{}{"fork": fork}
+}
+
+func (m *migrator) migrateForEach(state map[string]interface{}, path string) map[string]interface{} {
+	each := stringValue(state["iterationParam"])
+	if each == "" {
+		each = defaultIterationVar
+	}
+	forConfig := map[string]interface{}{"each": each, "in": runtimeExpression(stringValue(state["inputCollection"]))}
+	if at := stringValue(state["indexParameter"]); at != "" {
+		forConfig["at"] = at
+	}
+	if stringValue(state["mode"]) != "sequential" {
+		m.report(path+"/mode", "iterations run sequentially; 0.8 foreach states default to parallel")
+	}
+	if _, ok := state["outputCollection"]; ok {
+		m.report(path+"/outputCollection", "outputCollection is not migrated")
+	}
+	return map[string]interface{}{
+		"for": forConfig,
+		"do":  m.migrateActions(state["actions"], path+"/actions"),
+	}
+}
+
+// migrateEventState turns the consumed events into a listen task, followed by the event actions
+func (m *migrator) migrateEventState(state map[string]interface{}, path string) map[string]interface{} {
+	var filters []interface{}
+	var actions []interface{}
+	for i, raw := range toList(state["onEvents"]) {
+		onEvent, _ := raw.(map[string]interface{})
+		onEventPath := fmt.Sprintf("%s/onEvents/%d", path, i)
+		for _, ref := range toList(onEvent["eventRefs"]) {
+			filters = append(filters, m.eventFilter(stringValue(ref), onEventPath+"/eventRefs"))
+		}
+		actions = append(actions, m.migrateActions(onEvent["actions"], onEventPath+"/actions")...)
+	}
+
+	to := map[string]interface{}{}
+	switch {
+	case len(filters) == 1:
+		to["one"] = filters[0]
+	case state["exclusive"] == false:
+		to["all"] = filters
+	default:
+		to["any"] = filters
+	}
+	listen := map[string]interface{}{"listen": map[string]interface{}{"to": to}}
+	if len(actions) == 0 {
+		return listen
+	}
+	return map[string]interface{}{"do": append([]interface{}{map[string]interface{}{"listen": listen}}, actions...)}
+}
+
+// eventFilter builds a listen filter from a named event definition
+func (m *migrator) eventFilter(ref, path string) map[string]interface{} {
+	with := map[string]interface{}{}
+	event, ok := m.events[ref]
+	if !ok {
+		m.report(path, "event %q is not defined", ref)
+		with["type"] = ref
+		return map[string]interface{}{"with": with}
+	}
+	if eventType := stringValue(event["type"]); eventType != "" {
+		with["type"] = eventType
+	}
+	if source := stringValue(event["source"]); strings.Contains(source, ":") {
+		with["source"] = source
+	} else if source != "" {
+		m.report(path, "event %q source %q is not an absolute URI and was left out", ref, source)
+	}
+	if len(with) == 0 {
+		with["type"] = ref
+	}
+	return map[string]interface{}{"with": with}
+}
+
+// migrateActions translates actions to a task list, leaving out those that cannot be migrated
+func (m *migrator) migrateActions(raw interface{}, path string) []interface{} {
+	tasks := []interface{}{}
+	for i, item := range toList(raw) {
+		action, _ := item.(map[string]interface{})
+		actionPath := fmt.Sprintf("%s/%d", path, i)
+		name := stringValue(action["name"])
+		if name == "" {
+			name = fmt.Sprintf("action%d", i+1)
+		}
+		if task, ok := m.migrateAction(action, actionPath); ok {
+			tasks = append(tasks, map[string]interface{}{name: task})
+		}
+	}
+	return tasks
+}
+
+func (m *migrator) migrateAction(action map[string]interface{}, path string) (map[string]interface{}, bool) {
+	var task map[string]interface{}
+	switch {
+	case action["functionRef"] != nil:
+		var ok bool
+		if task, ok = m.migrateFunctionRef(action["functionRef"], path+"/functionRef"); !ok {
+			return nil, false
+		}
+	case action["subFlowRef"] != nil:
+		m.report(path+"/subFlowRef", "sub-flows are not supported and were left out")
+		return nil, false
+	case action["eventRef"] != nil:
+		m.report(path+"/eventRef", "event-triggered actions are not supported and were left out")
+		return nil, false
+	default:
+		m.report(path, "action has no function reference and was left out")
+		return nil, false
+	}
+
+	if condition := stringValue(action["condition"]); condition != "" {
+		task["if"] = runtimeExpression(condition)
+	}
+	if filter, ok := action["actionDataFilter"].(map[string]interface{}); ok {
+		if results := stringValue(filter["results"]); results != "" {
+			task["output"] = map[string]interface{}{"as": runtimeExpression(results)}
+		}
+		if _, ok := filter["toStateData"]; ok {
+			m.report(path+"/actionDataFilter/toStateData", "toStateData is not migrated; results are stored under the task name")
+		}
+	}
+	for _, key := range []string{"sleep", "retryRef", "nonRetryableErrors", "retryableErrors"} {
+		if _, ok := action[key]; ok {
+			m.report(path+"/"+key, "`%s` is not migrated", key)
+		}
+	}
+	return task, true
+}
+
+// migrateFunctionRef translates a function call according to the type of the referenced function
+func (m *migrator) migrateFunctionRef(raw interface{}, path string) (map[string]interface{}, bool) {
+	refName := stringValue(raw)
+	var arguments map[string]interface{}
+	if ref, ok := raw.(map[string]interface{}); ok {
+		refName = stringValue(ref["refName"])
+		arguments, _ = ref["arguments"].(map[string]interface{})
+	}
+	function, ok := m.functions[refName]
+	if !ok {
+		m.report(path, "function %q is not defined", refName)
+		return nil, false
+	}
+
+	operation := stringValue(function["operation"])
+	functionType := stringValue(function["type"])
+	if functionType == "" {
+		functionType = "rest"
+	}
+	switch functionType {
+	case "rest", "openapi":
+		document, operationID, found := strings.Cut(operation, "#")
+		if !found {
+			m.report(path, "function %q operation %q is not of the form <openapi document>#<operationId>", refName, operation)
+			return nil, false
+		}
+		with := map[string]interface{}{"document": map[string]interface{}{"endpoint": document}, "operationId": operationID}
+		if arguments != nil {
+			with["parameters"] = arguments
+		}
+		return map[string]interface{}{"call": "openapi", "with": with}, true
+	case "rpc":
+		parts := strings.Split(operation, "#")
+		if len(parts) != 3 {
+			m.report(path, "function %q operation %q is not of the form <proto>#<service>#<method>", refName, operation)
+			return nil, false
+		}
+		m.report(path, "gRPC function %q uses the placeholder host %s:%d", refName, placeholderGRPCHost, placeholderGRPCPort)
+		with := map[string]interface{}{
+			"proto":   map[string]interface{}{"endpoint": parts[0]},
+			"service": map[string]interface{}{"name": parts[1], "host": placeholderGRPCHost, "port": placeholderGRPCPort},
+			"method":  parts[2],
+		}
+		if arguments != nil {
+			with["arguments"] = arguments
+		}
+		return map[string]interface{}{"call": "grpc", "with": with}, true
+	case "asyncapi":
+		document, operationID, found := strings.Cut(operation, "#")
+		if !found {
+			m.report(path, "function %q operation %q is not of the form <asyncapi document>#<operationId>", refName, operation)
+			return nil, false
+		}
+		with := map[string]interface{}{"document": map[string]interface{}{"endpoint": document}, "operation": operationID}
+		if arguments != nil {
+			with["message"] = map[string]interface{}{"payload": arguments}
+		}
+		return map[string]interface{}{"call": "asyncapi", "with": with}, true
+	case "expression":
+		// The expression result is stored under the function name
+		return map[string]interface{}{"set": map[string]interface{}{refName: runtimeExpression(operation)}}, true
+	default:
+		m.report(path, "%s function %q is not supported and was left out", functionType, refName)
+		return nil, false
+	}
+}
+
+// runtimeExpression wraps a 0.8 expression, which may be written without `${ }`, as a 1.0 runtime
+// expression; 1.0 reads strings outside `${ }` as literals
+func runtimeExpression(expression string) string {
+	expression = strings.TrimSpace(expression)
+	if expression == "" || model.IsStrictExpr(expression) {
+		return expression
+	}
+	return "${ " + expression + " }"
+}
+
+// migrateVersion pads numeric versions such as 1.0 to semantic versions
+func (m *migrator) migrateVersion(version string) string {
+	if !legacyVersionPattern.MatchString(version) {
+		if version != "" {
+			m.report("/version", "version %q is not a semantic version; using %s", version, migratedVersion)
+		}
+		return migratedVersion
+	}
+	for strings.Count(version, ".") < 2 {
+		version += ".0"
+	}
+	return version
+}
+
+// migratedWorkflowName derives a 1.0 name (lowercase letters, digits and dashes) from the id or name
+func migratedWorkflowName(definition map[string]interface{}) string {
+	for _, key := range []string{"id", "key", "name"} {
+		name := strings.Trim(nameUnsafePattern.ReplaceAllString(strings.ToLower(stringValue(definition[key])), "-"), "-")
+		if name != "" {
+			return name
+		}
+	}
+	return migratedName
+}
+
+// stateTransitions lists the states a state can transition to, in order
+func stateTransitions(state map[string]interface{}) []string {
+	var targets []string
+	if target := transitionTarget(state["transition"]); target != "" {
+		targets = append(targets, target)
+	}
+	conditions := append(toList(state["dataConditions"]), toList(state["eventConditions"])...)
+	if defaultCondition, ok := state["defaultCondition"]; ok {
+		conditions = append(conditions, defaultCondition)
+	}
+	for _, raw := range conditions {
+		if condition, ok := raw.(map[string]interface{}); ok {
+			if target := transitionTarget(condition["transition"]); target != "" {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// stateJump is a transition or end of a state, with the path it is defined at
+type stateJump struct {
+	path   string
+	target string // a state name, "end", or "continue" for a condition without either
+}
+
+// stateJumps lists the transitions and ends of a state and of its switch conditions
+func stateJumps(state map[string]interface{}, path string) []stateJump {
+	jump := func(owner map[string]interface{}, ownerPath string) stateJump {
+		if end, ok := owner["end"]; ok && end != false {
+			return stateJump{path: ownerPath + "/end", target: "end"}
+		}
+		if target := transitionTarget(owner["transition"]); target != "" {
+			return stateJump{path: ownerPath + "/transition", target: target}
+		}
+		return stateJump{path: ownerPath, target: "continue"}
+	}
+
+	jumps := []stateJump{jump(state, path)}
+	for i, raw := range toList(state["dataConditions"]) {
+		if condition, ok := raw.(map[string]interface{}); ok {
+			jumps = append(jumps, jump(condition, fmt.Sprintf("%s/dataConditions/%d", path, i)))
+		}
+	}
+	if condition, ok := state["defaultCondition"].(map[string]interface{}); ok {
+		jumps = append(jumps, jump(condition, path+"/defaultCondition"))
+	}
+	return jumps
+}
+
+// startStateName returns the start state, given as a name or as {stateName: ...}
+func startStateName(start interface{}) string {
+	if object, ok := start.(map[string]interface{}); ok {
+		return stringValue(object["stateName"])
+	}
+	return stringValue(start)
+}
+
+// transitionTarget returns the next state of a transition, given as a name or as {nextState: ...}
+func transitionTarget(transition interface{}) string {
+	if object, ok := transition.(map[string]interface{}); ok {
+		return stringValue(object["nextState"])
+	}
+	return stringValue(transition)
+}
+
+// namedObjects indexes a list of objects by their name
+func namedObjects(raw interface{}) map[string]map[string]interface{} {
+	objects := map[string]map[string]interface{}{}
+	for _, item := range toList(raw) {
+		if object, ok := item.(map[string]interface{}); ok {
+			objects[stringValue(object["name"])] = object
+		}
+	}
+	return objects
+}
+
+func toList(raw interface{}) []interface{} {
+	list, _ := raw.([]interface{})
+	return list
+}
+
+func stringValue(raw interface{}) string {
+	switch value := raw.(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,251 @@
+package workflows
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestMigrateLegacyWorkflow(t *testing.T) {
+	source, err := os.ReadFile("../../test_data/legacy-order.sw.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+	if !IsLegacyWorkflow(source) {
+		t.Fatal("Expected a legacy workflow")
+	}
+
+	result, err := MigrateLegacyWorkflow(source, FormatYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if IsLegacyWorkflow([]byte(result.Workflow)) {
+		t.Error("Expected the migrated workflow not to be legacy")
+	}
+	workflowDef, err := parser.FromYAMLSource([]byte(result.Workflow))
+	if err != nil {
+		t.Fatalf("Migrated workflow is invalid: %v\n%s", err, result.Workflow)
+	}
+	if workflowDef.Document.Name != "order-processing" || workflowDef.Document.Version != "1.0.0" || workflowDef.Document.Title != "Order Processing" {
+		t.Errorf("Unexpected document %+v", workflowDef.Document)
+	}
+
+	tasks := *workflowDef.Do
+	expected := []struct{ name, taskType, then string }{
+		{"CheckOrder", "call:openapi", ""},
+		{"Route", "switch", ""},
+		{"Review", "wait", ""},
+		{"Charge", "fork", ""},
+		{"PerItem", "for", ""},
+		{"Done", "set", ""},
+		{"Ship", "listen", "end"},
+	}
+	if len(tasks) != len(expected) {
+		t.Fatalf("Expected %d tasks, got %d:\n%s", len(expected), len(tasks), result.Workflow)
+	}
+	for i, task := range tasks {
+		then := ""
+		if base := task.GetBase(); base != nil && base.Then != nil {
+			then = base.Then.Value
+		}
+		if task.Key != expected[i].name || taskTypeName(task) != expected[i].taskType || then != expected[i].then {
+			t.Errorf("Task %d: expected %+v, got %s %s then %q", i, expected[i], task.Key, taskTypeName(task), then)
+		}
+	}
+	for _, fragment := range []string{
+		"operationId: getOrder",
+		"as: ${ .order }",
+		"when: ${ .order.total > 100 }\n            then: Review",
+		"- case2:\n            when: ${ .order.express }\n            then: end",
+		"- default:\n            then: Charge",
+		"port: 50051",
+		"each: line",
+		"total: ${ .items | map(.price) | add }",
+		"type: com.example.order.shipped",
+	} {
+		if !strings.Contains(result.Workflow, fragment) {
+			t.Errorf("Expected the migrated workflow to contain %q:\n%s", fragment, result.Workflow)
+		}
+	}
+
+	issues := map[string]bool{}
+	for _, issue := range result.Issues {
+		issues[issue.Path] = true
+	}
+	for _, path := range []string{
+		"/states/4/branches/0/actions/0/functionRef",
+		"/states/4/branches/1/actions/1/functionRef",
+		"/states/7",
+		"/states/2/dataConditions/1/end",
+		"/states/2/defaultCondition/transition",
+	} {
+		if !issues[path] {
+			t.Errorf("Expected an issue at %s, got %+v", path, result.Issues)
+		}
+	}
+}
+
+func TestMigrateLegacyWorkflowJSON(t *testing.T) {
+	source := `{"id": "hello", "version": "2", "specVersion": "0.8", "start": "Greet",
+		"states": [{"name": "Greet", "type": "inject", "data": {"greeting": "hello"}, "end": true}]}`
+
+	result, err := MigrateLegacyWorkflow([]byte(source), FormatJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{"document":{"dsl":"1.0.0","namespace":"default","name":"hello","version":"2.0.0"},"do":[{"Greet":{"then":"end","set":{"greeting":"hello"}}}]}`
+	if output := strings.Join(strings.Fields(result.Workflow), ""); output != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, output)
+	}
+
+	if _, err := MigrateLegacyWorkflow([]byte("document:\n  dsl: 1.0.0\ndo: []\n"), FormatYAML); err == nil {
+		t.Error("Expected an error for a 1.0 definition")
+	}
+}
+
+func TestExecuteMigratedWorkflow(t *testing.T) {
+	source := `id: totals
+version: '1.0'
+specVersion: '0.8'
+start: Prepare
+functions:
+  - name: total
+    type: expression
+    operation: ${ .items | map(.price) | add }
+states:
+  - name: Prepare
+    type: inject
+    data:
+      items:
+        - price: 2
+        - price: 3
+    transition: Sum
+  - name: Sum
+    type: operation
+    actions:
+      - functionRef: total
+    transition: PerItem
+  - name: PerItem
+    type: foreach
+    inputCollection: ${ .items }
+    iterationParam: line
+    mode: sequential
+    actions:
+      - name: tally
+        functionRef: total
+    end: true
+`
+	result, err := MigrateLegacyWorkflow([]byte(source), FormatYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if issues := LintWorkflowSource([]byte(result.Workflow), LintOptions{Disabled: []string{LintUndefinedReference}}); HasLintErrors(issues) {
+		t.Fatalf("Expected the migrated workflow to run on this engine, got %+v\n%s", issues, result.Workflow)
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(EvaluateExpressionActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, result.Workflow, nil)
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("Expected workflow to complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v\n%s", err, result.Workflow)
+	}
+	var state map[string]interface{}
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	sum, _ := state["Sum"].(map[string]interface{})
+	if sum["total"] != float64(5) {
+		t.Errorf("Expected the expression function to total the items, got %v\n%s", state["Sum"], result.Workflow)
+	}
+	if _, ok := state["PerItem"]; !ok {
+		t.Errorf("Expected the foreach state to run, got %v", state)
+	}
+}
+
+func TestMigrateBareExpressions(t *testing.T) {
+	source := `id: applicants
+version: '1.0'
+specVersion: '0.8'
+start: Check
+functions:
+  - name: isAdult
+    type: expression
+    operation: .applicant.age >= 18
+states:
+  - name: Check
+    type: operation
+    actions:
+      - functionRef: isAdult
+        condition: .applicant.age > 0
+        actionDataFilter:
+          results: .isAdult
+    stateDataFilter:
+      input: .
+    transition: Route
+  - name: Route
+    type: switch
+    dataConditions:
+      - name: adult
+        condition: .Check
+        transition: Visit
+    defaultCondition:
+      transition: Visit
+  - name: Visit
+    type: foreach
+    inputCollection: .applicant.tags
+    iterationParam: tag
+    mode: sequential
+    actions:
+      - functionRef: isAdult
+    end: true
+`
+	result, err := MigrateLegacyWorkflow([]byte(source), FormatYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, fragment := range []string{
+		"isAdult: ${ .applicant.age >= 18 }",
+		"if: ${ .applicant.age > 0 }",
+		"as: ${ .isAdult }",
+		"from: ${ . }",
+		"when: ${ .Check }",
+		"in: ${ .applicant.tags }",
+	} {
+		if !strings.Contains(result.Workflow, fragment) {
+			t.Errorf("Expected the migrated workflow to contain %q:\n%s", fragment, result.Workflow)
+		}
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(EvaluateExpressionActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, result.Workflow, map[string]interface{}{
+		"applicant": map[string]interface{}{"age": 21, "tags": []interface{}{"a"}},
+	})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v\n%s", err, result.Workflow)
+	}
+	var state map[string]interface{}
+	if err := env.GetWorkflowResult(&state); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	check, _ := state["Check"].(map[string]interface{})
+	if check["isAdult"] != true {
+		t.Errorf("Expected the bare expression to be evaluated, got %v\n%s", state["Check"], result.Workflow)
+	}
+}
@@ -0,0 +1,65 @@
+package workflows
+
+import (
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"sigs.k8s.io/yaml"
+)
+
+// Custom search attributes attached to executions at start time. They must be registered on the
+// Temporal namespace, e.g. `temporal operator search-attribute create --name SwKind --type Keyword`.
+var (
+	SearchAttributeKind                = temporal.NewSearchAttributeKeyKeyword("SwKind")
+	SearchAttributeDefinitionNamespace = temporal.NewSearchAttributeKeyKeyword("SwDefinitionNamespace")
+	SearchAttributeDefinitionName      = temporal.NewSearchAttributeKeyKeyword("SwDefinitionName")
+	SearchAttributeDefinitionVersion   = temporal.NewSearchAttributeKeyKeyword("SwDefinitionVersion")
+)
+
+// Execution kinds stored in the SwKind search attribute
+const (
+	ExecutionKindServerless = "serverless"
+	ExecutionKindChatbot    = "chatbot"
+)
+
+// ServerlessSearchAttributes returns the search attributes for a serverless workflow execution.
+// The definition's document metadata is read leniently so that invalid definitions are still
+// tagged; validation happens inside the workflow.
+func ServerlessSearchAttributes(source []byte) temporal.SearchAttributes {
+	updates := []temporal.SearchAttributeUpdate{
+		SearchAttributeKind.ValueSet(ExecutionKindServerless),
+	}
+
+	var definition struct {
+		Document struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+			Version   string `json:"version"`
+		} `json:"document"`
+	}
+	if err := yaml.Unmarshal(source, &definition); err == nil {
+		if definition.Document.Namespace != "" {
+			updates = append(updates, SearchAttributeDefinitionNamespace.ValueSet(definition.Document.Namespace))
+		}
+		if definition.Document.Name != "" {
+			updates = append(updates, SearchAttributeDefinitionName.ValueSet(definition.Document.Name))
+		}
+		if definition.Document.Version != "" {
+			updates = append(updates, SearchAttributeDefinitionVersion.ValueSet(definition.Document.Version))
+		}
+	}
+
+	return temporal.NewSearchAttributes(updates...)
+}
+
+// ChatbotSearchAttributes returns the search attributes for a chatbot thread
+func ChatbotSearchAttributes() temporal.SearchAttributes {
+	return temporal.NewSearchAttributes(SearchAttributeKind.ValueSet(ExecutionKindChatbot))
+}
+
+// searchAttributesRegistered reports whether the current execution was started with its SwKind
+// search attribute. The API starts executions without their attributes when the namespace rejects
+// them, so this tells workflow code whether the child executions it starts can be tagged.
+func searchAttributesRegistered(ctx workflow.Context) bool {
+	_, ok := workflow.GetTypedSearchAttributes(ctx).GetKeyword(SearchAttributeKind)
+	return ok
+}
@@ -0,0 +1,337 @@
+package workflows
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationIssue is a schema error located in the source of a workflow definition
+type ValidationIssue struct {
+	Path    string `json:"path"`   // JSON pointer to the offending node
+	Line    int    `json:"line"`   // 1-based line of the node, or of its parent when the node is missing
+	Column  int    `json:"column"` // 1-based column
+	Message string `json:"message"`
+	Keyword string `json:"keyword"` // schema keyword that failed, e.g. required or pattern
+}
+
+// concreteTaskTypes are the task type names the parser's task validation adds to error namespaces;
+// those errors duplicate the ones reported through the task item itself
+var concreteTaskTypes = map[string]bool{
+	"CallHTTP": true, "CallOpenAPI": true, "CallGRPC": true, "CallAsyncAPI": true, "CallFunction": true,
+	"DoTask": true, "ForkTask": true, "EmitTask": true, "ForTask": true, "ListenTask": true, "RaiseTask": true,
+	"RunTask": true, "SetTask": true, "SwitchTask": true, "TryTask": true, "WaitTask": true,
+}
+
+// validatorKeywords maps the parser's validation tags to JSON schema keywords
+var validatorKeywords = map[string]string{
+	"required":                     "required",
+	"required_without":             "required",
+	"required_without_all":         "required",
+	"oneof":                        "enum",
+	"oneofci":                      "enum",
+	"eq":                           "const",
+	"semver_pattern":               "pattern",
+	"iso8601_duration":             "pattern",
+	"uri_pattern":                  "pattern",
+	"uri_template_pattern":         "pattern",
+	"hostname_rfc1123":             "format",
+	"switch_item":                  "maxProperties",
+	"basic_policy":                 "oneOf",
+	"bearer_policy":                "oneOf",
+	"digest_policy":                "oneOf",
+	"oauth2_policy":                "oneOf",
+	"object_or_string":             "oneOf",
+	"object_or_runtime_expr":       "oneOf",
+	"string_or_runtime_expr":       "oneOf",
+	"uri_template_or_runtime_expr": "oneOf",
+	"json_pointer_or_runtime_expr": "oneOf",
+}
+
+// patternNames describe the values the pattern tags expect
+var patternNames = map[string]string{
+	"semver_pattern":       "semantic version",
+	"iso8601_duration":     "ISO 8601 duration",
+	"uri_pattern":          "URI",
+	"uri_template_pattern": "URI template",
+	"hostname_rfc1123":     "RFC 1123 hostname",
+}
+
+var (
+	yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+	taskNamePattern = regexp.MustCompile(`(?:task|key) '([^']+)'`)
+)
+
+// ValidateWorkflowSource parses a YAML or JSON definition with the sdk-go parser and returns its
+// errors located in the source. A valid definition returns no issues.
+func ValidateWorkflowSource(source []byte) []ValidationIssue {
+	var root yaml.Node
+	if err := yaml.Unmarshal(source, &root); err != nil {
+		return []ValidationIssue{syntaxIssue(err)}
+	}
+	document := &root
+	if len(root.Content) > 0 {
+		document = root.Content[0]
+	}
+
+	_, err := parser.FromYAMLSource(source)
+	if err == nil {
+		return []ValidationIssue{}
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []ValidationIssue{decodeIssue(document, err)}
+	}
+
+	issues := []ValidationIssue{}
+	seen := map[string]bool{}
+	for _, fieldError := range validationErrors {
+		segments := strings.Split(strings.TrimPrefix(fieldError.Namespace(), "Workflow."), ".")
+		if hasConcreteTaskSegment(segments) {
+			continue
+		}
+		issue := fieldIssue(document, segments, fieldError)
+		if key := issue.Path + " " + issue.Keyword; !seen[key] {
+			seen[key] = true
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// syntaxIssue reports a source that is not YAML or JSON
+func syntaxIssue(err error) ValidationIssue {
+	issue := ValidationIssue{Line: 1, Column: 1, Message: err.Error(), Keyword: "syntax"}
+	if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		issue.Line, _ = strconv.Atoi(match[1])
+	}
+	return issue
+}
+
+// decodeIssue locates an error raised while decoding the definition into the model
+func decodeIssue(document *yaml.Node, err error) ValidationIssue {
+	issue := ValidationIssue{Message: err.Error(), Keyword: "type"}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		if typeError.Field != "" {
+			issue.Path = "/" + strings.ReplaceAll(typeError.Field, ".", "/")
+		}
+	} else if matches := taskNamePattern.FindAllStringSubmatch(err.Error(), -1); matches != nil {
+		// Task errors name the tasks they are nested in, outermost first
+		node := document
+		for _, match := range matches {
+			path, task := findTask(node, match[1])
+			if task == nil {
+				break
+			}
+			issue.Path += path
+			node = task
+		}
+		if strings.Contains(err.Error(), "unknown task type") {
+			issue.Keyword = "oneOf"
+		}
+	}
+
+	issue.Line, issue.Column = locate(document, issue.Path)
+	return issue
+}
+
+// fieldIssue converts a validation error to an issue, walking the source along the error's
+// namespace, e.g. Do[0].Task.With.Endpoint
+func fieldIssue(document *yaml.Node, segments []string, fieldError validator.FieldError) ValidationIssue {
+	node := document
+	path := ""
+	property := ""
+	for i, segment := range segments {
+		name, index := splitNamespaceSegment(segment)
+		if name == "Task" {
+			// The value of a task item is the task under its name
+			if node.Kind == yaml.MappingNode && len(node.Content) == 2 {
+				path += "/" + escapePointer(node.Content[0].Value)
+				node = node.Content[1]
+			}
+			continue
+		}
+
+		key, child := mappingEntryFold(node, name)
+		if child == nil {
+			// Embedded structs have no key of their own; a missing last field is the error itself
+			if i == len(segments)-1 && node.Kind == yaml.MappingNode {
+				property = lowerFirst(name)
+				path += "/" + escapePointer(property)
+			}
+			continue
+		}
+		path += "/" + escapePointer(key)
+		node = child
+		if index >= 0 && node.Kind == yaml.SequenceNode && index < len(node.Content) {
+			path += "/" + strconv.Itoa(index)
+			node = node.Content[index]
+		}
+	}
+
+	line, column := locate(document, path)
+	return ValidationIssue{
+		Path:    path,
+		Line:    line,
+		Column:  column,
+		Message: validationMessage(fieldError, node, property),
+		Keyword: validationKeyword(fieldError),
+	}
+}
+
+// validationMessage describes a failed validation tag
+func validationMessage(fieldError validator.FieldError, node *yaml.Node, property string) string {
+	value := ""
+	if node.Kind == yaml.ScalarNode {
+		value = node.Value
+	}
+	switch tag := fieldError.Tag(); tag {
+	case "required", "required_without", "required_without_all":
+		if property != "" {
+			return fmt.Sprintf("missing required property %q", property)
+		}
+		return "value is required"
+	case "oneof", "oneofci":
+		return fmt.Sprintf("%q must be one of %s", value, strings.Join(strings.Fields(fieldError.Param()), ", "))
+	case "eq":
+		return fmt.Sprintf("%q must be %q", value, fieldError.Param())
+	case "min":
+		return fmt.Sprintf("must have at least %s item(s) or a value of at least %s", fieldError.Param(), fieldError.Param())
+	case "max":
+		return fmt.Sprintf("must have at most %s item(s) or a value of at most %s", fieldError.Param(), fieldError.Param())
+	case "switch_item":
+		return "each switch case must have exactly one name"
+	default:
+		if name, ok := patternNames[tag]; ok {
+			return fmt.Sprintf("%q is not a valid %s", value, name)
+		}
+		return fmt.Sprintf("value fails the %s check", tag)
+	}
+}
+
+// validationKeyword returns the schema keyword of a failed validation tag
+func validationKeyword(fieldError validator.FieldError) string {
+	if keyword, ok := validatorKeywords[fieldError.Tag()]; ok {
+		return keyword
+	}
+	switch fieldError.Tag() {
+	case "min", "max":
+		suffix := "imum"
+		switch fieldError.Kind() {
+		case reflect.Slice, reflect.Array:
+			suffix = "Items"
+		case reflect.Map:
+			suffix = "Properties"
+		case reflect.String:
+			suffix = "Length"
+		}
+		return fieldError.Tag() + suffix
+	}
+	return fieldError.Tag()
+}
+
+// hasConcreteTaskSegment reports whether a namespace goes through a concrete task type
+func hasConcreteTaskSegment(segments []string) bool {
+	for _, segment := range segments {
+		if name, _ := splitNamespaceSegment(segment); concreteTaskTypes[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitNamespaceSegment splits a namespace segment such as Do[2] into its field name and index;
+// the index is -1 when there is none
+func splitNamespaceSegment(segment string) (string, int) {
+	open := strings.IndexByte(segment, '[')
+	if open < 0 || !strings.HasSuffix(segment, "]") {
+		return segment, -1
+	}
+	index, err := strconv.Atoi(segment[open+1 : len(segment)-1])
+	if err != nil {
+		return segment[:open], -1
+	}
+	return segment[:open], index
+}
+
+// mappingEntryFold returns the key and value of a mapping entry matching a field name case-insensitively
+func mappingEntryFold(node *yaml.Node, name string) (string, *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return "", nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, name) {
+			return node.Content[i].Value, node.Content[i+1]
+		}
+	}
+	return "", nil
+}
+
+// findTask searches a node for the first task item with the given name and returns its path
+// relative to the node and the task
+func findTask(node *yaml.Node, name string) (string, *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if path, task := findTask(node.Content[i+1], name); task != nil {
+				return "/" + escapePointer(node.Content[i].Value) + path, task
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if item.Kind == yaml.MappingNode && len(item.Content) == 2 && item.Content[0].Value == name {
+				return "/" + strconv.Itoa(i) + "/" + escapePointer(name), item.Content[1]
+			}
+			if path, task := findTask(item, name); task != nil {
+				return "/" + strconv.Itoa(i) + path, task
+			}
+		}
+	}
+	return "", nil
+}
+
+// locate returns the line and column of the deepest node along a JSON pointer
+func locate(document *yaml.Node, path string) (int, int) {
+	node := document
+	if path != "" {
+		for _, token := range strings.Split(path[1:], "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			var child *yaml.Node
+			switch node.Kind {
+			case yaml.MappingNode:
+				child = mappingValue(node, token)
+			case yaml.SequenceNode:
+				if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(node.Content) {
+					child = node.Content[index]
+				}
+			}
+			if child == nil {
+				break
+			}
+			node = child
+		}
+	}
+	if node.Line == 0 {
+		return 1, 1
+	}
+	return node.Line, node.Column
+}
+
+// lowerFirst turns a field name into its property name, e.g. Endpoint into endpoint and DSL into dsl
+func lowerFirst(name string) string {
+	if name == "" || strings.ToUpper(name) == name {
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,78 @@
+package workflows
+
+import (
+	"testing"
+)
+
+func TestValidateWorkflowSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []ValidationIssue
+	}{
+		{
+			name:     "valid",
+			source:   "document:\n  dsl: 1.0.0\n  namespace: test\n  name: ok\n  version: 1.0.0\ndo:\n  - greet:\n      set:\n        greeting: hello\n",
+			expected: []ValidationIssue{},
+		},
+		{
+			name: "pattern and missing properties",
+			source: "document:\n  dsl: 1.0.0\n  namespace: test\n  version: one\ndo:\n" +
+				"  - fetch:\n      call: http\n      with:\n        method: get\n" +
+				"  - pause:\n      wait: PT1X\n",
+			expected: []ValidationIssue{
+				{Path: "/document/name", Line: 2, Column: 3, Keyword: "required"},
+				{Path: "/document/version", Line: 4, Column: 12, Keyword: "pattern"},
+				{Path: "/do/0/fetch/with/endpoint", Line: 9, Column: 9, Keyword: "required"},
+				{Path: "/do/1/pause/wait", Line: 11, Column: 13, Keyword: "pattern"},
+			},
+		},
+		{
+			name: "nested task",
+			source: "document:\n  dsl: 1.0.0\n  namespace: test\n  name: nested\n  version: 1.0.0\ndo:\n" +
+				"  - outer:\n      fork:\n        branches:\n          - inner:\n              switch:\n" +
+				"                - a:\n                    then: end\n                  b:\n                    then: end\n",
+			expected: []ValidationIssue{
+				{Path: "/do/0/outer/fork/branches/0/inner/switch/0", Line: 12, Column: 19, Keyword: "maxProperties"},
+			},
+		},
+		{
+			name:   "JSON",
+			source: "{\n  \"document\": {\"dsl\": \"1.0.0\", \"namespace\": \"test\", \"name\": [\"x\"], \"version\": \"1.0.0\"},\n  \"do\": []\n}",
+			expected: []ValidationIssue{
+				{Path: "/document/name", Line: 2, Column: 61, Keyword: "type"},
+			},
+		},
+		{
+			name: "unknown task type",
+			source: "document:\n  dsl: 1.0.0\n  namespace: test\n  name: unknown\n  version: 1.0.0\ndo:\n" +
+				"  - outer:\n      do:\n        - inner:\n            bogus: true\n",
+			expected: []ValidationIssue{
+				{Path: "/do/0/outer/do/0/inner", Line: 10, Column: 13, Keyword: "oneOf"},
+			},
+		},
+		{
+			name:   "syntax",
+			source: "document:\n  dsl: 1.0.0\n\tname: x\n",
+			expected: []ValidationIssue{
+				{Path: "", Line: 2, Column: 1, Keyword: "syntax"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := ValidateWorkflowSource([]byte(tt.source))
+			if len(issues) != len(tt.expected) {
+				t.Fatalf("Expected %d issues, got %+v", len(tt.expected), issues)
+			}
+			for i, issue := range issues {
+				expected := tt.expected[i]
+				if issue.Path != expected.Path || issue.Line != expected.Line || issue.Column != expected.Column ||
+					issue.Keyword != expected.Keyword || issue.Message == "" {
+					t.Errorf("Issue %d: expected %+v, got %+v", i, expected, issue)
+				}
+			}
+		})
+	}
+}
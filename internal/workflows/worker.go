@@ -1,6 +1,8 @@
 package workflows
 
 import (
+	"os"
+
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 )
@@ -13,16 +15,30 @@ func StartWorker(c client.Client) worker.Worker {
 	w.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
 	w.RegisterWorkflow(ExecuteServerlessJSONWorkflow)
 	w.RegisterWorkflow(ChatbotWorkflow)
+	w.RegisterWorkflow(DryRunServerlessWorkflow)
 	w.RegisterActivity(SimpleActivity)
 
 	// Register chatbot activities
 	chatbotActivities := NewChatbotActivities()
-	w.RegisterActivity(chatbotActivities.CallClaudeAPI)
+	w.RegisterActivity(chatbotActivities.GenerateReply)
 
 	// Register serverless workflow activities
 	w.RegisterActivity(HTTPCallActivity)
 	w.RegisterActivity(ExecuteBranchActivity)
 	w.RegisterActivity(EvaluateExpressionActivity)
+	w.RegisterActivity(EvaluateValueActivity)
+
+	// Register AsyncAPI activities. No broker adapter is registered by default, so calls fail
+	// instead of losing messages; ASYNCAPI_INMEMORY_BROKER=true opts into the process-local broker.
+	brokers := make(map[string]MessageBroker)
+	if os.Getenv("ASYNCAPI_INMEMORY_BROKER") == "true" {
+		brokers[""] = NewInMemoryBroker()
+	}
+	asyncAPIActivities := NewAsyncAPIActivities(brokers, os.Getenv("ASYNCAPI_DOCUMENT_DIR"))
+	w.RegisterActivity(asyncAPIActivities.CallAsyncAPI)
+
+	// Register native functions callable from workflow definitions via `call: <name>`
+	registerNativeFunctions(w)
 
 	return w
 }
@@ -8,10 +8,12 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
 	"github.com/serverlessworkflow/sdk-go/v3/model"
@@ -48,22 +50,26 @@ func SimpleActivity(ctx context.Context, name string) (string, error) {
 
 // WorkflowState represents the state of a serverless workflow execution
 type WorkflowState struct {
-	State      map[string]interface{} `json:"state"`
-	CurrentTask string                `json:"current_task"`
-	Status     string                `json:"status"` // "running", "completed", "failed"
+	State       map[string]interface{}    `json:"state"`
+	CurrentTask string                    `json:"current_task"` // reference of the innermost running task
+	Status      string                    `json:"status"`       // "running", "suspended", "cancelled", "completed", "failed"
+	Tasks       map[string]*TaskExecution `json:"tasks"`        // task executions keyed by task reference
+	Events      []ExecutionEvent          `json:"-"`            // served by WorkflowEventsQuery
 }
 
 // ExecuteServerlessYAMLWorkflow parses, validates, and executes the serverless workflow YAML.
-func ExecuteServerlessYAMLWorkflow(ctx workflow.Context, workflowYAML string) (map[string]interface{}, error) {
+// The input is the workflow input ($workflow.input) and the input of its first task.
+func ExecuteServerlessYAMLWorkflow(ctx workflow.Context, workflowYAML string, input map[string]interface{}) (map[string]interface{}, error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("ExecuteServerlessYAMLWorkflow workflow started")
 
 	// Initialize workflow state
 	workflowState := &WorkflowState{
-		State:      make(map[string]interface{}),
+		State:       make(map[string]interface{}),
 		CurrentTask: "",
-		Status:     "running",
+		Tasks:       make(map[string]*TaskExecution),
 	}
+	workflowState.setStatus(ctx, WorkflowStatusRunning)
 
 	// Set up query handler for workflow state
 	err := workflow.SetQueryHandler(ctx, "get-workflow-state", func() (*WorkflowState, error) {
@@ -73,41 +79,59 @@ func ExecuteServerlessYAMLWorkflow(ctx workflow.Context, workflowYAML string) (m
 		logger.Error("Failed to set query handler", "error", err)
 		return nil, err
 	}
+	err = setEventsQueryHandler(ctx, workflowState)
+	if err != nil {
+		logger.Error("Failed to set events query handler", "error", err)
+		return nil, err
+	}
+
+	// Handle suspend/resume updates
+	if err := setExecutionControlHandlers(ctx, workflowState); err != nil {
+		logger.Error("Failed to set execution control handlers", "error", err)
+		return nil, err
+	}
 
 	// Parse and validate the workflow YAML (validation is automatic)
 	workflowDef, err := parser.FromYAMLSource([]byte(workflowYAML))
 	if err != nil {
 		logger.Error("Failed to parse serverless workflow YAML", "error", err)
-		workflowState.Status = "failed"
+		workflowState.setStatus(ctx, WorkflowStatusFailed)
 		return nil, fmt.Errorf("invalid serverless workflow YAML: %w", err)
 	}
 
 	logger.Info("Serverless workflow YAML parsed and validated successfully")
 
 	// Execute the workflow
-	result, err := executeWorkflowDefinitionWithState(ctx, workflowDef, workflowState)
+	result, err := executeWorkflowDefinitionWithState(ctx, workflowDef, workflowState, input)
 	if err != nil {
+		if temporal.IsCanceledError(err) {
+			logger.Info("YAML serverless workflow cancelled")
+			workflowState.setStatus(ctx, WorkflowStatusCancelled)
+			return nil, err
+		}
 		logger.Error("Failed to execute YAML serverless workflow", "error", err)
-		workflowState.Status = "failed"
+		workflowState.setStatus(ctx, WorkflowStatusFailed)
 		return nil, fmt.Errorf("YAML workflow execution failed: %w", err)
 	}
 
-	workflowState.Status = "completed"
+	workflowState.setStatus(ctx, WorkflowStatusCompleted)
 	logger.Info("Serverless workflow YAML parsed and validated successfully")
 	return result, nil
 }
 
 // ExecuteServerlessJSONWorkflow parses, validates, and executes the serverless workflow JSON.
-func ExecuteServerlessJSONWorkflow(ctx workflow.Context, workflowJSON string) (map[string]interface{}, error) {
+// The input is the workflow input ($workflow.input) and the input of its first task.
+func ExecuteServerlessJSONWorkflow(ctx workflow.Context, workflowJSON string, input map[string]interface{}) (map[string]interface{}, error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("ExecuteServerlessJSONWorkflow workflow started")
 
 	// Initialize workflow state
 	workflowState := &WorkflowState{
-		State:      make(map[string]interface{}),
+		State:       make(map[string]interface{}),
 		CurrentTask: "",
-		Status:     "running",
+		Tasks:       make(map[string]*TaskExecution),
 	}
+	workflowState.setStatus(ctx, WorkflowStatusRunning)
 
 	// Set up query handler for workflow state
 	err := workflow.SetQueryHandler(ctx, "get-workflow-state", func() (*WorkflowState, error) {
@@ -117,32 +141,48 @@ func ExecuteServerlessJSONWorkflow(ctx workflow.Context, workflowJSON string) (m
 		logger.Error("Failed to set query handler", "error", err)
 		return nil, err
 	}
+	err = setEventsQueryHandler(ctx, workflowState)
+	if err != nil {
+		logger.Error("Failed to set events query handler", "error", err)
+		return nil, err
+	}
+
+	// Handle suspend/resume updates
+	if err := setExecutionControlHandlers(ctx, workflowState); err != nil {
+		logger.Error("Failed to set execution control handlers", "error", err)
+		return nil, err
+	}
 
 	// Parse and validate the workflow JSON (validation is automatic)
 	workflowDef, err := parser.FromJSONSource([]byte(workflowJSON))
 	if err != nil {
 		logger.Error("Failed to parse serverless workflow JSON", "error", err)
-		workflowState.Status = "failed"
+		workflowState.setStatus(ctx, WorkflowStatusFailed)
 		return nil, fmt.Errorf("invalid serverless workflow JSON: %w", err)
 	}
 
 	logger.Info("Serverless workflow JSON parsed and validated successfully")
 
 	// Execute the workflow
-	result, err := executeWorkflowDefinitionWithState(ctx, workflowDef, workflowState)
+	result, err := executeWorkflowDefinitionWithState(ctx, workflowDef, workflowState, input)
 	if err != nil {
+		if temporal.IsCanceledError(err) {
+			logger.Info("JSON serverless workflow cancelled")
+			workflowState.setStatus(ctx, WorkflowStatusCancelled)
+			return nil, err
+		}
 		logger.Error("Failed to execute JSON serverless workflow", "error", err)
-		workflowState.Status = "failed"
+		workflowState.setStatus(ctx, WorkflowStatusFailed)
 		return nil, fmt.Errorf("JSON workflow execution failed: %w", err)
 	}
 
-	workflowState.Status = "completed"
+	workflowState.setStatus(ctx, WorkflowStatusCompleted)
 	logger.Info("Serverless workflow executed successfully")
 	return result, nil
 }
 
 // executeWorkflowDefinition steps through the workflow definition and executes tasks
-func executeWorkflowDefinition(ctx workflow.Context, workflowDef *model.Workflow) (map[string]interface{}, error) {
+func executeWorkflowDefinition(ctx workflow.Context, workflowDef *model.Workflow, input map[string]interface{}) (map[string]interface{}, error) {
 	logger := workflow.GetLogger(ctx)
 
 	// Set up activity options
@@ -150,9 +190,17 @@ func executeWorkflowDefinition(ctx workflow.Context, workflowDef *model.Workflow
 		StartToCloseTimeout: time.Second * 30,
 	}
 	ctx = workflow.WithActivityOptions(ctx, ao)
+	ctx = withFunctionCatalog(ctx, workflowDef)
+	ctx, err := withRuntimeArguments(ctx, workflowDef, input)
+	if err != nil {
+		return nil, err
+	}
 
-	// Initialize workflow state
+	// Initialize workflow state; the workflow input is the input of the first task
 	workflowState := make(map[string]interface{})
+	for key, value := range input {
+		workflowState[key] = value
+	}
 
 	// Execute the "do" tasks
 	if workflowDef.Do != nil {
@@ -167,8 +215,9 @@ func executeWorkflowDefinition(ctx workflow.Context, workflowDef *model.Workflow
 	return workflowState, nil
 }
 
-// executeWorkflowDefinitionWithState steps through the workflow definition and executes tasks with state tracking
-func executeWorkflowDefinitionWithState(ctx workflow.Context, workflowDef *model.Workflow, state *WorkflowState) (map[string]interface{}, error) {
+// executeWorkflowDefinitionWithState steps through the workflow definition and executes tasks with state tracking.
+// The workflow input is the input of the first task.
+func executeWorkflowDefinitionWithState(ctx workflow.Context, workflowDef *model.Workflow, state *WorkflowState, input map[string]interface{}) (map[string]interface{}, error) {
 	logger := workflow.GetLogger(ctx)
 
 	// Set up activity options
@@ -176,6 +225,15 @@ func executeWorkflowDefinitionWithState(ctx workflow.Context, workflowDef *model
 		StartToCloseTimeout: time.Second * 30,
 	}
 	ctx = workflow.WithActivityOptions(ctx, ao)
+	ctx = withFunctionCatalog(ctx, workflowDef)
+	ctx, err := withRuntimeArguments(ctx, workflowDef, input)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withExecutionRecorder(ctx, workflowDef, state)
+	for key, value := range input {
+		state.State[key] = value
+	}
 
 	// Execute the "do" tasks
 	if workflowDef.Do != nil {
@@ -198,10 +256,13 @@ func executeTasks(ctx workflow.Context, tasks model.TaskList, state map[string]i
 	for i, taskItem := range tasks {
 		logger.Info("Executing task", "index", i, "key", taskItem.Key)
 
-		result, err := executeTaskItem(ctx, taskItem, state)
+		result, skipped, err := runTask(ctx, i, taskItem, state)
 		if err != nil {
 			return nil, fmt.Errorf("task %d (%s) failed: %w", i, taskItem.Key, err)
 		}
+		if skipped {
+			continue
+		}
 
 		lastResult = result
 		state[fmt.Sprintf("task_%d_result", i)] = result
@@ -217,37 +278,95 @@ func executeTasksWithState(ctx workflow.Context, tasks model.TaskList, workflowS
 	var lastResult interface{}
 
 	for i, taskItem := range tasks {
-		workflowState.CurrentTask = taskItem.Key
 		logger.Info("Executing task", "index", i, "key", taskItem.Key)
 
-		result, err := executeTaskItem(ctx, taskItem, workflowState.State)
+		result, skipped, err := runTask(ctx, i, taskItem, workflowState.State)
 		if err != nil {
 			return nil, fmt.Errorf("task %d (%s) failed: %w", i, taskItem.Key, err)
 		}
+		if skipped {
+			continue
+		}
 
 		lastResult = result
 		workflowState.State[fmt.Sprintf("task_%d_result", i)] = result
 		workflowState.State[taskItem.Key] = result
 	}
 
-	workflowState.CurrentTask = ""
 	return lastResult, nil
 }
 
+// runTask executes the task at the given index of the current task list and records its execution.
+// Tasks whose `if` condition is not met are skipped.
+func runTask(ctx workflow.Context, index int, taskItem *model.TaskItem, state map[string]interface{}) (interface{}, bool, error) {
+	parent := ""
+	if descriptor, ok := ctx.Value(taskDescriptorKey).(*TaskDescriptor); ok {
+		parent = descriptor.Reference
+	}
+
+	// Suspension takes effect between tasks
+	if err := awaitResumed(ctx); err != nil {
+		return nil, false, err
+	}
+
+	taskCtx, descriptor := withTaskDescriptor(ctx, index, taskItem)
+	recordTaskStarted(taskCtx, descriptor.Reference, taskItem.Key, taskTypeName(taskItem), parent, state)
+
+	if base := taskItem.GetBase(); base != nil && base.If != nil {
+		var shouldRun bool
+		err := workflow.ExecuteActivity(taskCtx, EvaluateExpressionActivity, EvaluateExpressionRequest{
+			Expression: base.If.Value,
+			Context:    state,
+			Arguments:  expressionArguments(taskCtx, state, base.If.Value),
+		}).Get(taskCtx, &shouldRun)
+		if err != nil {
+			err = fmt.Errorf("failed to evaluate if condition '%s': %w", base.If.Value, err)
+			recordTaskFaulted(taskCtx, descriptor.Reference, err)
+			return nil, false, err
+		}
+		if !shouldRun {
+			recordTaskSkipped(taskCtx, descriptor.Reference)
+			return nil, true, nil
+		}
+	}
+
+	result, err := executeTaskItem(taskCtx, taskItem, state)
+	if err == nil {
+		err = exportTaskOutput(taskCtx, taskItem, state, result)
+	}
+	if err != nil {
+		recordTaskFaulted(taskCtx, descriptor.Reference, err)
+		return nil, false, err
+	}
+
+	recordTaskCompleted(taskCtx, descriptor.Reference, result)
+	return result, false, nil
+}
+
 // executeTaskItem executes a single task item
 func executeTaskItem(ctx workflow.Context, taskItem *model.TaskItem, state map[string]interface{}) (interface{}, error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Executing task item", "key", taskItem.Key)
 
+	// Dry runs simulate calls to external systems
+	if isDryRun(ctx) {
+		if result := dryRunCallResult(taskItem); result != nil {
+			return result, nil
+		}
+	}
+
 	// Handle different task types using the As* methods
 	if httpTask := taskItem.AsCallHTTPTask(); httpTask != nil {
 		return executeHTTPTask(ctx, httpTask, state)
 	}
+	if asyncAPITask := taskItem.AsCallAsyncAPITask(); asyncAPITask != nil {
+		return executeAsyncAPITask(ctx, taskItem.Key, asyncAPITask, state)
+	}
 	if forkTask := taskItem.AsForkTask(); forkTask != nil {
 		return executeForkTaskItem(ctx, forkTask, state)
 	}
 	if setTask := taskItem.AsSetTask(); setTask != nil {
-		return executeSetTaskItem(setTask, state)
+		return executeSetTaskItem(ctx, setTask, state)
 	}
 	if doTask := taskItem.AsDoTask(); doTask != nil {
 		return executeDoTask(ctx, doTask, state)
@@ -258,6 +377,12 @@ func executeTaskItem(ctx workflow.Context, taskItem *model.TaskItem, state map[s
 	if forTask := taskItem.AsForTask(); forTask != nil {
 		return executeForTask(ctx, forTask, state)
 	}
+	if tryTask := taskItem.AsTryTask(); tryTask != nil {
+		return executeTryTask(ctx, tryTask, state)
+	}
+	if callTask := taskItem.AsCallFunctionTask(); callTask != nil {
+		return executeFunctionCall(ctx, taskItem.Key, callTask, state)
+	}
 
 	return nil, fmt.Errorf("unsupported task type for task: %s", taskItem.Key)
 }
@@ -294,10 +419,15 @@ func executeForkTaskItem(ctx workflow.Context, forkTask *model.ForkTask, state m
 	// Execute branches in parallel
 	branches := *forkTask.Fork.Branches
 	futures := make([]workflow.Future, len(branches))
+	references := make([]string, len(branches))
 	for i, branch := range branches {
+		references[i] = fmt.Sprintf("%s/fork/branches/%d/%s", currentTaskReference(ctx), i, branch.Key)
+		recordTaskStarted(ctx, references[i], branch.Key, taskTypeName(branch), currentTaskReference(ctx), state)
 		futures[i] = workflow.ExecuteActivity(ctx, ExecuteBranchActivity, ExecuteBranchRequest{
-			Tasks: model.TaskList{branch},
-			State: state,
+			Tasks:     model.TaskList{branch},
+			State:     state,
+			Arguments: expressionArguments(ctx, state, branch),
+			DryRun:    isDryRun(ctx),
 		})
 	}
 
@@ -307,8 +437,11 @@ func executeForkTaskItem(ctx workflow.Context, forkTask *model.ForkTask, state m
 		var result interface{}
 		err := future.Get(ctx, &result)
 		if err != nil {
+			recordTaskFaulted(ctx, references[i], err)
 			return nil, fmt.Errorf("branch %d failed: %w", i, err)
 		}
+		recordTaskCompleted(ctx, references[i], result)
+		recordSubtreeEnded(ctx, references[i], TaskStatusCompleted)
 		results[i] = result
 	}
 
@@ -317,11 +450,32 @@ func executeForkTaskItem(ctx workflow.Context, forkTask *model.ForkTask, state m
 }
 
 // executeSetTaskItem handles variable assignment
-func executeSetTaskItem(setTask *model.SetTask, state map[string]interface{}) (interface{}, error) {
-	for key, value := range setTask.Set {
+func executeSetTaskItem(ctx workflow.Context, setTask *model.SetTask, state map[string]interface{}) (interface{}, error) {
+	// Evaluate every expression in the set object against the current state
+	var evaluated interface{}
+	err := workflow.ExecuteActivity(ctx, EvaluateValueActivity, EvaluateValueRequest{
+		Value:     map[string]interface{}(setTask.Set),
+		Context:   state,
+		Arguments: expressionArguments(ctx, state, setTask.Set),
+	}).Get(ctx, &evaluated)
+	if err != nil {
+		return nil, wrapExpressionError(err, "failed to evaluate set")
+	}
+
+	return mergeSetResult(evaluated, state)
+}
+
+// mergeSetResult merges the evaluated set object into the state; each top-level key replaces
+// the previous value, as the set task's output is the evaluated object itself
+func mergeSetResult(evaluated interface{}, state map[string]interface{}) (interface{}, error) {
+	values, ok := evaluated.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("set must evaluate to an object, got %T", evaluated)
+	}
+	for key, value := range values {
 		state[key] = value
 	}
-	return setTask.Set, nil
+	return values, nil
 }
 
 // executeDoTask handles sequential execution of nested tasks
@@ -330,9 +484,111 @@ func executeDoTask(ctx workflow.Context, doTask *model.DoTask, state map[string]
 	logger.Info("Executing do task with nested tasks", "taskCount", len(*doTask.Do))
 
 	// Execute nested tasks sequentially
+	ctx = withTaskScope(ctx, currentTaskReference(ctx)+"/do")
 	return executeTasks(ctx, *doTask.Do, state)
 }
 
+// executeTryTask runs the try tasks and, when one of them faults with an error the catch accepts,
+// the catch tasks with the error stored in the state under catch.as (default "error"). When the
+// execution is cancelled the catch tasks always run, on a disconnected context so they can call
+// activities, and the cancellation is then raised again so the execution is recorded as cancelled.
+func executeTryTask(ctx workflow.Context, tryTask *model.TryTask, state map[string]interface{}) (interface{}, error) {
+	logger := workflow.GetLogger(ctx)
+	reference := currentTaskReference(ctx)
+
+	result, err := executeTasks(withTaskScope(ctx, reference+"/try"), *tryTask.Try, state)
+	if err == nil {
+		return result, nil
+	}
+
+	catch := tryTask.Catch
+	caught := newRuntimeError(reference, err)
+	catchState := make(map[string]interface{}, len(state)+1)
+	for key, value := range state {
+		catchState[key] = value
+	}
+	errorName := "error"
+	if catch.As != "" {
+		errorName = catch.As
+	}
+	catchState[errorName] = caught
+
+	catchCtx := ctx
+	cancelled := temporal.IsCanceledError(err) || ctx.Err() != nil
+	if cancelled {
+		logger.Info("Execution cancelled, running catch tasks", "task", reference)
+		catchCtx = withCompensation(ctx)
+	} else {
+		matched, matchErr := catchesError(catchCtx, catch, caught, catchState)
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if !matched {
+			return nil, err
+		}
+		logger.Info("Caught task error", "task", reference, "error", err)
+	}
+
+	if catch.Do == nil {
+		if cancelled {
+			return nil, err
+		}
+		return nil, nil
+	}
+	result, catchErr := executeTasks(withTaskScope(catchCtx, reference+"/catch/do"), *catch.Do, catchState)
+	if cancelled {
+		if catchErr != nil {
+			logger.Error("Catch tasks failed after cancellation", "task", reference, "error", catchErr)
+		}
+		return nil, err
+	}
+	if catchErr != nil {
+		return nil, catchErr
+	}
+	for key, value := range catchState {
+		if key != errorName {
+			state[key] = value
+		}
+	}
+	return result, nil
+}
+
+// catchesError reports whether the catch accepts the error: it must match errors.with, satisfy
+// when and not satisfy exceptWhen, both evaluated against the state holding the error
+func catchesError(ctx workflow.Context, catch *model.TryTaskCatch, caught *WorkflowError, state map[string]interface{}) (bool, error) {
+	if filter := catch.Errors.With; filter != nil {
+		if (filter.Type != "" && filter.Type != caught.Type) ||
+			(filter.Status != 0 && filter.Status != caught.Status) ||
+			(filter.Instance != "" && filter.Instance != caught.Instance) ||
+			(filter.Title != "" && filter.Title != caught.Title) ||
+			(filter.Details != "" && filter.Details != caught.Detail) {
+			return false, nil
+		}
+	}
+
+	for _, condition := range []struct {
+		expression *model.RuntimeExpression
+		want       bool
+	}{{catch.When, true}, {catch.ExceptWhen, false}} {
+		if condition.expression == nil {
+			continue
+		}
+		var result bool
+		err := workflow.ExecuteActivity(ctx, EvaluateExpressionActivity, EvaluateExpressionRequest{
+			Expression: condition.expression.Value,
+			Context:    state,
+			Arguments:  expressionArguments(ctx, state, condition.expression.Value),
+		}).Get(ctx, &result)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate catch condition '%s': %w", condition.expression.Value, err)
+		}
+		if result != condition.want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // HTTPCallRequest represents an HTTP call request
 type HTTPCallRequest struct {
 	Method   string            `json:"method"`
@@ -352,12 +608,15 @@ type HTTPCallResult struct {
 type EvaluateExpressionRequest struct {
 	Expression string                 `json:"expression"`
 	Context    map[string]interface{} `json:"context"`
+	Arguments  *RuntimeArguments      `json:"arguments,omitempty"`
 }
 
 // ExecuteBranchRequest represents a branch execution request
 type ExecuteBranchRequest struct {
-	Tasks model.TaskList         `json:"tasks"`
-	State map[string]interface{} `json:"state"`
+	Tasks     model.TaskList         `json:"tasks"`
+	State     map[string]interface{} `json:"state"`
+	Arguments *RuntimeArguments      `json:"arguments,omitempty"`
+	DryRun    bool                   `json:"dry_run,omitempty"`
 }
 
 // HTTPCallActivity executes HTTP calls
@@ -485,6 +744,10 @@ func ExecuteBranchActivity(ctx context.Context, req ExecuteBranchRequest) (inter
 	logger := activity.GetLogger(ctx)
 	logger.Info("ExecuteBranchActivity started", "tasks", len(req.Tasks))
 
+	if req.DryRun {
+		ctx = context.WithValue(ctx, dryRunKey, true)
+	}
+
 	// Execute tasks sequentially within this branch
 	branchState := make(map[string]interface{})
 	var lastResult interface{}
@@ -503,18 +766,18 @@ func ExecuteBranchActivity(ctx context.Context, req ExecuteBranchRequest) (inter
 
 		if doTask := taskItem.AsDoTask(); doTask != nil {
 			// Handle "do" tasks which contain nested task lists
-			result, err = executeBranchDoTask(ctx, doTask, branchState)
+			result, err = executeBranchDoTask(ctx, doTask, branchState, req.Arguments)
 		} else if httpTask := taskItem.AsCallHTTPTask(); httpTask != nil {
 			result, err = executeBranchHTTPTask(ctx, httpTask)
 		} else if setTask := taskItem.AsSetTask(); setTask != nil {
-			result, err = executeBranchSetTask(setTask, branchState)
+			result, err = executeBranchSetTask(setTask, branchState, req.Arguments)
 		} else {
 			err = fmt.Errorf("unsupported task type in branch: %s", taskItem.Key)
 		}
 
 		if err != nil {
 			logger.Error("Branch task failed", "task", taskItem.Key, "error", err)
-			return nil, fmt.Errorf("branch task %s failed: %w", taskItem.Key, err)
+			return nil, wrapExpressionError(err, "branch task %s failed", taskItem.Key)
 		}
 
 		lastResult = result
@@ -530,7 +793,7 @@ func ExecuteBranchActivity(ctx context.Context, req ExecuteBranchRequest) (inter
 }
 
 // executeBranchDoTask executes "do" tasks within a branch
-func executeBranchDoTask(ctx context.Context, doTask *model.DoTask, state map[string]interface{}) (interface{}, error) {
+func executeBranchDoTask(ctx context.Context, doTask *model.DoTask, state map[string]interface{}, args *RuntimeArguments) (interface{}, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("Executing branch do task", "nestedTasks", len(*doTask.Do))
 
@@ -545,13 +808,13 @@ func executeBranchDoTask(ctx context.Context, doTask *model.DoTask, state map[st
 		if httpTask := nestedTaskItem.AsCallHTTPTask(); httpTask != nil {
 			result, err = executeBranchHTTPTask(ctx, httpTask)
 		} else if setTask := nestedTaskItem.AsSetTask(); setTask != nil {
-			result, err = executeBranchSetTask(setTask, state)
+			result, err = executeBranchSetTask(setTask, state, args)
 		} else {
 			err = fmt.Errorf("unsupported nested task type in branch: %s", nestedTaskItem.Key)
 		}
 
 		if err != nil {
-			return nil, fmt.Errorf("nested task %s failed: %w", nestedTaskItem.Key, err)
+			return nil, wrapExpressionError(err, "nested task %s failed", nestedTaskItem.Key)
 		}
 
 		lastResult = result
@@ -566,6 +829,10 @@ func executeBranchHTTPTask(ctx context.Context, httpTask *model.CallHTTP) (inter
 	logger := activity.GetLogger(ctx)
 	logger.Info("Executing branch HTTP task", "endpoint", httpTask.With.Endpoint.String())
 
+	if isDryRunActivity(ctx) {
+		return dryRunHTTPResult(httpTask), nil
+	}
+
 	// Use the same HTTP call logic as the main workflow
 	req := HTTPCallRequest{
 		Method:   httpTask.With.Method,
@@ -578,11 +845,19 @@ func executeBranchHTTPTask(ctx context.Context, httpTask *model.CallHTTP) (inter
 }
 
 // executeBranchSetTask executes set tasks within a branch
-func executeBranchSetTask(setTask *model.SetTask, state map[string]interface{}) (interface{}, error) {
-	for key, value := range setTask.Set {
-		state[key] = value
+func executeBranchSetTask(setTask *model.SetTask, state map[string]interface{}, args *RuntimeArguments) (interface{}, error) {
+	if args != nil {
+		branchArgs := *args
+		branchArgs.Input = state
+		args = &branchArgs
 	}
-	return setTask.Set, nil
+
+	evaluated, err := evaluateValue(map[string]interface{}(setTask.Set), state, args)
+	if err != nil {
+		return nil, wrapExpressionError(err, "failed to evaluate set")
+	}
+
+	return mergeSetResult(evaluated, state)
 }
 
 // executeSwitchTask handles conditional branching logic
@@ -602,6 +877,7 @@ func executeSwitchTask(ctx workflow.Context, switchTask *model.SwitchTask, state
 				err := workflow.ExecuteActivity(ctx, EvaluateExpressionActivity, EvaluateExpressionRequest{
 					Expression: switchCase.When.Value,
 					Context:    state,
+					Arguments:  expressionArguments(ctx, state, switchCase.When.Value),
 				}).Get(ctx, &conditionResult)
 				
 				if err != nil {
@@ -638,16 +914,25 @@ func executeForTask(ctx workflow.Context, forTask *model.ForTask, state map[stri
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Executing for task", "collection", forTask.For.In, "each", forTask.For.Each)
 
-	// Evaluate the collection expression
+	// Evaluate the collection expression; bare paths such as `items` or `.items` predate runtime
+	// expressions and are still evaluated as one
+	in := forTask.For.In
+	if !model.IsStrictExpr(in) {
+		in = legacyPathExpression(in)
+	}
 	var collection interface{}
-	err := workflow.ExecuteActivity(ctx, EvaluateExpressionActivity, EvaluateExpressionRequest{
-		Expression: forTask.For.In,
-		Context:    state,
+	err := workflow.ExecuteActivity(ctx, EvaluateValueActivity, EvaluateValueRequest{
+		Value:     in,
+		Context:   state,
+		Arguments: expressionArguments(ctx, state, in),
 	}).Get(ctx, &collection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate collection expression '%s': %w", forTask.For.In, err)
 	}
 
+	// Nested tasks are referenced relative to the for task
+	ctx = withTaskScope(ctx, currentTaskReference(ctx)+"/do")
+
 	// Convert to slice for iteration
 	items, ok := collection.([]interface{})
 	if !ok {
@@ -681,6 +966,7 @@ func executeForTask(ctx workflow.Context, forTask *model.ForTask, state map[stri
 			err := workflow.ExecuteActivity(ctx, EvaluateExpressionActivity, EvaluateExpressionRequest{
 				Expression: forTask.While,
 				Context:    loopState,
+				Arguments:  expressionArguments(ctx, loopState, forTask.While),
 			}).Get(ctx, &shouldContinue)
 			if err != nil {
 				return nil, fmt.Errorf("failed to evaluate while condition '%s': %w", forTask.While, err)
@@ -708,16 +994,27 @@ func executeForTask(ctx workflow.Context, forTask *model.ForTask, state map[stri
 	return results, nil
 }
 
-// EvaluateExpressionActivity evaluates runtime expressions using a simplified evaluation
+// legacyPathPattern matches the bare property paths accepted before runtime expressions
+var legacyPathPattern = regexp.MustCompile(`^\.?[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// legacyPathExpression turns a bare path such as `items`, `.items` or `order.items` into a runtime
+// expression; anything else is wrapped as it is
+func legacyPathExpression(path string) string {
+	path = strings.TrimSpace(path)
+	if legacyPathPattern.MatchString(path) && !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return "${ " + path + " }"
+}
+
+// EvaluateExpressionActivity evaluates a runtime expression to a boolean
 func EvaluateExpressionActivity(ctx context.Context, req EvaluateExpressionRequest) (bool, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("Evaluating expression", "expression", req.Expression)
 
-	// Simplified expression evaluation for basic cases
-	// In a full implementation, this would use gojq or similar
-	result, err := evaluateSimpleExpression(req.Expression, req.Context)
+	result, err := evaluateExpression(req.Expression, req.Context, req.Arguments)
 	if err != nil {
-		return false, fmt.Errorf("failed to evaluate expression '%s': %w", req.Expression, err)
+		return false, wrapExpressionError(err, "failed to evaluate expression '%s'", req.Expression)
 	}
 
 	// Convert result to boolean
@@ -1,9 +1,20 @@
 package workflows
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
 )
 
 func TestExpressionToBooleanConversion(t *testing.T) {
@@ -158,8 +169,8 @@ func TestIsTruthy(t *testing.T) {
 
 func TestWorkflowParsing(t *testing.T) {
 	tests := []struct {
-		name     string
-		yaml     string
+		name       string
+		yaml       string
 		shouldPass bool
 	}{
 		{
@@ -218,14 +229,796 @@ do:
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			_, err := parser.FromYAMLSource([]byte(tt.yaml))
-			
+
 			if tt.shouldPass && err != nil {
 				t.Errorf("Expected workflow to be valid, but got error: %v", err)
 			}
-			
+
 			if !tt.shouldPass && err == nil {
 				t.Error("Expected workflow to be invalid, but it passed validation")
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestRuntimeArgumentExpressions(t *testing.T) {
+	t.Setenv("SW_SECRET_ORDER_API_KEY", "s3cr3t")
+	t.Setenv("ANTHROPIC_API_KEY", "must-not-leak")
+
+	args := &RuntimeArguments{
+		Context: map[string]interface{}{"customer": "acme"},
+		Input:   map[string]interface{}{"orderId": "42"},
+		Workflow: &WorkflowDescriptor{
+			ID:         "yaml-workflow-1",
+			Definition: map[string]interface{}{"document": map[string]interface{}{"name": "orders"}},
+		},
+		Task:    &TaskDescriptor{Name: "checkOrder", Reference: "/do/0/checkOrder"},
+		Secrets: []string{"ORDER_API_KEY", "ANTHROPIC_API_KEY"},
+	}
+	input := map[string]interface{}{"orderId": "42", "items": []interface{}{"a", "b"}}
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   interface{}
+	}{
+		{"input document", "${ .orderId }", "42"},
+		{"jq function", "${ .items | length }", 2},
+		{"$input", "${ $input.orderId }", "42"},
+		{"$context", "${ $context.customer }", "acme"},
+		{"$workflow", "${ $workflow.id }", "yaml-workflow-1"},
+		{"$workflow definition", "${ $workflow.definition.document.name }", "orders"},
+		{"$task", "${ $task.reference }", "/do/0/checkOrder"},
+		{"$runtime", "${ $runtime.name }", RuntimeName},
+		{"$secrets", "${ $secrets.ORDER_API_KEY }", "s3cr3t"},
+		{"$secrets without prefix", "${ $secrets.ANTHROPIC_API_KEY }", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluateExpression(tt.expression, input, args)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestWorkflowDefinitionSentOnlyWhenReferenced(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	definitions := map[string]bool{}
+	env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args converter.EncodedValues) {
+		var req EvaluateValueRequest
+		if err := args.Get(&req); err != nil {
+			t.Errorf("Failed to decode activity input: %v", err)
+			return
+		}
+		set, _ := req.Value.(map[string]interface{})
+		for key := range set {
+			definitions[key] = req.Arguments.Workflow.Definition != nil
+		}
+	})
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: orders
+  version: 1.0.0
+do:
+  - plain:
+      set:
+        total: ${ .items | length }
+  - named:
+      set:
+        name: ${ $workflow.definition.document.name }
+`, map[string]interface{}{"items": []interface{}{"a"}})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if definitions["total"] {
+		t.Error("Expected the definition to be left out of an expression that does not read $workflow")
+	}
+	if !definitions["name"] {
+		t.Error("Expected the definition to be sent for an expression that reads $workflow")
+	}
+}
+
+func TestMalformedExpressionsFail(t *testing.T) {
+	input := map[string]interface{}{"status": "x", "items": []interface{}{"a"}}
+
+	for _, expression := range []string{
+		"${ lenght(.items) > 0 }",
+		"${ notAFunction }",
+		`${ .status == "x" and }`,
+	} {
+		t.Run(expression, func(t *testing.T) {
+			result, err := evaluateExpression(expression, input, nil)
+			if err == nil || !strings.Contains(err.Error(), "failed to compile expression") {
+				t.Fatalf("Expected a compile error, got result %v and error %v", result, err)
+			}
+		})
+	}
+}
+
+func TestMalformedExpressionsFailTaskWithoutRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     string
+		activity string
+		message  string
+	}{
+		{
+			name:     "if",
+			task:     "if: ${ lenght(.items) > 0 }\n      set:\n        ran: true",
+			activity: "EvaluateExpressionActivity",
+			message:  "failed to evaluate if condition",
+		},
+		{
+			name:     "set",
+			task:     "set:\n        count: ${ lenght(.items) }",
+			activity: "EvaluateValueActivity",
+			message:  "count: failed to compile expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+			env.RegisterActivity(EvaluateExpressionActivity)
+			env.RegisterActivity(EvaluateValueActivity)
+
+			attempts := 0
+			env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args converter.EncodedValues) {
+				if info.ActivityType.Name == tt.activity {
+					attempts++
+				}
+			})
+
+			env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: malformed-expression
+  version: 1.0.0
+do:
+  - guarded:
+      `+tt.task+`
+`, map[string]interface{}{"items": []interface{}{"a"}})
+
+			if !env.IsWorkflowCompleted() {
+				t.Fatal("Expected the workflow to complete")
+			}
+			err := env.GetWorkflowError()
+			if err == nil {
+				t.Fatal("Expected the malformed expression to fail the workflow")
+			}
+			if !strings.Contains(err.Error(), tt.message) || !strings.Contains(err.Error(), "failed to compile expression") {
+				t.Errorf("Expected a compile error, got %v", err)
+			}
+			if attempts != 1 {
+				t.Errorf("Expected a single %s attempt, got %d", tt.activity, attempts)
+			}
+		})
+	}
+}
+
+func TestSetTaskEvaluatesExpressions(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: set-expressions
+  version: 1.0.0
+do:
+  - initialize:
+      set:
+        order:
+          id: "42"
+          status: placed
+        items: [1, 2, 3]
+  - summarize:
+      set:
+        orderStatus: ${ .order.status }
+        itemCount: ${ .items | length }
+        summary:
+          id: ${ .order.id }
+          literal: unchanged
+        copy: ${ .order }
+`, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+
+	if result["orderStatus"] != "placed" {
+		t.Errorf("Expected orderStatus to be evaluated, got %v", result["orderStatus"])
+	}
+	if result["itemCount"] != float64(3) {
+		t.Errorf("Expected itemCount 3, got %v", result["itemCount"])
+	}
+	summary, _ := result["summary"].(map[string]interface{})
+	if summary["id"] != "42" || summary["literal"] != "unchanged" {
+		t.Errorf("Expected nested set values to be evaluated, got %v", result["summary"])
+	}
+	copied, _ := result["copy"].(map[string]interface{})
+	if copied["status"] != "placed" {
+		t.Errorf("Expected whole-object expression to be evaluated, got %v", result["copy"])
+	}
+}
+
+func TestWorkflowInput(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: workflow-input
+  version: 1.0.0
+do:
+  - readInput:
+      set:
+        fromState: ${ .orderId }
+        fromInput: ${ $input.orderId }
+        fromWorkflow: ${ $workflow.input.orderId }
+`, map[string]interface{}{"orderId": "42"})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := env.GetWorkflowResult(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	for _, key := range []string{"fromState", "fromInput", "fromWorkflow"} {
+		if result[key] != "42" {
+			t.Errorf("Expected %s to be 42, got %v", key, result[key])
+		}
+	}
+}
+
+func TestForTaskBareCollectionPath(t *testing.T) {
+	for _, in := range []string{"items", ".items", "${ .items }"} {
+		t.Run(in, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+			env.RegisterActivity(EvaluateValueActivity)
+
+			env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: for-bare-path
+  version: 1.0.0
+do:
+  - loop:
+      for:
+        each: item
+        in: "`+in+`"
+      do:
+        - visit:
+            set:
+              visited: ${ .item }
+`, map[string]interface{}{"items": []interface{}{"a", "b"}})
+
+			if err := env.GetWorkflowError(); err != nil {
+				t.Fatalf("Workflow failed: %v", err)
+			}
+			var result map[string]interface{}
+			if err := env.GetWorkflowResult(&result); err != nil {
+				t.Fatalf("Failed to decode result: %v", err)
+			}
+			iterations, _ := result["loop"].([]interface{})
+			if len(iterations) != 2 {
+				t.Fatalf("Expected 2 iterations, got %v", result["loop"])
+			}
+			last, _ := iterations[1].(map[string]interface{})
+			if last["visited"] != "b" {
+				t.Errorf("Expected the second iteration to visit b, got %v", iterations[1])
+			}
+		})
+	}
+}
+
+func TestBranchSetTaskEvaluatesExpressions(t *testing.T) {
+	state := map[string]interface{}{"processOrderData": map[string]interface{}{"status": float64(200)}}
+	setTask := &model.SetTask{Set: map[string]interface{}{"orderStatus": "${ .processOrderData.status }"}}
+
+	result, err := executeBranchSetTask(setTask, state, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	values, _ := result.(map[string]interface{})
+	if values["orderStatus"] != float64(200) || state["orderStatus"] != float64(200) {
+		t.Errorf("Expected orderStatus 200, got result %v state %v", values["orderStatus"], state["orderStatus"])
+	}
+	if setTask.Set["orderStatus"] != "${ .processOrderData.status }" {
+		t.Error("Expected the set definition to be left unchanged")
+	}
+}
+
+func TestTaskExecutionHistory(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(EvaluateExpressionActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: history
+  version: 1.0.0
+do:
+  - initialize:
+      set:
+        orderType: electronic
+  - processOrder:
+      do:
+        - markElectronic:
+            if: ${ .orderType == "electronic" }
+            set:
+              electronic: true
+        - markPhysical:
+            if: ${ .orderType == "physical" }
+            set:
+              physical: true
+`, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	encoded, err := env.QueryWorkflow("get-workflow-state")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var state WorkflowState
+	if err := encoded.Get(&state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+
+	expected := map[string]string{
+		"/do/0/initialize":                       TaskStatusCompleted,
+		"/do/1/processOrder":                     TaskStatusCompleted,
+		"/do/1/processOrder/do/0/markElectronic": TaskStatusCompleted,
+		"/do/1/processOrder/do/1/markPhysical":   TaskStatusSkipped,
+	}
+	for reference, status := range expected {
+		execution, ok := state.Tasks[reference]
+		if !ok {
+			t.Errorf("Expected execution for %s", reference)
+			continue
+		}
+		if execution.Status != status {
+			t.Errorf("Expected %s to be %s, got %s", reference, status, execution.Status)
+		}
+	}
+
+	parent := state.Tasks["/do/1/processOrder"]
+	if parent == nil || len(parent.Children) != 2 {
+		t.Errorf("Expected processOrder to have 2 children, got %+v", parent)
+	}
+	if electronic := state.Tasks["/do/1/processOrder/do/0/markElectronic"]; electronic != nil {
+		if electronic.Runs != 1 || electronic.StartedAt == nil || electronic.EndedAt == nil {
+			t.Errorf("Expected timings and runs to be recorded, got %+v", electronic)
+		}
+		if electronic.Parent != "/do/1/processOrder" {
+			t.Errorf("Expected parent /do/1/processOrder, got %s", electronic.Parent)
+		}
+	}
+	if state.CurrentTask != "" {
+		t.Errorf("Expected no current task after completion, got %s", state.CurrentTask)
+	}
+}
+
+func TestExecutionEvents(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+	env.RegisterActivity(EvaluateExpressionActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: events
+  version: 1.0.0
+do:
+  - first:
+      set:
+        step: 1
+  - second:
+      if: ${ .step == 2 }
+      set:
+        step: 2
+`, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	encoded, err := env.QueryWorkflow(WorkflowEventsQuery, int64(0))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var events []ExecutionEvent
+	if err := encoded.Get(&events); err != nil {
+		t.Fatalf("Failed to decode events: %v", err)
+	}
+
+	expected := []struct{ eventType, task string }{
+		{WorkflowStatusEvent(WorkflowStatusRunning), ""},
+		{EventTaskStarted, "/do/0/first"},
+		{EventTaskCompleted, "/do/0/first"},
+		{EventTaskStarted, "/do/1/second"},
+		{EventTaskSkipped, "/do/1/second"},
+		{WorkflowStatusEvent(WorkflowStatusCompleted), ""},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %+v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.ID != int64(i+1) || event.Type != expected[i].eventType || event.Task != expected[i].task {
+			t.Errorf("Event %d: expected %s %s, got %+v", i+1, expected[i].eventType, expected[i].task, event)
+		}
+	}
+	if !IsTerminalEvent(events[len(events)-1]) {
+		t.Error("Expected the last event to be terminal")
+	}
+
+	encoded, err = env.QueryWorkflow(WorkflowEventsQuery, int64(4))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if err := encoded.Get(&events); err != nil {
+		t.Fatalf("Failed to decode events: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != 5 {
+		t.Errorf("Expected the events after ID 4, got %+v", events)
+	}
+}
+
+func TestExecutionEventsPagedAndTrimmed(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: large-output
+  version: 1.0.0
+do:
+  - large:
+      set:
+        padding: ${ "x" * 5000 }
+`, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+
+	encoded, err := env.QueryWorkflow(WorkflowEventsQuery, int64(1), 1)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var page []ExecutionEvent
+	if err := encoded.Get(&page); err != nil {
+		t.Fatalf("Failed to decode events: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != 2 {
+		t.Fatalf("Expected a page of the one event after ID 1, got %+v", page)
+	}
+
+	encoded, err = env.QueryWorkflow(WorkflowEventsQuery, int64(2), 1)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if err := encoded.Get(&page); err != nil {
+		t.Fatalf("Failed to decode events: %v", err)
+	}
+	if len(page) != 1 || page[0].Type != EventTaskCompleted || page[0].Output != nil || !page[0].OutputTruncated {
+		t.Errorf("Expected the large output to be left out of the completed event, got %+v", page)
+	}
+
+	encoded, err = env.QueryWorkflow("get-workflow-state")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var state WorkflowState
+	if err := encoded.Get(&state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	output, _ := state.Tasks["/do/0/large"].Output.(map[string]interface{})
+	if padding, _ := output["padding"].(string); len(padding) != 5000 {
+		t.Errorf("Expected the task to keep its full output, got %v", state.Tasks["/do/0/large"])
+	}
+}
+
+const lifecycleTestWorkflow = `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: lifecycle
+  version: 1.0.0
+do:
+  - first:
+      set:
+        step: 1
+  - second:
+      set:
+        step: 2
+`
+
+// updateExecutionControl sends a suspend or resume update and records its outcome
+func updateExecutionControl(env *testsuite.TestWorkflowEnvironment, name string, status *string, rejected *error) {
+	env.UpdateWorkflow(name, uuid.NewString(), &testsuite.TestUpdateCallback{
+		OnAccept: func() {},
+		OnReject: func(err error) {
+			if rejected != nil {
+				*rejected = err
+			}
+		},
+		OnComplete: func(result interface{}, err error) {
+			if status != nil {
+				*status, _ = result.(string)
+			}
+		},
+	})
+}
+
+func TestSuspendAndResume(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	var suspended, resumed string
+	var suspendTwiceErr error
+	env.RegisterDelayedCallback(func() {
+		updateExecutionControl(env, SuspendUpdate, &suspended, nil)
+	}, 0)
+
+	var suspendedStatus string
+	env.RegisterDelayedCallback(func() {
+		encoded, err := env.QueryWorkflow("get-workflow-state")
+		if err != nil {
+			t.Errorf("Query failed: %v", err)
+		} else {
+			var state WorkflowState
+			encoded.Get(&state)
+			suspendedStatus = state.Status
+		}
+		updateExecutionControl(env, SuspendUpdate, nil, &suspendTwiceErr)
+	}, time.Minute)
+	env.RegisterDelayedCallback(func() {
+		updateExecutionControl(env, ResumeUpdate, &resumed, nil)
+	}, 2*time.Minute)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, lifecycleTestWorkflow, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if suspendedStatus != WorkflowStatusSuspended || suspended != WorkflowStatusSuspended {
+		t.Errorf("Expected status %s while suspended, got %s (update returned %q)", WorkflowStatusSuspended, suspendedStatus, suspended)
+	}
+	if resumed != WorkflowStatusRunning {
+		t.Errorf("Expected resume to return %s, got %q", WorkflowStatusRunning, resumed)
+	}
+	var applicationErr *temporal.ApplicationError
+	if !errors.As(suspendTwiceErr, &applicationErr) || applicationErr.Type() != ExecutionStateErrorType {
+		t.Errorf("Expected a second suspend to be rejected with %s, got %v", ExecutionStateErrorType, suspendTwiceErr)
+	}
+}
+
+func TestResumeRunningExecutionRejected(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	var resumeErr error
+	env.RegisterDelayedCallback(func() {
+		updateExecutionControl(env, ResumeUpdate, nil, &resumeErr)
+	}, 0)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, lifecycleTestWorkflow, nil)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	var applicationErr *temporal.ApplicationError
+	if !errors.As(resumeErr, &applicationErr) || applicationErr.Type() != ExecutionStateErrorType {
+		t.Errorf("Expected resuming a running execution to be rejected with %s, got %v", ExecutionStateErrorType, resumeErr)
+	}
+}
+
+func TestCancelWhileSuspended(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	env.RegisterDelayedCallback(func() {
+		updateExecutionControl(env, SuspendUpdate, nil, nil)
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, lifecycleTestWorkflow, nil)
+
+	if err := env.GetWorkflowError(); !temporal.IsCanceledError(err) {
+		t.Fatalf("Expected cancellation, got %v", err)
+	}
+}
+
+const tryTestWorkflow = `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: try-catch
+  version: 1.0.0
+do:
+  - guarded:
+      try:
+        - first:
+            set:
+              step: 1
+        - broken:
+            if: ${ lenght(.step) > 0 }
+            set:
+              step: 2
+      catch:
+        as: failure
+        %s
+        do:
+          - compensate:
+              set:
+                compensated: true
+                failureStatus: ${ .failure.status }
+`
+
+func TestTryCatchesTaskError(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     string
+		shouldPass bool
+	}{
+		{"any error", "", true},
+		{"matching filter", "errors: {with: {status: 500}}", true},
+		{"matching when", "when: ${ .failure.instance == \"/do/0/guarded\" }", true},
+		{"non-matching filter", "errors: {with: {status: 400}}", false},
+		{"exceptWhen", "exceptWhen: ${ .step == 1 }", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+			env.RegisterActivity(EvaluateValueActivity)
+			env.RegisterActivity(EvaluateExpressionActivity)
+
+			env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, fmt.Sprintf(tryTestWorkflow, tt.filter), nil)
+
+			err := env.GetWorkflowError()
+			if !tt.shouldPass {
+				if err == nil {
+					t.Fatal("Expected the uncaught error to fail the workflow")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Workflow failed: %v", err)
+			}
+			var result map[string]interface{}
+			if err := env.GetWorkflowResult(&result); err != nil {
+				t.Fatalf("Failed to decode result: %v", err)
+			}
+			if result["compensated"] != true || result["failureStatus"] != float64(500) {
+				t.Errorf("Expected the catch tasks to run with the error, got %v", result)
+			}
+			if _, ok := result["failure"]; ok {
+				t.Error("Expected the caught error to stay out of the workflow state")
+			}
+		})
+	}
+}
+
+func TestCancelRunsCatchTasks(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ExecuteServerlessYAMLWorkflow)
+	env.RegisterActivity(EvaluateValueActivity)
+
+	// Suspends inside the try, once its first task has run
+	suspended := false
+	env.SetOnActivityCompletedListener(func(info *activity.Info, result converter.EncodedValue, err error) {
+		if !suspended {
+			suspended = true
+			updateExecutionControl(env, SuspendUpdate, nil, nil)
+		}
+	})
+	env.RegisterDelayedCallback(func() {
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(ExecuteServerlessYAMLWorkflow, `
+document:
+  dsl: 1.0.0
+  namespace: test
+  name: cancel-compensation
+  version: 1.0.0
+do:
+  - guarded:
+      try:
+        - first:
+            set:
+              step: 1
+        - second:
+            set:
+              step: 2
+        - third:
+            set:
+              step: 3
+      catch:
+        do:
+          - compensate:
+              set:
+                compensated: true
+`, nil)
+
+	if err := env.GetWorkflowError(); !temporal.IsCanceledError(err) {
+		t.Fatalf("Expected cancellation, got %v", err)
+	}
+
+	encoded, err := env.QueryWorkflow("get-workflow-state")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var state WorkflowState
+	if err := encoded.Get(&state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if state.Status != WorkflowStatusCancelled {
+		t.Errorf("Expected status %s, got %s", WorkflowStatusCancelled, state.Status)
+	}
+	compensate := state.Tasks["/do/0/guarded/catch/do/0/compensate"]
+	if compensate == nil || compensate.Status != TaskStatusCompleted {
+		t.Errorf("Expected the catch task to complete before the cancellation was recorded, got %+v", compensate)
+	}
+}